@@ -0,0 +1,232 @@
+// cmd/eval/main.go
+// Offline relevance evaluation of the whole answer pipeline
+//
+// Run the question set against Gemini and Toolbox and record every response
+// (needs GEMINI_API_KEY and Toolbox):
+//
+//	go run ./cmd/eval -record
+//
+// Then replay the recordings through the agent (offline) and write
+// eval-report.json and .md:
+//
+//	go run ./cmd/eval -out eval-report
+//
+// Replay runs the current code on the recorded responses, so a change to
+// merging, extraction or scoring shows up offline; a changed prompt or
+// keywords misses the recordings and needs -record. The eval fails when a
+// rule (recall, verbatim, speaker or volume) was never scored. Reports are
+// ignored by git: run the eval on two commits and diff their reports.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	prophetagent "github.com/temple-square/prophet-agent/internal/agent"
+)
+
+// evalSet is the question set with its labels and correctness rules.
+type evalSet struct {
+	Rules     rules      `json:"rules"`
+	Questions []question `json:"questions"`
+}
+
+// question is one evaluation question. Relevant lists talk or verse IDs, or
+// labels ("Speaker: Title", "Book 3:5") where IDs aren't known.
+type question struct {
+	Question string   `json:"question"`
+	Relevant []string `json:"relevant"`
+}
+
+// rules constrain which volumes a search agent may return. Keys are search
+// agent name prefixes, e.g. "scriptures_bom". Speaker rules come from the
+// roster (see speakerRulesAt).
+type rules struct {
+	Volumes map[string][]string `json:"volumes"`
+}
+
+// recording is one agent run, summary included.
+type recording struct {
+	Question   string
+	RecordedAt time.Time // when its responses were recorded
+	Searches   []prophetagent.SearchTrace
+	FirstCard  map[string]time.Duration // by section
+	Summary    string
+	Total      time.Duration
+	Error      string
+}
+
+func main() {
+	setPath := flag.String("questions", "cmd/eval/fixtures/questions.json", "question set with labels and rules")
+	recordingsPath := flag.String("recordings", "cmd/eval/fixtures/recordings.json", "recorded Gemini and Toolbox responses")
+	record := flag.Bool("record", false, "run against Gemini and Toolbox and (re)write the recordings")
+	out := flag.String("out", "eval-report", "report path prefix (.json and .md are added)")
+	flag.Parse()
+
+	data, err := os.ReadFile(*setPath)
+	if err != nil {
+		log.Fatalf("Failed to read question set: %v", err)
+	}
+	var set evalSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		log.Fatalf("Failed to parse question set: %v", err)
+	}
+
+	// Every Gemini and Toolbox request goes through the recorder or replayer
+	cfg := prophetagent.Config{SpeakersRefresh: -1}
+	var rec *recorder
+	var replay *replayer
+	recordedAt := time.Now().UTC()
+	if *record {
+		rec = &recorder{base: http.DefaultTransport.(*http.Transport).Clone()}
+		cfg.Transport = rec
+	} else {
+		data, err := os.ReadFile(*recordingsPath)
+		if err != nil {
+			log.Fatalf("No recordings at %s (run with -record first): %v", *recordingsPath, err)
+		}
+		var c cassette
+		if err := json.Unmarshal(data, &c); err != nil {
+			log.Fatalf("Failed to parse recordings: %v", err)
+		}
+		log.Printf("Replaying %d responses recorded %s from %s", len(c.Exchanges), c.RecordedAt.Format(time.DateOnly), c.Source)
+		replay = newReplayer(&c)
+		recordedAt = c.RecordedAt
+		cfg.Transport = replay
+		cfg.APIKey = "replay" // never sent anywhere
+	}
+
+	ctx := context.Background()
+	agent, err := prophetagent.New(ctx, cfg)
+	if err != nil {
+		log.Fatalf("Failed to create agent: %v", err)
+	}
+	recs := runQuestions(ctx, agent, set.Questions, recordedAt)
+
+	if rec != nil {
+		c := rec.cassette("Gemini "+prophetagent.DefaultModel+" and Toolbox", recordedAt)
+		data, _ := json.MarshalIndent(c, "", "  ")
+		if err := os.WriteFile(*recordingsPath, append(data, '\n'), 0o644); err != nil {
+			log.Fatalf("Failed to write recordings: %v", err)
+		}
+		log.Printf("Wrote %d responses to %s", len(c.Exchanges), *recordingsPath)
+	}
+	if replay != nil {
+		if misses := replay.Misses(); len(misses) > 0 {
+			log.Printf("%d requests weren't recorded (prompts or keywords changed?), re-record with -record; first: %s", len(misses), misses[0])
+		}
+	}
+
+	rep := score(set, recs, agent.Speakers())
+	jsonData, _ := json.MarshalIndent(rep, "", "  ")
+	if err := os.WriteFile(*out+".json", append(jsonData, '\n'), 0o644); err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+	f, err := os.Create(*out + ".md")
+	if err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+	defer f.Close()
+	writeMarkdown(f, rep)
+	log.Printf("Wrote %s.json and %s.md", *out, *out)
+	if unscored := rep.Summary.unscored(); len(unscored) > 0 {
+		log.Fatalf("Never scored %s: no run produced cards these rules apply to (recordings without quotes or verses?)", strings.Join(unscored, ", "))
+	}
+}
+
+// runQuestions runs each question through the agent and then the summary,
+// one at a time so latencies aren't skewed by the other questions.
+func runQuestions(ctx context.Context, agent *prophetagent.ProphetAgent, questions []question, recordedAt time.Time) []recording {
+	var recs []recording
+	for _, q := range questions {
+		rec := recording{Question: q.Question, RecordedAt: recordedAt, FirstCard: map[string]time.Duration{}}
+		var mu sync.Mutex
+		runCtx := prophetagent.WithSearchObserver(ctx, func(trace prophetagent.SearchTrace) {
+			mu.Lock()
+			defer mu.Unlock()
+			rec.Searches = append(rec.Searches, trace)
+		})
+		runCtx, cancel := agent.WithSessionBudget(runCtx)
+
+		start := time.Now()
+		var cards answerCards
+		for result := range agent.Run(runCtx, q.Question) {
+			if result.Error != nil && result.Section == "" {
+				rec.Error = result.Error.Error()
+			}
+			if result.Done || result.Content == "" {
+				continue
+			}
+			if _, ok := rec.FirstCard[result.Section]; !ok {
+				rec.FirstCard[result.Section] = time.Since(start)
+			}
+			if !result.Partial {
+				cards.add(result.Section, result.Content)
+			}
+		}
+		if rec.Error == "" {
+			summary, err := cards.summarize(runCtx, agent, q.Question)
+			if err != nil {
+				rec.Error = "summary: " + err.Error()
+			}
+			rec.Summary = summary
+		}
+		rec.Total = time.Since(start)
+		cancel()
+		sort.Slice(rec.Searches, func(i, j int) bool { return rec.Searches[i].Agent < rec.Searches[j].Agent })
+
+		log.Printf("%6dms %d searches %q", rec.Total.Milliseconds(), len(rec.Searches), q.Question)
+		recs = append(recs, rec)
+	}
+	return recs
+}
+
+// answerCards collects a run's cards for the summary.
+type answerCards struct {
+	presidents, leaders []prophetagent.StructuredQuote
+	scriptures          []prophetagent.StructuredScripture
+}
+
+func (c *answerCards) add(section, content string) {
+	quotes, scriptures := parseCards(content)
+	switch section {
+	case prophetagent.SectionPresidents:
+		c.presidents = append(c.presidents, quotes...)
+	case prophetagent.SectionLeaders:
+		c.leaders = append(c.leaders, quotes...)
+	case prophetagent.SectionScriptures:
+		c.scriptures = append(c.scriptures, scriptures...)
+	}
+}
+
+// summarize generates the summary from the cards. They arrive in whatever
+// order the searches finish, so they're sorted first: the same cards must
+// make the same request for the recording to replay.
+func (c *answerCards) summarize(ctx context.Context, agent *prophetagent.ProphetAgent, question string) (string, error) {
+	for _, quotes := range [][]prophetagent.StructuredQuote{c.presidents, c.leaders} {
+		sort.SliceStable(quotes, func(i, j int) bool {
+			return quotes[i].Speaker+quotes[i].Quote < quotes[j].Speaker+quotes[j].Quote
+		})
+	}
+	sort.SliceStable(c.scriptures, func(i, j int) bool { return c.scriptures[i].Reference < c.scriptures[j].Reference })
+
+	content, err := agent.GenerateSummary(ctx, question, c.presidents, c.leaders, c.scriptures, nil)
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		Summary []string `json:"summary"`
+	}
+	if err := json.Unmarshal([]byte(content), &resp); err != nil {
+		return "", err
+	}
+	return strings.Join(resp.Summary, "\n\n"), nil
+}
@@ -0,0 +1,232 @@
+// cmd/eval/replay.go
+// Recording and replay of the Gemini and Toolbox HTTP exchanges
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cassette is every HTTP exchange of a recorded run of the question set.
+type cassette struct {
+	Source     string     `json:"source"` // what answered the requests
+	RecordedAt time.Time  `json:"recorded_at"`
+	Exchanges  []exchange `json:"exchanges"`
+}
+
+// exchange is one request and the response it got. Requests are matched by
+// method, path and body, so a changed prompt or changed keywords miss.
+type exchange struct {
+	Key     string        `json:"key"`
+	Request string        `json:"request"` // method and path, for reading the file
+	Status  int           `json:"status"`
+	Body    string        `json:"body"`
+	Error   string        `json:"error,omitempty"` // the request failed without a response
+	Headers time.Duration `json:"headers"`         // until the response headers
+	Total   time.Duration `json:"total"`           // until the body was read
+}
+
+// requestKey reads req's body, leaving it readable, and returns the key
+// the exchange is stored under and a readable label. The host is left out
+// so a recording replays against any TOOLBOX_URL.
+func requestKey(req *http.Request) (key, label string, err error) {
+	var body []byte
+	if req.Body != nil {
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return "", "", err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	label = req.Method + " " + req.URL.RequestURI()
+	sum := sha256.Sum256(body)
+	return label + " " + hex.EncodeToString(sum[:12]), label, nil
+}
+
+// recorder passes requests to base and keeps every exchange.
+type recorder struct {
+	base http.RoundTripper
+
+	mu        sync.Mutex
+	exchanges []exchange
+}
+
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	key, label, err := requestKey(req)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	resp, err := r.base.RoundTrip(req)
+	if err != nil {
+		r.add(exchange{Key: key, Request: label, Error: err.Error(), Headers: time.Since(start), Total: time.Since(start)})
+		return nil, err
+	}
+	ex := exchange{Key: key, Request: label, Status: resp.StatusCode, Headers: time.Since(start)}
+
+	// The body is kept as it's read, so streamed responses still stream
+	resp.Body = &teeBody{ReadCloser: resp.Body, done: func(body []byte) {
+		ex.Body = string(body)
+		ex.Total = time.Since(start)
+		r.add(ex)
+	}}
+	return resp, nil
+}
+
+func (r *recorder) add(ex exchange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.exchanges = append(r.exchanges, ex)
+}
+
+// cassette returns the exchanges recorded so far.
+func (r *recorder) cassette(source string, recordedAt time.Time) *cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &cassette{Source: source, RecordedAt: recordedAt, Exchanges: append([]exchange(nil), r.exchanges...)}
+}
+
+// teeBody copies a response body as it's read and hands the copy to done
+// when the body is closed.
+type teeBody struct {
+	io.ReadCloser
+	buf  bytes.Buffer
+	once sync.Once
+	done func([]byte)
+}
+
+func (t *teeBody) Read(p []byte) (int, error) {
+	n, err := t.ReadCloser.Read(p)
+	t.buf.Write(p[:n])
+	return n, err
+}
+
+func (t *teeBody) Close() error {
+	err := t.ReadCloser.Close()
+	t.once.Do(func() { t.done(t.buf.Bytes()) })
+	return err
+}
+
+// replayer answers requests from a cassette, taking as long as the
+// recorded responses did so latencies stay comparable. Identical requests
+// get their recorded responses in order, the last one repeating.
+type replayer struct {
+	mu     sync.Mutex
+	byKey  map[string][]exchange
+	served map[string]int
+	misses []string
+}
+
+func newReplayer(c *cassette) *replayer {
+	p := &replayer{byKey: map[string][]exchange{}, served: map[string]int{}}
+	for _, ex := range c.Exchanges {
+		p.byKey[ex.Key] = append(p.byKey[ex.Key], ex)
+	}
+	return p
+}
+
+func (p *replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	key, label, err := requestKey(req)
+	if err != nil {
+		return nil, err
+	}
+	ex, ok := p.next(key, label)
+	if !ok {
+		return nil, fmt.Errorf("no recorded response for %s (run with -record)", label)
+	}
+
+	if err := sleep(req.Context(), ex.Headers); err != nil {
+		return nil, err
+	}
+	if ex.Error != "" {
+		return nil, fmt.Errorf("%s", ex.Error)
+	}
+	return &http.Response{
+		StatusCode: ex.Status,
+		Status:     fmt.Sprintf("%d %s", ex.Status, http.StatusText(ex.Status)),
+		Header:     http.Header{},
+		Body:       newPacedBody(req.Context(), ex.Body, ex.Total-ex.Headers),
+		Request:    req,
+	}, nil
+}
+
+func (p *replayer) next(key, label string) (exchange, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	recorded := p.byKey[key]
+	if len(recorded) == 0 {
+		p.misses = append(p.misses, label)
+		return exchange{}, false
+	}
+	i := min(p.served[key], len(recorded)-1)
+	p.served[key]++
+	return recorded[i], true
+}
+
+// Misses returns the requests that had no recorded response.
+func (p *replayer) Misses() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.misses...)
+}
+
+// pacedBody returns a recorded body one SSE event at a time, spread over
+// the time the body originally took, so streamed cards arrive as they did.
+type pacedBody struct {
+	ctx    context.Context
+	chunks []string
+	gap    time.Duration
+	cur    *strings.Reader
+}
+
+func newPacedBody(ctx context.Context, body string, d time.Duration) *pacedBody {
+	chunks := strings.SplitAfter(body, "\n\n")
+	if chunks[len(chunks)-1] == "" {
+		chunks = chunks[:len(chunks)-1]
+	}
+	var gap time.Duration
+	if len(chunks) > 0 && d > 0 {
+		gap = d / time.Duration(len(chunks))
+	}
+	return &pacedBody{ctx: ctx, chunks: chunks, gap: gap, cur: strings.NewReader("")}
+}
+
+func (b *pacedBody) Read(p []byte) (int, error) {
+	for b.cur.Len() == 0 {
+		if len(b.chunks) == 0 {
+			return 0, io.EOF
+		}
+		if err := sleep(b.ctx, b.gap); err != nil {
+			return 0, err
+		}
+		b.cur = strings.NewReader(b.chunks[0])
+		b.chunks = b.chunks[1:]
+	}
+	return b.cur.Read(p)
+}
+
+func (b *pacedBody) Close() error { return nil }
+
+// sleep waits for d or until ctx ends.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
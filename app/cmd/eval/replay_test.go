@@ -0,0 +1,89 @@
+// cmd/eval/replay_test.go
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// post sends body to url through rt and returns the response body.
+func post(t *testing.T, rt http.RoundTripper, url, body string) (string, error) {
+	t.Helper()
+	resp, err := (&http.Client{Transport: rt}).Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	return string(b), err
+}
+
+// TestRecordAndReplay verifies a recording replays against another host,
+// identical requests get their responses in order, and changed requests miss
+func TestRecordAndReplay(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		fmt.Fprintf(w, "data: %s\n\ndata: call %d\n\n", body, n)
+	}))
+	defer srv.Close()
+
+	rec := &recorder{base: http.DefaultTransport}
+	var recorded []string
+	for _, body := range []string{"a", "a", "b"} {
+		got, err := post(t, rec, srv.URL+"/tool/invoke", body)
+		if err != nil {
+			t.Fatalf("Record %s: %v", body, err)
+		}
+		recorded = append(recorded, got)
+	}
+	c := rec.cassette("test", time.Now())
+	if len(c.Exchanges) != 3 || c.Exchanges[0].Request != "POST /tool/invoke" {
+		t.Fatalf("Exchanges = %+v", c.Exchanges)
+	}
+
+	p := newReplayer(c)
+	for i, body := range []string{"a", "a", "b", "a"} {
+		got, err := post(t, p, "http://elsewhere.test/tool/invoke", body)
+		if err != nil {
+			t.Fatalf("Replay %d: %v", i, err)
+		}
+		// The last response recorded for "a" repeats
+		want := []string{recorded[0], recorded[1], recorded[2], recorded[1]}[i]
+		if got != want {
+			t.Errorf("Replay %d = %q, want %q", i, got, want)
+		}
+	}
+	if calls.Load() != 3 {
+		t.Errorf("Expected replay not to reach the server, got %d calls", calls.Load())
+	}
+
+	if _, err := post(t, p, "http://elsewhere.test/tool/invoke", "c"); err == nil || !strings.Contains(err.Error(), "-record") {
+		t.Errorf("Expected a miss for a changed body, got %v", err)
+	}
+	if misses := p.Misses(); len(misses) != 1 || misses[0] != "POST /tool/invoke" {
+		t.Errorf("Misses = %v", misses)
+	}
+}
+
+// TestPacedBody verifies a replayed stream keeps its events and takes about
+// as long as it was recorded taking
+func TestPacedBody(t *testing.T) {
+	body := "data: 1\n\ndata: 2\n\ndata: 3\n\n"
+	start := time.Now()
+	b := newPacedBody(t.Context(), body, 30*time.Millisecond)
+	got, err := io.ReadAll(b)
+	if err != nil || string(got) != body {
+		t.Errorf("Body = %q, %v; want %q", got, err, body)
+	}
+	if d := time.Since(start); d < 30*time.Millisecond {
+		t.Errorf("Body took %v, want at least 30ms", d)
+	}
+}
@@ -0,0 +1,414 @@
+// cmd/eval/score.go
+// Scoring and report output for the evaluation harness
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	prophetagent "github.com/temple-square/prophet-agent/internal/agent"
+)
+
+// report is the diffable evaluation result. Ratios are nil when nothing
+// was measurable (e.g. a question without labels has no recall).
+type report struct {
+	Summary   summary         `json:"summary"`
+	Questions []questionScore `json:"questions"`
+}
+
+type summary struct {
+	Questions       int              `json:"questions"`
+	Errors          int              `json:"errors"`
+	Summarized      *float64         `json:"summarized"`
+	Recall          *float64         `json:"recall"`
+	Verbatim        *float64         `json:"verbatim"`
+	SpeakerCorrect  *float64         `json:"speaker_correct"`
+	VolumeCorrect   *float64         `json:"volume_correct"`
+	FirstCardP50MS  map[string]int64 `json:"first_card_p50_ms"`
+	FirstCardP95MS  map[string]int64 `json:"first_card_p95_ms"`
+	TotalP50MS      int64            `json:"total_p50_ms"`
+	TotalP95MS      int64            `json:"total_p95_ms"`
+	SearchP50MS     map[string]int64 `json:"search_p50_ms"`
+	ViolationCounts map[string]int   `json:"violation_counts"`
+}
+
+type questionScore struct {
+	Question       string           `json:"question"`
+	Error          string           `json:"error,omitempty"`
+	Summarized     bool             `json:"summarized"`
+	Recall         *float64         `json:"recall"`
+	Missed         []string         `json:"missed,omitempty"`
+	Cards          int              `json:"cards"`
+	Verbatim       *float64         `json:"verbatim"`
+	SpeakerCorrect *float64         `json:"speaker_correct"`
+	VolumeCorrect  *float64         `json:"volume_correct"`
+	FirstCardMS    map[string]int64 `json:"first_card_ms"`
+	TotalMS        int64            `json:"total_ms"`
+	Violations     []string         `json:"violations,omitempty"`
+}
+
+// ratio accumulates a hits/total fraction.
+type ratio struct{ hits, total int }
+
+func (r *ratio) add(ok bool) {
+	r.total++
+	if ok {
+		r.hits++
+	}
+}
+
+func (r ratio) value() *float64 {
+	if r.total == 0 {
+		return nil
+	}
+	v := float64(r.hits) / float64(r.total)
+	return &v
+}
+
+// score scores the runs. Speaker rules are taken from speakers' roster on
+// the date each run was recorded.
+func score(set evalSet, recs []recording, speakers *prophetagent.SpeakerRegistry) report {
+	byQuestion := map[string]question{}
+	for _, q := range set.Questions {
+		byQuestion[q.Question] = q
+	}
+
+	rep := report{Summary: summary{
+		FirstCardP50MS:  map[string]int64{},
+		FirstCardP95MS:  map[string]int64{},
+		SearchP50MS:     map[string]int64{},
+		ViolationCounts: map[string]int{},
+	}}
+	var recall, verbatim, speakerCorrect, volumes, summarized ratio
+	var totals []time.Duration
+	firstCards := map[string][]time.Duration{}
+	searchDurations := map[string][]time.Duration{}
+
+	for _, rec := range recs {
+		qs := questionScore{Question: rec.Question, Error: rec.Error, Summarized: rec.Summary != "", FirstCardMS: map[string]int64{}, TotalMS: rec.Total.Milliseconds()}
+		summarized.add(qs.Summarized)
+		allow, deny := speakerRulesAt(speakers, rec.RecordedAt)
+		rep.Summary.Questions++
+		if rec.Error != "" {
+			rep.Summary.Errors++
+		}
+		totals = append(totals, rec.Total)
+		for section, d := range rec.FirstCard {
+			qs.FirstCardMS[section] = d.Milliseconds()
+			firstCards[section] = append(firstCards[section], d)
+		}
+
+		// Retrieval recall over every row any search handed its formatter
+		retrieved := map[string]bool{}
+		for _, s := range rec.Searches {
+			for _, row := range s.Rows {
+				retrieved[normalize(row.ID)] = true
+				retrieved[normalize(row.Label)] = true
+			}
+		}
+		var qRecall ratio
+		for _, want := range byQuestion[rec.Question].Relevant {
+			found := retrieved[normalize(want)]
+			qRecall.add(found)
+			recall.add(found)
+			if !found {
+				qs.Missed = append(qs.Missed, want)
+			}
+		}
+		qs.Recall = qRecall.value()
+
+		var qVerbatim, qSpeakers, qVolumes ratio
+		for _, s := range rec.Searches {
+			searchDurations[searchGroup(s.Agent)] = append(searchDurations[searchGroup(s.Agent)], s.Duration)
+			source := normalize(joinRows(s.Rows))
+			quotes, scriptures := parseCards(s.Content)
+			for _, q := range quotes {
+				qs.Cards++
+				ok := strings.Contains(source, normalize(q.Quote))
+				qVerbatim.add(ok)
+				verbatim.add(ok)
+				if !ok {
+					qs.Violations = append(qs.Violations, fmt.Sprintf("%s: quote from %s is not verbatim", s.Agent, q.Speaker))
+				}
+				if allowed, denied := prefixRule(allow, s.Agent), prefixRule(deny, s.Agent); allowed != nil || denied != nil {
+					ok := speakerAllowed(q.Speaker, allowed, denied)
+					qSpeakers.add(ok)
+					speakerCorrect.add(ok)
+					if !ok {
+						qs.Violations = append(qs.Violations, fmt.Sprintf("%s: speaker %s not allowed", s.Agent, q.Speaker))
+					}
+				}
+			}
+			for _, sc := range scriptures {
+				qs.Cards++
+				ok := strings.Contains(source, normalize(sc.Text))
+				qVerbatim.add(ok)
+				verbatim.add(ok)
+				if !ok {
+					qs.Violations = append(qs.Violations, fmt.Sprintf("%s: %s text is not verbatim", s.Agent, sc.Reference))
+				}
+				if allowed := prefixRule(set.Rules.Volumes, s.Agent); allowed != nil {
+					ok := containsFold(allowed, sc.Volume)
+					qVolumes.add(ok)
+					volumes.add(ok)
+					if !ok {
+						qs.Violations = append(qs.Violations, fmt.Sprintf("%s: %s is from %s", s.Agent, sc.Reference, sc.Volume))
+					}
+				}
+			}
+		}
+		qs.Verbatim = qVerbatim.value()
+		qs.SpeakerCorrect = qSpeakers.value()
+		qs.VolumeCorrect = qVolumes.value()
+		sort.Strings(qs.Violations)
+		for _, v := range qs.Violations {
+			rep.Summary.ViolationCounts[violationKind(v)]++
+		}
+		rep.Questions = append(rep.Questions, qs)
+	}
+
+	rep.Summary.Recall = recall.value()
+	rep.Summary.Verbatim = verbatim.value()
+	rep.Summary.SpeakerCorrect = speakerCorrect.value()
+	rep.Summary.Summarized = summarized.value()
+	rep.Summary.VolumeCorrect = volumes.value()
+	rep.Summary.TotalP50MS = percentile(totals, 50)
+	rep.Summary.TotalP95MS = percentile(totals, 95)
+	for section, ds := range firstCards {
+		rep.Summary.FirstCardP50MS[section] = percentile(ds, 50)
+		rep.Summary.FirstCardP95MS[section] = percentile(ds, 95)
+	}
+	for group, ds := range searchDurations {
+		rep.Summary.SearchP50MS[group] = percentile(ds, 50)
+	}
+	sort.Slice(rep.Questions, func(i, j int) bool { return rep.Questions[i].Question < rep.Questions[j].Question })
+	return rep
+}
+
+// unscored lists the rule columns no run was scored on, e.g. speaker rules
+// when no search returned a quote. A report missing one says nothing about
+// that rule, so the eval fails rather than passing it.
+func (s summary) unscored() []string {
+	var out []string
+	for _, col := range []struct {
+		name  string
+		value *float64
+	}{
+		{"recall", s.Recall},
+		{"verbatim", s.Verbatim},
+		{"speaker_correct", s.SpeakerCorrect},
+		{"volume_correct", s.VolumeCorrect},
+	} {
+		if col.value == nil {
+			out = append(out, col.name)
+		}
+	}
+	return out
+}
+
+// parseCards decodes a search's formatted output.
+func parseCards(content string) ([]prophetagent.StructuredQuote, []prophetagent.StructuredScripture) {
+	var resp struct {
+		Quotes     []prophetagent.StructuredQuote     `json:"quotes"`
+		Scriptures []prophetagent.StructuredScripture `json:"scriptures"`
+	}
+	if json.Unmarshal([]byte(content), &resp) != nil {
+		return nil, nil
+	}
+	return resp.Quotes, resp.Scriptures
+}
+
+func joinRows(rows []prophetagent.TraceRow) string {
+	texts := make([]string, len(rows))
+	for i, row := range rows {
+		texts[i] = row.Text
+	}
+	return strings.Join(texts, "\n")
+}
+
+// normalize folds case, whitespace and typographic punctuation so verbatim
+// checks ignore formatting the LLM may legitimately change.
+var punctuation = strings.NewReplacer("’", "'", "‘", "'", "“", "\"", "”", "\"", "—", "-", "–", "-", "…", "...")
+
+func normalize(s string) string {
+	return strings.Join(strings.Fields(punctuation.Replace(strings.ToLower(s))), " ")
+}
+
+// prefixRule returns the rule for the longest prefix of agent.
+func prefixRule(rules map[string][]string, agent string) []string {
+	best := ""
+	for prefix := range rules {
+		if strings.HasPrefix(agent, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return nil
+	}
+	return rules[best]
+}
+
+// speakerRulesAt returns the speakers each search agent may quote, and
+// those it must not, on date t: the named agents cover one calling each,
+// and the leaders searches leave the First Presidency (and leaders_other
+// the Twelve) to the agents above them. Keys are agent name prefixes.
+func speakerRulesAt(r *prophetagent.SpeakerRegistry, t time.Time) (allow, deny map[string][]string) {
+	allow = map[string][]string{}
+	if president, ok := r.PresidentAt(t); ok {
+		allow["presidents_current"] = names(president)
+	}
+	formers := r.FormerPresidentsAt(t)
+	if len(formers) > 0 {
+		allow["presidents_former"] = names(formers[0])
+	}
+	if presidents := r.PresidentsAt(t); len(presidents) > 0 {
+		allow["presidents_general"] = names(presidents...)
+	}
+	counselors := r.CounselorsAt(t)
+	if len(counselors) > 0 {
+		allow["leaders_first_counselor"] = names(counselors[0])
+	}
+	if len(counselors) > 1 {
+		allow["leaders_second_counselor"] = names(counselors[1])
+	}
+
+	firstPresidency := slices.Concat(r.MembersAt(prophetagent.QuorumFirstPresidency, t), formers)
+	deny = map[string][]string{
+		"leaders_q12":   names(firstPresidency...),
+		"leaders_other": names(slices.Concat(firstPresidency, r.MembersAt(prophetagent.QuorumTwelve, t))...),
+	}
+	return allow, deny
+}
+
+// names lists the speakers' names for speakerAllowed.
+func names(speakers ...prophetagent.Speaker) []string {
+	var out []string
+	for _, sp := range speakers {
+		out = append(out, sp.Name)
+	}
+	return out
+}
+
+// speakerAllowed matches names loosely, so "President Dallin H. Oaks"
+// matches "Dallin H. Oaks".
+func speakerAllowed(speaker string, allowed, denied []string) bool {
+	matches := func(names []string) bool {
+		s := normalize(speaker)
+		for _, name := range names {
+			if strings.Contains(s, normalize(name)) {
+				return true
+			}
+		}
+		return false
+	}
+	if matches(denied) {
+		return false
+	}
+	return allowed == nil || matches(allowed)
+}
+
+func containsFold(values []string, v string) bool {
+	for _, x := range values {
+		if strings.EqualFold(x, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// searchGroup maps a search agent to its family, e.g. leaders_other_a to
+// leaders_other.
+func searchGroup(agent string) string {
+	if strings.HasSuffix(agent, "_a") || strings.HasSuffix(agent, "_b") {
+		return agent[:len(agent)-2]
+	}
+	return agent
+}
+
+func violationKind(v string) string {
+	switch {
+	case strings.Contains(v, "not verbatim"):
+		return "not_verbatim"
+	case strings.Contains(v, "not allowed"):
+		return "wrong_speaker"
+	default:
+		return "wrong_volume"
+	}
+}
+
+func percentile(ds []time.Duration, p int) int64 {
+	if len(ds) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), ds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	idx := (len(sorted)*p + 99) / 100
+	if idx > 0 {
+		idx--
+	}
+	return sorted[idx].Milliseconds()
+}
+
+func writeMarkdown(w io.Writer, rep report) {
+	s := rep.Summary
+	fmt.Fprintf(w, "# Evaluation report\n\n")
+	fmt.Fprintf(w, "| metric | value |\n|---|---|\n")
+	fmt.Fprintf(w, "| questions | %d |\n| errors | %d |\n| summarized | %s |\n", s.Questions, s.Errors, pct(s.Summarized))
+	fmt.Fprintf(w, "| retrieval recall | %s |\n| verbatim quotes | %s |\n", pct(s.Recall), pct(s.Verbatim))
+	fmt.Fprintf(w, "| speaker correct | %s |\n| volume correct | %s |\n", pct(s.SpeakerCorrect), pct(s.VolumeCorrect))
+	fmt.Fprintf(w, "| total p50 / p95 | %dms / %dms |\n", s.TotalP50MS, s.TotalP95MS)
+	for _, section := range sortedKeys(s.FirstCardP50MS) {
+		fmt.Fprintf(w, "| first %s card p50 / p95 | %dms / %dms |\n", section, s.FirstCardP50MS[section], s.FirstCardP95MS[section])
+	}
+	for _, group := range sortedKeys(s.SearchP50MS) {
+		fmt.Fprintf(w, "| %s search p50 | %dms |\n", group, s.SearchP50MS[group])
+	}
+
+	fmt.Fprintf(w, "\n## Questions\n\n")
+	fmt.Fprintf(w, "| question | recall | cards | verbatim | speaker | volume | total |\n|---|---|---|---|---|---|---|\n")
+	for _, q := range rep.Questions {
+		fmt.Fprintf(w, "| %s | %s | %d | %s | %s | %s | %dms |\n",
+			q.Question, pct(q.Recall), q.Cards, pct(q.Verbatim), pct(q.SpeakerCorrect), pct(q.VolumeCorrect), q.TotalMS)
+	}
+
+	fmt.Fprintf(w, "\n## Issues\n\n")
+	issues := 0
+	for _, q := range rep.Questions {
+		if q.Error != "" {
+			fmt.Fprintf(w, "- %s: error: %s\n", q.Question, q.Error)
+			issues++
+		}
+		for _, m := range q.Missed {
+			fmt.Fprintf(w, "- %s: not retrieved: %s\n", q.Question, m)
+			issues++
+		}
+		for _, v := range q.Violations {
+			fmt.Fprintf(w, "- %s: %s\n", q.Question, v)
+			issues++
+		}
+	}
+	if issues == 0 {
+		fmt.Fprintf(w, "None.\n")
+	}
+}
+
+func pct(v *float64) string {
+	if v == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%%", 100**v)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,181 @@
+// cmd/eval/score_test.go
+package main
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	prophetagent "github.com/temple-square/prophet-agent/internal/agent"
+)
+
+// TestScoreRecallVerbatimAndVolumes verifies recall against labels, verbatim
+// checks that ignore typography, and scripture volume rules
+func TestScoreRecallVerbatimAndVolumes(t *testing.T) {
+	set := evalSet{
+		Rules: rules{Volumes: map[string][]string{"scriptures_bom": {"Book of Mormon"}}},
+		Questions: []question{
+			{Question: "What is faith?", Relevant: []string{"Alma 32:21", "Ether 12:6"}},
+		},
+	}
+	recs := []recording{{
+		Question:  "What is faith?",
+		Summary:   "Faith is hope in things not seen.",
+		FirstCard: map[string]time.Duration{prophetagent.SectionScriptures: 2 * time.Second},
+		Total:     5 * time.Second,
+		Searches: []prophetagent.SearchTrace{{
+			Agent: "scriptures_bom",
+			Rows: []prophetagent.TraceRow{
+				{ID: "alma-32-21", Label: "Alma 32:21", Text: "faith is not to have a perfect knowledge of things"},
+				{ID: "john-3-16", Label: "John 3:16", Text: "For God so loved the world"},
+			},
+			Content: `{"scriptures": [
+				{"volume": "Book of Mormon", "reference": "Alma 32:21", "text": "Faith is not to have a  perfect knowledge of things"},
+				{"volume": "New Testament", "reference": "John 3:16", "text": "For God so loved the world"},
+				{"volume": "Book of Mormon", "reference": "Ether 12:6", "text": "faith is things which are hoped for"}
+			]}`,
+			Duration: time.Second,
+		}},
+	}}
+
+	rep := score(set, recs, prophetagent.NewSpeakerRegistry())
+	s := rep.Summary
+	if s.Questions != 1 || s.Errors != 0 || *s.Summarized != 1 {
+		t.Errorf("Summary = %+v", s)
+	}
+	if *s.Recall != 0.5 {
+		t.Errorf("Recall = %v, want 0.5", *s.Recall)
+	}
+	if got := *s.Verbatim; got < 0.66 || got > 0.67 {
+		t.Errorf("Verbatim = %v, want 2/3", got)
+	}
+	if got := *s.VolumeCorrect; got < 0.66 || got > 0.67 {
+		t.Errorf("VolumeCorrect = %v, want 2/3", got)
+	}
+	if s.SpeakerCorrect != nil {
+		t.Errorf("SpeakerCorrect = %v, want nil without quotes", *s.SpeakerCorrect)
+	}
+	if got := s.unscored(); !slices.Equal(got, []string{"speaker_correct"}) {
+		t.Errorf("unscored = %v, want [speaker_correct]", got)
+	}
+	if s.FirstCardP50MS[prophetagent.SectionScriptures] != 2000 || s.TotalP50MS != 5000 || s.SearchP50MS["scriptures_bom"] != 1000 {
+		t.Errorf("Latencies = %+v", s)
+	}
+	if s.ViolationCounts["not_verbatim"] != 1 || s.ViolationCounts["wrong_volume"] != 1 {
+		t.Errorf("ViolationCounts = %v", s.ViolationCounts)
+	}
+
+	q := rep.Questions[0]
+	if q.Cards != 3 || !q.Summarized || !slices.Equal(q.Missed, []string{"Ether 12:6"}) {
+		t.Errorf("Question = %+v", q)
+	}
+}
+
+// TestScoreSpeakerRules verifies quotes are checked against the roster on
+// the date the run was recorded
+func TestScoreSpeakerRules(t *testing.T) {
+	set := evalSet{Questions: []question{{Question: "Q"}}}
+	quote := func(agent, speaker string) prophetagent.SearchTrace {
+		return prophetagent.SearchTrace{
+			Agent:   agent,
+			Rows:    []prophetagent.TraceRow{{Text: "quote"}},
+			Content: `{"quotes": [{"speaker": "President ` + speaker + `", "title": "T", "quote": "quote"}]}`,
+		}
+	}
+	searches := []prophetagent.SearchTrace{
+		quote("presidents_current", "Dallin H. Oaks"),
+		quote("leaders_first_counselor", "Henry B. Eyring"),
+		quote("leaders_other_a", "D. Todd Christofferson"),
+	}
+
+	// After October 2025 all three are in the First Presidency
+	after := score(set, []recording{{Question: "Q", RecordedAt: time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC), Searches: searches}}, prophetagent.NewSpeakerRegistry())
+	if got := after.Questions[0].Violations; len(got) != 1 || !strings.Contains(got[0], "leaders_other_a: speaker President D. Todd Christofferson") {
+		t.Errorf("Violations after = %v", got)
+	}
+
+	// Before, President Nelson led the Church and Elder Christofferson was
+	// in the Twelve
+	before := score(set, []recording{{Question: "Q", RecordedAt: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), Searches: searches}}, prophetagent.NewSpeakerRegistry())
+	got := before.Questions[0].Violations
+	if len(got) != 3 {
+		t.Fatalf("Violations before = %v, want 3", got)
+	}
+	for i, want := range []string{"leaders_first_counselor: speaker President Henry B. Eyring", "leaders_other_a: speaker President D. Todd Christofferson", "presidents_current: speaker President Dallin H. Oaks"} {
+		if !strings.Contains(got[i], want) {
+			t.Errorf("Violation %d = %q, want %q", i, got[i], want)
+		}
+	}
+	if before.Summary.ViolationCounts["wrong_speaker"] != 3 {
+		t.Errorf("ViolationCounts = %v", before.Summary.ViolationCounts)
+	}
+}
+
+// TestSpeakerRulesAt verifies the named agents follow the roster
+func TestSpeakerRulesAt(t *testing.T) {
+	allow, deny := speakerRulesAt(prophetagent.NewSpeakerRegistry(), time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC))
+	want := map[string]string{
+		"presidents_current":       "Dallin H. Oaks",
+		"presidents_former":        "Russell M. Nelson",
+		"leaders_first_counselor":  "Henry B. Eyring",
+		"leaders_second_counselor": "D. Todd Christofferson",
+	}
+	for agent, name := range want {
+		if !slices.Equal(allow[agent], []string{name}) {
+			t.Errorf("allow[%s] = %v, want [%s]", agent, allow[agent], name)
+		}
+	}
+	if !slices.Contains(allow["presidents_general"], "Russell M. Nelson") || !slices.Contains(allow["presidents_general"], "Dallin H. Oaks") {
+		t.Errorf("allow[presidents_general] = %v", allow["presidents_general"])
+	}
+	for _, name := range want {
+		if !slices.Contains(deny["leaders_q12"], name) || !slices.Contains(deny["leaders_other"], name) {
+			t.Errorf("Expected %s denied to the leaders searches, got %v", name, deny)
+		}
+	}
+}
+
+// TestScoreHelpers verifies normalization, rule prefixes and percentiles
+func TestScoreHelpers(t *testing.T) {
+	if got := normalize("  “Faith’s”\n  ENDURE—to the end…"); got != `"faith's" endure-to the end...` {
+		t.Errorf("normalize = %q", got)
+	}
+	rules := map[string][]string{"leaders": {"a"}, "leaders_other": {"b"}}
+	if got := prefixRule(rules, "leaders_other_b"); !slices.Equal(got, []string{"b"}) {
+		t.Errorf("prefixRule = %v, want the longest prefix's rule", got)
+	}
+	if got := prefixRule(rules, "scriptures_bom"); got != nil {
+		t.Errorf("prefixRule without a match = %v", got)
+	}
+	for agent, want := range map[string]string{"leaders_q12_a": "leaders_q12", "leaders_other_b": "leaders_other", "scriptures_bom": "scriptures_bom"} {
+		if got := searchGroup(agent); got != want {
+			t.Errorf("searchGroup(%q) = %q, want %q", agent, got, want)
+		}
+	}
+	ds := []time.Duration{4 * time.Second, time.Second, 3 * time.Second, 2 * time.Second}
+	if p50, p95 := percentile(ds, 50), percentile(ds, 95); p50 != 2000 || p95 != 4000 {
+		t.Errorf("percentile = %d, %d; want 2000, 4000", p50, p95)
+	}
+	if percentile(nil, 50) != 0 {
+		t.Error("Expected 0 for no durations")
+	}
+}
+
+// TestWriteMarkdown verifies the report lists every issue, or none
+func TestWriteMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	writeMarkdown(&buf, report{Questions: []questionScore{{Question: "Q", Error: "boom", Missed: []string{"Alma 32:21"}}}})
+	for _, want := range []string{"| questions | 0 |", "- Q: error: boom", "- Q: not retrieved: Alma 32:21"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected %q in the report:\n%s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	writeMarkdown(&buf, report{})
+	if !strings.Contains(buf.String(), "None.") {
+		t.Errorf("Expected no issues:\n%s", buf.String())
+	}
+}
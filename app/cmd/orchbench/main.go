@@ -0,0 +1,295 @@
+// cmd/orchbench/main.go
+// Benchmark harness comparing split and combined orchestrator modes
+//
+// Record real Gemini responses for the fixture questions (needs GEMINI_API_KEY):
+//
+//	go run ./cmd/orchbench -record -runs 3
+//
+// Then compare latency and keyword quality from the recordings (offline):
+//
+//	go run ./cmd/orchbench
+//
+// The committed fixtures/recordings.json is a baseline from the local
+// classifier and the keywords the pipeline falls back to without Gemini, so
+// it has no latency. Record over it for real numbers.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	prophetagent "github.com/temple-square/prophet-agent/internal/agent"
+)
+
+// fixture is a benchmark question with the terms good keywords should contain.
+type fixture struct {
+	Question      string   `json:"question"`
+	Safe          bool     `json:"safe"`
+	ExpectedTerms []string `json:"expected_terms"`
+}
+
+// recording is one orchestrator run captured from Gemini.
+type recording struct {
+	Question   string                        `json:"question"`
+	Mode       prophetagent.OrchestratorMode `json:"mode"`
+	Run        int                           `json:"run"`
+	RecordedAt time.Time                     `json:"recorded_at"`
+	Timings    prophetagent.KeywordTimings   `json:"timings"`
+	Safe       bool                          `json:"safe"`
+	Keywords   map[string]string             `json:"keywords,omitempty"`
+	Error      string                        `json:"error,omitempty"`
+}
+
+func main() {
+	fixturesPath := flag.String("fixtures", "cmd/orchbench/fixtures/questions.json", "fixture questions")
+	recordingsPath := flag.String("recordings", "cmd/orchbench/fixtures/recordings.json", "recorded orchestrator runs")
+	record := flag.Bool("record", false, "call Gemini and (re)write the recordings")
+	runs := flag.Int("runs", 3, "runs per question and mode when recording")
+	flag.Parse()
+
+	fixtures, err := loadFixtures(*fixturesPath)
+	if err != nil {
+		log.Fatalf("Failed to load fixtures: %v", err)
+	}
+
+	if *record {
+		recs, err := recordRuns(fixtures, *runs)
+		if err != nil {
+			log.Fatalf("Recording failed: %v", err)
+		}
+		data, _ := json.MarshalIndent(recs, "", "  ")
+		if err := os.WriteFile(*recordingsPath, append(data, '\n'), 0o644); err != nil {
+			log.Fatalf("Failed to write recordings: %v", err)
+		}
+		log.Printf("Wrote %d recordings to %s", len(recs), *recordingsPath)
+	}
+
+	data, err := os.ReadFile(*recordingsPath)
+	if err != nil {
+		log.Fatalf("No recordings at %s (run with -record first): %v", *recordingsPath, err)
+	}
+	var recs []recording
+	if err := json.Unmarshal(data, &recs); err != nil {
+		log.Fatalf("Failed to parse recordings: %v", err)
+	}
+	report(os.Stdout, fixtures, recs)
+}
+
+func loadFixtures(path string) ([]fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fixtures []fixture
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return nil, err
+	}
+	return fixtures, nil
+}
+
+// recordRuns alternates modes per question so both see the same API conditions.
+func recordRuns(fixtures []fixture, runs int) ([]recording, error) {
+	ctx := context.Background()
+	agents := map[prophetagent.OrchestratorMode]*prophetagent.ProphetAgent{}
+	for _, mode := range []prophetagent.OrchestratorMode{prophetagent.OrchestratorSplit, prophetagent.OrchestratorCombined} {
+		a, err := prophetagent.New(ctx, prophetagent.Config{OrchestratorMode: mode})
+		if err != nil {
+			return nil, err
+		}
+		agents[mode] = a
+	}
+
+	var recs []recording
+	for _, f := range fixtures {
+		for run := 1; run <= runs; run++ {
+			for _, mode := range []prophetagent.OrchestratorMode{prophetagent.OrchestratorSplit, prophetagent.OrchestratorCombined} {
+				resp, timings, err := agents[mode].GenerateKeywords(ctx, f.Question, mode)
+				rec := recording{Question: f.Question, Mode: mode, Run: run, RecordedAt: time.Now().UTC(), Timings: timings}
+				if err != nil {
+					rec.Error = err.Error()
+				} else {
+					rec.Safe = resp.Safe
+					rec.Keywords = resp.Fields()
+				}
+				log.Printf("%-8s run %d %6dms %q", mode, run, timings.AllKeywords.Milliseconds(), f.Question)
+				recs = append(recs, rec)
+			}
+		}
+	}
+	return recs, nil
+}
+
+// modeStats aggregates recordings for one mode.
+type modeStats struct {
+	first, all     []time.Duration
+	calls, errors  int
+	runs           int
+	safetyCorrect  int
+	recallSum      float64
+	recallN        int
+	emptyFields    int
+	totalFields    int
+	keywordsByQues map[string][]map[string]string
+}
+
+func report(w io.Writer, fixtures []fixture, recs []recording) {
+	byQuestion := map[string]fixture{}
+	for _, f := range fixtures {
+		byQuestion[f.Question] = f
+	}
+
+	stats := map[prophetagent.OrchestratorMode]*modeStats{}
+	for _, rec := range recs {
+		s := stats[rec.Mode]
+		if s == nil {
+			s = &modeStats{keywordsByQues: map[string][]map[string]string{}}
+			stats[rec.Mode] = s
+		}
+		s.runs++
+		if rec.Error != "" {
+			s.errors++
+			continue
+		}
+		s.first = append(s.first, rec.Timings.FirstKeywords)
+		s.all = append(s.all, rec.Timings.AllKeywords)
+		s.calls += rec.Timings.Calls
+
+		f, ok := byQuestion[rec.Question]
+		if !ok {
+			continue
+		}
+		if rec.Safe == f.Safe {
+			s.safetyCorrect++
+		}
+		if !f.Safe || !rec.Safe {
+			continue
+		}
+		s.recallSum += termRecall(f.ExpectedTerms, rec.Keywords)
+		s.recallN++
+		for _, kw := range rec.Keywords {
+			s.totalFields++
+			if strings.TrimSpace(kw) == "" {
+				s.emptyFields++
+			}
+		}
+		s.keywordsByQues[rec.Question] = append(s.keywordsByQues[rec.Question], rec.Keywords)
+	}
+
+	modes := []prophetagent.OrchestratorMode{prophetagent.OrchestratorSplit, prophetagent.OrchestratorCombined}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "mode\truns\terrors\tcalls/q\tfirst p50\tfirst p95\tall p50\tall p95\tsafety\trecall\tempty fields")
+	for _, mode := range modes {
+		s := stats[mode]
+		if s == nil {
+			continue
+		}
+		ok := s.runs - s.errors
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f\t%v\t%v\t%v\t%v\t%s\t%s\t%s\n",
+			mode, s.runs, s.errors, ratio(s.calls, ok),
+			percentile(s.first, 50), percentile(s.first, 95),
+			percentile(s.all, 50), percentile(s.all, 95),
+			pct(float64(s.safetyCorrect), float64(ok)),
+			pct(s.recallSum, float64(s.recallN)),
+			pct(float64(s.emptyFields), float64(s.totalFields)))
+	}
+	tw.Flush()
+
+	split, combined := stats[prophetagent.OrchestratorSplit], stats[prophetagent.OrchestratorCombined]
+	if split != nil && combined != nil {
+		var sum float64
+		var n int
+		for q, splitKW := range split.keywordsByQues {
+			for _, a := range splitKW {
+				for _, b := range combined.keywordsByQues[q] {
+					sum += keywordOverlap(a, b)
+					n++
+				}
+			}
+		}
+		fmt.Fprintf(w, "\nsplit/combined keyword overlap (Jaccard over terms): %s\n", pct(sum, float64(n)))
+	}
+}
+
+// termRecall is the fraction of expected terms found in any keyword field.
+func termRecall(expected []string, keywords map[string]string) float64 {
+	if len(expected) == 0 {
+		return 1
+	}
+	all := strings.ToLower(strings.Join(values(keywords), " "))
+	found := 0
+	for _, term := range expected {
+		if strings.Contains(all, strings.ToLower(term)) {
+			found++
+		}
+	}
+	return float64(found) / float64(len(expected))
+}
+
+// keywordOverlap is the Jaccard similarity of the two runs' keyword terms.
+func keywordOverlap(a, b map[string]string) float64 {
+	ta, tb := terms(a), terms(b)
+	if len(ta) == 0 && len(tb) == 0 {
+		return 1
+	}
+	inter := 0
+	for t := range ta {
+		if tb[t] {
+			inter++
+		}
+	}
+	return float64(inter) / float64(len(ta)+len(tb)-inter)
+}
+
+func terms(keywords map[string]string) map[string]bool {
+	set := map[string]bool{}
+	for _, kw := range keywords {
+		for _, t := range strings.Fields(strings.ToLower(kw)) {
+			set[strings.Trim(t, ".,;:\"'")] = true
+		}
+	}
+	return set
+}
+
+func values(m map[string]string) []string {
+	out := make([]string, 0, len(m))
+	for _, v := range m {
+		out = append(out, v)
+	}
+	return out
+}
+
+func percentile(ds []time.Duration, p int) time.Duration {
+	if len(ds) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), ds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	idx := (len(sorted)*p + 99) / 100
+	if idx > 0 {
+		idx--
+	}
+	return sorted[idx].Round(time.Millisecond)
+}
+
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
+
+func pct(num, den float64) string {
+	if den == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%%", 100*num/den)
+}
@@ -0,0 +1,388 @@
+// cmd/server/answers.go
+// Saved answers: each finished session's sections, persisted through the
+// agent's answers toolset and served statically at /a/{id}
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/a-h/templ"
+	"gofr.dev/pkg/gofr"
+	"gofr.dev/pkg/gofr/http/response"
+
+	prophetagent "github.com/temple-square/prophet-agent/internal/agent"
+	"github.com/temple-square/prophet-agent/internal/ui/components"
+	"github.com/temple-square/prophet-agent/internal/ui/layouts"
+)
+
+const (
+	// defaultAnswerTTL is how long a saved answer's link keeps working
+	defaultAnswerTTL = 30 * 24 * time.Hour
+	// defaultAnswerPurgeInterval is how often expired answers are deleted
+	defaultAnswerPurgeInterval = time.Hour
+	// maxMemoryAnswers bounds the in-memory copies; the oldest go first
+	maxMemoryAnswers = 5000
+	// answerStoreTimeout bounds each call to the answers table
+	answerStoreTimeout = 5 * time.Second
+	// ogDescriptionLength is where the share description is cut
+	ogDescriptionLength = 200
+)
+
+// answerSnapshot is the sections a visitor saw for one question. The
+// sections are the persisted payload; the rest are table columns.
+type answerSnapshot struct {
+	ID         string                `json:"-"`
+	Question   string                `json:"-"`
+	AskedAt    time.Time             `json:"asked_at"`
+	Presidents []StructuredQuote     `json:"presidents,omitempty"`
+	Leaders    []StructuredQuote     `json:"leaders,omitempty"`
+	Bible      []StructuredScripture `json:"bible,omitempty"`
+	BOM        []StructuredScripture `json:"bom,omitempty"`
+	Other      []StructuredScripture `json:"other,omitempty"`
+	Summary    []string              `json:"summary,omitempty"`
+	CreatedAt  time.Time             `json:"-"`
+	ExpiresAt  time.Time             `json:"-"` // zero keeps the answer until deleted
+
+	persisted bool // saved to the answers table
+}
+
+func (s *answerSnapshot) empty() bool {
+	return len(s.Presidents)+len(s.Leaders)+len(s.Bible)+len(s.BOM)+len(s.Other)+len(s.Summary) == 0
+}
+
+func (s *answerSnapshot) expired(now time.Time) bool {
+	return !s.ExpiresAt.IsZero() && !now.Before(s.ExpiresAt)
+}
+
+// url is the public link to the saved answer.
+func (s *answerSnapshot) url() string {
+	return publicBaseURL + "/a/" + s.ID
+}
+
+// pageProps converts the snapshot for the answer page, the same way the
+// live stream converts each section.
+func (s *answerSnapshot) pageProps() components.AnswerPageProps {
+	presidents := append([]StructuredQuote(nil), s.Presidents...)
+	sortPresidentsQuotes(presidents)
+	return components.AnswerPageProps{
+		Meta: layouts.Meta{
+			Title:       s.Question,
+			Description: s.description(),
+			URL:         s.url(),
+			Image:       publicBaseURL + "/static/img/og-preview.webp",
+			ImageWidth:  "1200",
+			ImageHeight: "640",
+		},
+		Question:   s.Question,
+		Answered:   components.FormatDate(s.locale(), s.CreatedAt),
+		Presidents: convertQuotesToSpeakers(presidents),
+		Leaders:    convertQuotesToSpeakers(s.Leaders),
+		Bible:      convertStructuredScriptures(s.Bible),
+		BOM:        convertStructuredScriptures(s.BOM),
+		Other:      convertStructuredScriptures(s.Other),
+		Summary:    s.Summary,
+	}
+}
+
+// locale is the language the answer is shown in, its question's.
+func (s *answerSnapshot) locale() string {
+	return string(prophetagent.DetectLanguage(s.Question))
+}
+
+// description is the share preview text: the start of the summary, cut at
+// a word boundary.
+func (s *answerSnapshot) description() string {
+	if len(s.Summary) == 0 {
+		return "Teachings from Church leaders and the scriptures on: " + s.Question
+	}
+	text := s.Summary[0]
+	if utf8.RuneCountInString(text) <= ogDescriptionLength {
+		return text
+	}
+	cut := string([]rune(text)[:ogDescriptionLength])
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,;:.") + "…"
+}
+
+// answerPersister is the answers table, implemented by the agent.
+type answerPersister interface {
+	SaveAnswer(ctx context.Context, ans prophetagent.StoredAnswer) error
+	LoadAnswer(ctx context.Context, id string) (prophetagent.StoredAnswer, bool, error)
+	DeleteAnswer(ctx context.Context, id string) (bool, error)
+	DeleteExpiredAnswers(ctx context.Context) (int, error)
+}
+
+// answerStore saves answers to the answers table and keeps a copy in
+// memory, which serves this instance's answers when the table can't.
+type answerStore struct {
+	persist answerPersister // nil keeps answers in memory only
+	ttl     time.Duration   // zero keeps answers until deleted
+
+	mu     sync.Mutex
+	memory map[string]*answerSnapshot
+	max    int
+	now    func() time.Time
+}
+
+var answers = newAnswerStore(nil, defaultAnswerTTL, maxMemoryAnswers)
+
+func newAnswerStore(persist answerPersister, ttl time.Duration, limit int) *answerStore {
+	return &answerStore{
+		persist: persist,
+		ttl:     ttl,
+		memory:  map[string]*answerSnapshot{},
+		max:     limit,
+		now:     time.Now,
+	}
+}
+
+// initAnswers configures the answer store from ANSWER_TTL and starts the
+// expiry purge every ANSWER_PURGE_INTERVAL.
+func initAnswers(ctx context.Context, persist answerPersister) {
+	ttl := getEnvDuration("ANSWER_TTL", defaultAnswerTTL)
+	if ttl < 0 {
+		ttl = 0
+	}
+	answers = newAnswerStore(persist, ttl, maxMemoryAnswers)
+	if os.Getenv("ANSWERS_ADMIN_KEY") == "" {
+		log.Println("ANSWERS_ADMIN_KEY not set, saved answers can't be deleted on request")
+	}
+	if interval := getEnvDuration("ANSWER_PURGE_INTERVAL", defaultAnswerPurgeInterval); ttl > 0 && interval > 0 {
+		go answers.purgeEvery(ctx, interval)
+	}
+}
+
+// save assigns the snapshot its ID and timestamps and stores it. The
+// in-memory copy is kept even if the table write fails.
+func (s *answerStore) save(ctx context.Context, snap *answerSnapshot) error {
+	now := s.now()
+	snap.ID = newAnswerID()
+	snap.CreatedAt = now
+	if s.ttl > 0 {
+		snap.ExpiresAt = now.Add(s.ttl)
+	}
+	s.remember(snap)
+
+	if s.persist == nil {
+		return nil
+	}
+	payload, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("failed to encode answer: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, answerStoreTimeout)
+	defer cancel()
+	err = s.persist.SaveAnswer(ctx, prophetagent.StoredAnswer{
+		ID:        snap.ID,
+		Question:  snap.Question,
+		Payload:   payload,
+		CreatedAt: snap.CreatedAt,
+		ExpiresAt: snap.ExpiresAt,
+	})
+	if errors.Is(err, prophetagent.ErrAnswersUnavailable) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	snap.persisted = true
+	s.mu.Unlock()
+	return nil
+}
+
+// remember keeps the in-memory copy, evicting expired copies and, past the
+// size bound, the oldest.
+func (s *answerStore) remember(snap *answerSnapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	var oldest *answerSnapshot
+	for id, existing := range s.memory {
+		if existing.expired(now) {
+			delete(s.memory, id)
+			continue
+		}
+		if oldest == nil || existing.CreatedAt.Before(oldest.CreatedAt) {
+			oldest = existing
+		}
+	}
+	if len(s.memory) >= s.max && oldest != nil {
+		delete(s.memory, oldest.ID)
+	}
+	s.memory[snap.ID] = snap
+}
+
+// get returns the answer for id, or nil if it is unknown, expired or
+// deleted. The table is authoritative for answers saved to it (another
+// instance may have deleted one); the in-memory copy serves the rest, and
+// all of them while the table can't be read.
+func (s *answerStore) get(ctx context.Context, id string) *answerSnapshot {
+	tableRead := false
+	if s.persist != nil {
+		snap, err := s.load(ctx, id)
+		switch {
+		case err == nil && snap != nil:
+			return snap
+		case err == nil:
+			tableRead = true
+		case !errors.Is(err, prophetagent.ErrAnswersUnavailable):
+			log.Printf("Answers: load %s failed, trying memory: %v", id, err)
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap, ok := s.memory[id]
+	if !ok || snap.expired(s.now()) || (tableRead && snap.persisted) {
+		return nil
+	}
+	return snap
+}
+
+func (s *answerStore) load(ctx context.Context, id string) (*answerSnapshot, error) {
+	ctx, cancel := context.WithTimeout(ctx, answerStoreTimeout)
+	defer cancel()
+	stored, ok, err := s.persist.LoadAnswer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || stored.Expired(s.now()) {
+		return nil, nil
+	}
+	var snap answerSnapshot
+	if err := json.Unmarshal(stored.Payload, &snap); err != nil {
+		return nil, fmt.Errorf("failed to decode answer %s: %w", id, err)
+	}
+	snap.ID, snap.Question = stored.ID, stored.Question
+	snap.CreatedAt, snap.ExpiresAt = stored.CreatedAt, stored.ExpiresAt
+	return &snap, nil
+}
+
+// delete removes the answer from the table and memory and reports whether
+// it existed.
+func (s *answerStore) delete(ctx context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	_, inMemory := s.memory[id]
+	delete(s.memory, id)
+	s.mu.Unlock()
+	if s.persist == nil {
+		return inMemory, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, answerStoreTimeout)
+	defer cancel()
+	deleted, err := s.persist.DeleteAnswer(ctx, id)
+	if errors.Is(err, prophetagent.ErrAnswersUnavailable) {
+		return inMemory, nil
+	}
+	return deleted || inMemory, err
+}
+
+// purgeExpired deletes expired answers from the table and memory.
+func (s *answerStore) purgeExpired(ctx context.Context) (int, error) {
+	now := s.now()
+	s.mu.Lock()
+	purged := 0
+	for id, snap := range s.memory {
+		if snap.expired(now) {
+			delete(s.memory, id)
+			purged++
+		}
+	}
+	s.mu.Unlock()
+	if s.persist == nil {
+		return purged, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, answerStoreTimeout)
+	defer cancel()
+	n, err := s.persist.DeleteExpiredAnswers(ctx)
+	if errors.Is(err, prophetagent.ErrAnswersUnavailable) {
+		return purged, nil
+	}
+	return n, err
+}
+
+func (s *answerStore) purgeEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n, err := s.purgeExpired(ctx); err != nil {
+				log.Printf("Answers: purge failed: %v", err)
+			} else if n > 0 {
+				log.Printf("Answers: purged %d expired answers", n)
+			}
+		}
+	}
+}
+
+// newAnswerID returns an unguessable ID that is short enough to keep the
+// take-home QR code small.
+func newAnswerID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("failed to generate answer ID: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
+
+// handleAnswerPage renders a saved answer, or a friendly page once it has
+// expired or been deleted. No agent runs: the page is the stored sections.
+func handleAnswerPage(ctx *gofr.Context) (interface{}, error) {
+	rctx := ctx.Request.Context()
+	var page templ.Component = components.AnswerExpired()
+	if snap := answers.get(rctx, ctx.PathParam("id")); snap != nil {
+		page = components.AnswerPage(snap.pageProps())
+		rctx = components.WithLocale(rctx, snap.locale())
+	}
+	var buf bytes.Buffer
+	if err := page.Render(rctx, &buf); err != nil {
+		return nil, fmt.Errorf("failed to render answer page: %w", err)
+	}
+	return response.File{
+		Content:     buf.Bytes(),
+		ContentType: "text/html; charset=utf-8",
+	}, nil
+}
+
+// handleDeleteAnswer deletes a saved answer (e.g. on a visitor's request).
+// It is disabled unless ANSWERS_ADMIN_KEY is set, and requires that key.
+func handleDeleteAnswer(ctx *gofr.Context) (interface{}, error) {
+	var req struct {
+		ID  string `json:"id" form:"id"`
+		Key string `json:"key" form:"key"`
+	}
+	if err := ctx.Bind(&req); err != nil {
+		return nil, fmt.Errorf("failed to parse request: %w", err)
+	}
+	adminKey := os.Getenv("ANSWERS_ADMIN_KEY")
+	if adminKey == "" {
+		return nil, fmt.Errorf("deleting answers is disabled")
+	}
+	if subtle.ConstantTimeCompare([]byte(req.Key), []byte(adminKey)) != 1 {
+		return nil, fmt.Errorf("invalid key")
+	}
+	deleted, err := answers.delete(ctx.Request.Context(), req.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete answer: %w", err)
+	}
+	log.Printf("Answers: delete %s requested (existed: %v)", req.ID, deleted)
+	return map[string]bool{"deleted": deleted}, nil
+}
@@ -0,0 +1,125 @@
+// cmd/server/answers_test.go
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	prophetagent "github.com/temple-square/prophet-agent/internal/agent"
+)
+
+// fakeAnswersTable is an in-memory answers table.
+type fakeAnswersTable struct {
+	rows map[string]prophetagent.StoredAnswer
+	err  error
+}
+
+func (f *fakeAnswersTable) SaveAnswer(_ context.Context, ans prophetagent.StoredAnswer) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.rows[ans.ID] = ans
+	return nil
+}
+
+func (f *fakeAnswersTable) LoadAnswer(_ context.Context, id string) (prophetagent.StoredAnswer, bool, error) {
+	if f.err != nil {
+		return prophetagent.StoredAnswer{}, false, f.err
+	}
+	ans, ok := f.rows[id]
+	return ans, ok, nil
+}
+
+func (f *fakeAnswersTable) DeleteAnswer(_ context.Context, id string) (bool, error) {
+	_, ok := f.rows[id]
+	delete(f.rows, id)
+	return ok, f.err
+}
+
+func (f *fakeAnswersTable) DeleteExpiredAnswers(context.Context) (int, error) {
+	return 0, f.err
+}
+
+func TestAnswerStorePersistsSections(t *testing.T) {
+	table := &fakeAnswersTable{rows: map[string]prophetagent.StoredAnswer{}}
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	store := newAnswerStore(table, time.Hour, 10)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	snap := &answerSnapshot{
+		Question:   "How can I strengthen my faith?",
+		Presidents: []StructuredQuote{{Speaker: "Dallin H. Oaks", Title: "Faith", Quote: "Faith is a principle of action."}},
+		Summary:    []string{"Faith grows as we act on it."},
+	}
+	if err := store.save(ctx, snap); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	row, ok := table.rows[snap.ID]
+	if !ok || !row.ExpiresAt.Equal(now.Add(time.Hour)) || !strings.Contains(string(row.Payload), "principle of action") {
+		t.Fatalf("row = %+v", row)
+	}
+
+	// A fresh store (another instance) reads the answer from the table
+	other := newAnswerStore(table, time.Hour, 10)
+	other.now = store.now
+	got := other.get(ctx, snap.ID)
+	if got == nil || got.Question != snap.Question || len(got.Presidents) != 1 || got.Summary[0] != snap.Summary[0] {
+		t.Fatalf("get = %+v", got)
+	}
+
+	// Deleted elsewhere: the in-memory copy must not bring it back
+	if deleted, err := other.delete(ctx, snap.ID); err != nil || !deleted {
+		t.Fatalf("delete = %v, %v", deleted, err)
+	}
+	if store.get(ctx, snap.ID) != nil {
+		t.Error("deleted answer still served from memory")
+	}
+
+	now = now.Add(2 * time.Hour)
+	expired := &answerSnapshot{Question: "q", Summary: []string{"s"}}
+	store.now = func() time.Time { return now.Add(-2 * time.Hour) }
+	if err := store.save(ctx, expired); err != nil {
+		t.Fatal(err)
+	}
+	store.now = func() time.Time { return now }
+	if store.get(ctx, expired.ID) != nil {
+		t.Error("expired answer should not be served")
+	}
+}
+
+func TestAnswerStoreFallsBackToMemory(t *testing.T) {
+	table := &fakeAnswersTable{rows: map[string]prophetagent.StoredAnswer{}, err: errors.New("toolbox down")}
+	store := newAnswerStore(table, 0, 2)
+	ctx := context.Background()
+
+	var saved []*answerSnapshot
+	for i := 0; i < 3; i++ {
+		snap := &answerSnapshot{Question: "q", Summary: []string{"s"}}
+		if err := store.save(ctx, snap); err == nil {
+			t.Fatal("expected the table error")
+		}
+		if !snap.ExpiresAt.IsZero() {
+			t.Error("a zero TTL keeps answers until deleted")
+		}
+		saved = append(saved, snap)
+		time.Sleep(time.Millisecond)
+	}
+	if store.get(ctx, saved[0].ID) != nil {
+		t.Error("oldest in-memory answer should be evicted past the bound")
+	}
+	if store.get(ctx, saved[2].ID) == nil {
+		t.Error("unpersisted answer should be served from memory")
+	}
+}
+
+func TestAnswerDescription(t *testing.T) {
+	snap := &answerSnapshot{Summary: []string{strings.Repeat("word ", 60)}}
+	desc := snap.description()
+	if len([]rune(desc)) > ogDescriptionLength+1 || !strings.HasSuffix(desc, "word…") {
+		t.Errorf("description = %q", desc)
+	}
+}
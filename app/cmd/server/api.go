@@ -0,0 +1,562 @@
+// cmd/server/api.go
+// Versioned JSON API: structured answers for clients other than the kiosk
+// (mobile app, chat bots), returned whole or streamed as SSE JSON events
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	gofrHTTP "gofr.dev/pkg/gofr/http"
+
+	prophetagent "github.com/temple-square/prophet-agent/internal/agent"
+)
+
+const (
+	// apiBasePath prefixes every API route; the GoFr server proxies it to
+	// the internal server, which can set status codes and stream.
+	apiBasePath = "/api/v1/"
+	// maxAPIQuestionLength is the longest question the API accepts, in runes
+	maxAPIQuestionLength = 1000
+	// maxAPIBodyBytes bounds request bodies
+	maxAPIBodyBytes = 16 << 10
+)
+
+// Section statuses reported in APISections.
+const (
+	apiSectionComplete = "complete"
+	apiSectionEmpty    = "empty"
+	apiSectionFailed   = "failed"
+	apiSectionSkipped  = "skipped"
+)
+
+// Answer statuses reported in APIAnswer.
+const (
+	apiAnswered   = "answered"
+	apiRedirected = "redirected"
+	apiDeclined   = "declined"
+)
+
+// APIAnswerRequest is the body of POST /api/v1/answers.
+type APIAnswerRequest struct {
+	Question string `json:"question" doc:"The question to answer, up to 1000 characters"`
+	Locale   string `json:"locale,omitempty" doc:"Locale of the redirect message and suggested questions offered when the question is redirected; defaults to the language of the question"`
+}
+
+// APIScriptures is the scriptures section by category.
+type APIScriptures struct {
+	Bible        []StructuredScripture `json:"bible"`
+	BookOfMormon []StructuredScripture `json:"book_of_mormon"`
+	Other        []StructuredScripture `json:"other" doc:"Doctrine and Covenants and Pearl of Great Price"`
+}
+
+// APISections is the final status of each section.
+type APISections struct {
+	Presidents string `json:"presidents" enum:"complete,empty,failed,skipped"`
+	Leaders    string `json:"leaders" enum:"complete,empty,failed,skipped"`
+	Scriptures string `json:"scriptures" enum:"complete,empty,failed,skipped"`
+	Summary    string `json:"summary" enum:"complete,empty,failed,skipped"`
+}
+
+// APIRedirect is offered instead of an answer for questions the kiosk
+// doesn't answer.
+type APIRedirect struct {
+	Message     string                           `json:"message"`
+	Suggestions []prophetagent.SuggestedQuestion `json:"suggestions" doc:"Questions to ask instead"`
+}
+
+// APIAnswer is a finished answer.
+type APIAnswer struct {
+	ID         string             `json:"id,omitempty" doc:"ID of the saved answer; absent when nothing was found"`
+	URL        string             `json:"url,omitempty" doc:"Public page showing the saved answer"`
+	Question   string             `json:"question"`
+	Status     string             `json:"status" enum:"answered,redirected,declined" doc:"redirected: see redirect; declined: the agents refused the question"`
+	Presidents PresidentsResponse `json:"presidents" doc:"Remarks from Church Presidents, the current President first"`
+	Leaders    LeadersResponse    `json:"leaders" doc:"Remarks from other Church leaders"`
+	Scriptures APIScriptures      `json:"scriptures"`
+	Summary    []string           `json:"summary" doc:"Summary paragraphs"`
+	Related    []string           `json:"related" doc:"Questions to explore next, grounded in the talks and scriptures shown; empty when none were generated"`
+	Sections   APISections        `json:"sections"`
+	Redirect   *APIRedirect       `json:"redirect,omitempty"`
+}
+
+// APISectionStatus is the data of a section_status stream event.
+type APISectionStatus struct {
+	Section string `json:"section" enum:"presidents,leaders,scriptures,summary"`
+	Status  string `json:"status" enum:"complete,empty,failed"`
+}
+
+// APIWaiting is the data of a waiting stream event.
+type APIWaiting struct {
+	Position int `json:"position" doc:"1-based position in the queue of questions waiting for an agent run"`
+}
+
+// APIError is the body of every error response and error stream event.
+type APIError struct {
+	Error string `json:"error"`
+}
+
+// apiKeyring holds the API keys from API_KEYS, each issued to a named
+// client. The name identifies the client in logs and rate limits.
+type apiKeyring struct {
+	keys []apiKey
+}
+
+type apiKey struct {
+	name string
+	key  []byte
+}
+
+// parseAPIKeys parses comma-separated name:key pairs.
+func parseAPIKeys(spec string) (apiKeyring, error) {
+	var ring apiKeyring
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, key, ok := strings.Cut(entry, ":")
+		name, key = strings.TrimSpace(name), strings.TrimSpace(key)
+		if !ok || name == "" || key == "" {
+			return apiKeyring{}, fmt.Errorf("API key entry %q is not name:key", entry)
+		}
+		ring.keys = append(ring.keys, apiKey{name: name, key: []byte(key)})
+	}
+	return ring, nil
+}
+
+// client returns the name of the client the key was issued to. Every key
+// is compared in constant time.
+func (k apiKeyring) client(key string) (string, bool) {
+	name := ""
+	for _, candidate := range k.keys {
+		if subtle.ConstantTimeCompare([]byte(key), candidate.key) == 1 && name == "" {
+			name = candidate.name
+		}
+	}
+	return name, name != ""
+}
+
+// requestAPIKey reads the key from an Authorization Bearer token or the
+// X-API-Key header.
+func requestAPIKey(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); len(auth) > len("Bearer ") && strings.EqualFold(auth[:len("Bearer ")], "Bearer ") {
+		return strings.TrimSpace(auth[len("Bearer "):])
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
+
+var (
+	apiKeys    apiKeyring
+	apiLimiter = newRateLimiter(30, 10)
+)
+
+// initAPI loads the API keys and the per-client rate limit. The API
+// rejects every request while API_KEYS is unset.
+func initAPI() error {
+	ring, err := parseAPIKeys(getEnv("API_KEYS", ""))
+	if err != nil {
+		return err
+	}
+	apiKeys = ring
+	apiLimiter = newRateLimiter(getEnvInt("API_RATE_LIMIT_PER_MIN", 30), getEnvInt("API_RATE_LIMIT_BURST", 10))
+	if len(ring.keys) == 0 {
+		log.Println("API_KEYS not set, the JSON API is disabled")
+	} else {
+		log.Printf("JSON API enabled for %d clients", len(ring.keys))
+	}
+	return nil
+}
+
+// registerAPI adds the API routes to the internal server's mux.
+func registerAPI(mux *http.ServeMux, agent *prophetagent.ProphetAgent) {
+	mux.HandleFunc("GET "+apiBasePath+"openapi.json", handleOpenAPISpec)
+	mux.HandleFunc("POST "+apiBasePath+"answers", func(w http.ResponseWriter, r *http.Request) {
+		handleAPIAnswer(w, r, agent)
+	})
+	mux.Handle(apiBasePath+"mcp", mcpHTTPHandler(newMCPServer(agent)))
+}
+
+// authenticateAPI returns the client whose API key the request carries, or
+// writes a 401.
+func authenticateAPI(w http.ResponseWriter, r *http.Request) (string, bool) {
+	client, ok := apiKeys.client(requestAPIKey(r))
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+		writeAPIError(w, http.StatusUnauthorized, "missing or invalid API key")
+	}
+	return client, ok
+}
+
+// statsPaths are the operational endpoints on the GoFr server that need a
+// key: they expose usage the public shouldn't see.
+var statsPaths = map[string]bool{
+	"/api/suggestions/stats": true,
+	"/api/llm/stats":         true,
+}
+
+// authorizedForStats reports whether the request carries an API key or the
+// ANSWERS_ADMIN_KEY. Behind the workers' basic auth only X-API-Key reaches
+// the app.
+func authorizedForStats(r *http.Request) bool {
+	key := requestAPIKey(r)
+	if key == "" {
+		return false
+	}
+	if _, ok := apiKeys.client(key); ok {
+		return true
+	}
+	adminKey := os.Getenv("ANSWERS_ADMIN_KEY")
+	return adminKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(adminKey)) == 1
+}
+
+// statsAuthMiddleware rejects stats requests without a key before they
+// reach GoFr, whose handlers can't read request headers.
+func statsAuthMiddleware() gofrHTTP.Middleware {
+	return func(inner http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if statsPaths[r.URL.Path] && !authorizedForStats(r) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+				writeAPIError(w, http.StatusUnauthorized, "missing or invalid API key")
+				return
+			}
+			inner.ServeHTTP(w, r)
+		})
+	}
+}
+
+// handleAPIAnswer answers a question as JSON, or as a stream of JSON
+// events when the client accepts text/event-stream or passes stream=true.
+func handleAPIAnswer(w http.ResponseWriter, r *http.Request, agent *prophetagent.ProphetAgent) {
+	client, ok := authenticateAPI(w, r)
+	if !ok {
+		return
+	}
+	if ok, wait := apiLimiter.allow(client); !ok {
+		log.Printf("API: Rate limited client=%s (retry in %v)", client, wait)
+		w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+		writeAPIError(w, http.StatusTooManyRequests, "too many requests")
+		return
+	}
+
+	var req APIAnswerRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAPIBodyBytes)).Decode(&req); err != nil {
+		writeAPIError(w, http.StatusBadRequest, "request body must be a JSON object with a question")
+		return
+	}
+	question := strings.TrimSpace(req.Question)
+	if question == "" {
+		writeAPIError(w, http.StatusBadRequest, "question is required")
+		return
+	}
+	if utf8.RuneCountInString(question) > maxAPIQuestionLength {
+		writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("question is longer than %d characters", maxAPIQuestionLength))
+		return
+	}
+	locale := req.Locale
+	if locale == "" {
+		locale = string(prophetagent.DetectLanguage(question))
+	}
+
+	stream := wantsEventStream(r)
+	if runs.full() && prophetagent.ClassifyContent(question) == prophetagent.ContentSafe {
+		w.Header().Set("Retry-After", "30")
+		writeAPIError(w, http.StatusServiceUnavailable, errAPIBusy.Error())
+		return
+	}
+
+	log.Printf("API: Answering for client=%s stream=%v question=%q", client, stream, question)
+	if !stream {
+		answer, err := answerQuestion(r.Context(), agent, question, locale, newAPIEvents(nil))
+		if err != nil {
+			writeAPIError(w, http.StatusServiceUnavailable, err.Error())
+			return
+		}
+		writeAPIJSON(w, http.StatusOK, answer)
+		return
+	}
+	sendAPIEvents(w, func(emit func(string, any)) {
+		answer, err := answerQuestion(r.Context(), agent, question, locale, newAPIEvents(emit))
+		if err != nil {
+			emit("error", APIError{Error: err.Error()})
+			return
+		}
+		emit("answer", answer)
+	})
+}
+
+// errAPIBusy is returned when the run queue is full.
+var errAPIBusy = errors.New("too many questions are being answered, try again in a minute")
+
+// answerQuestion answers a question for the API and MCP tools: a redirect
+// for questions the kiosk doesn't answer, otherwise the agents' answer.
+func answerQuestion(ctx context.Context, agent *prophetagent.ProphetAgent, question, locale string, ev *apiEvents) (APIAnswer, error) {
+	classification, category := prophetagent.ClassifyContentCategory(question)
+	if classification != prophetagent.ContentSafe {
+		log.Printf("API: Redirected question classification=%s", classification)
+		return redirectedAPIAnswer(question, redirectFor(classification, category, locale)), nil
+	}
+	return runAPIAnswer(ctx, agent, question, ev)
+}
+
+// runAPIAnswer waits for a run slot, collects the answer and saves it.
+func runAPIAnswer(ctx context.Context, agent *prophetagent.ProphetAgent, question string, ev *apiEvents) (APIAnswer, error) {
+	release, err := runs.acquire(ctx, ev.waiting)
+	if err != nil {
+		if errors.Is(err, errRunQueueFull) {
+			return APIAnswer{}, errAPIBusy
+		}
+		return APIAnswer{}, err
+	}
+	defer release()
+
+	askedAt := time.Now()
+	collected := collectAnswer(ctx, agent, question, ev)
+	answer := ev.answer(question, collected)
+	if collected.blocked {
+		answer.Status = apiDeclined
+		answer.Sections = APISections{
+			Presidents: apiSectionSkipped,
+			Leaders:    apiSectionSkipped,
+			Scriptures: apiSectionSkipped,
+			Summary:    apiSectionSkipped,
+		}
+		return answer, nil
+	}
+	if snap := collected.snapshot(question, askedAt); !snap.empty() {
+		// Saved outside the request context, which may be the budget
+		// that just expired
+		if err := answers.save(context.Background(), snap); err != nil {
+			log.Printf("API: Failed to persist answer %s, keeping it in memory: %v", snap.ID, err)
+		}
+		answer.ID, answer.URL = snap.ID, snap.url()
+	}
+	turn := collected.turn(question)
+	related, err := agent.RelatedQuestions(ctx, question, turn.Quotes, turn.Scriptures)
+	if err != nil {
+		// Optional, as on the kiosk
+		log.Printf("API: Related questions failed: %v", err)
+	}
+	answer.Related = orEmpty(related)
+	return answer, nil
+}
+
+// redirectedAPIAnswer is the answer to a question the kiosk doesn't answer.
+func redirectedAPIAnswer(question string, redirect prophetagent.RedirectResponse) APIAnswer {
+	answer := newAPIAnswer(question, &answerCollector{})
+	answer.Status = apiRedirected
+	answer.Sections = APISections{
+		Presidents: apiSectionSkipped,
+		Leaders:    apiSectionSkipped,
+		Scriptures: apiSectionSkipped,
+		Summary:    apiSectionSkipped,
+	}
+	answer.Redirect = &APIRedirect{
+		Message:     redirect.Message,
+		Suggestions: orEmpty(redirect.Suggestions),
+	}
+	return answer
+}
+
+// newAPIAnswer converts the collected sections. Empty sections are empty
+// lists rather than null.
+func newAPIAnswer(question string, c *answerCollector) APIAnswer {
+	return APIAnswer{
+		Question:   question,
+		Status:     apiAnswered,
+		Presidents: apiPresidents(c),
+		Leaders:    LeadersResponse{Quotes: orEmpty(c.leaders)},
+		Scriptures: apiScriptures(c),
+		Summary:    orEmpty(c.summary),
+		Related:    []string{},
+	}
+}
+
+// apiPresidents orders the presidents section like the kiosk does.
+func apiPresidents(c *answerCollector) PresidentsResponse {
+	quotes := append([]StructuredQuote{}, c.presidents...)
+	sortPresidentsQuotes(quotes)
+	return PresidentsResponse{Quotes: quotes}
+}
+
+func apiScriptures(c *answerCollector) APIScriptures {
+	return APIScriptures{
+		Bible:        orEmpty(c.bible),
+		BookOfMormon: orEmpty(c.bom),
+		Other:        orEmpty(c.other),
+	}
+}
+
+func orEmpty[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
+
+// apiEvents tracks each section's status for the answer and, when
+// streaming, emits every change as a JSON event.
+type apiEvents struct {
+	emit       func(event string, data any) // nil when answering whole
+	content    map[string]bool
+	failed     map[string]bool
+	status     map[string]string
+	lastUpdate time.Time
+}
+
+func newAPIEvents(emit func(event string, data any)) *apiEvents {
+	return &apiEvents{
+		emit:    emit,
+		content: map[string]bool{},
+		failed:  map[string]bool{},
+		status:  map[string]string{},
+	}
+}
+
+func (e *apiEvents) send(event string, data any) {
+	if e.emit != nil {
+		e.emit(event, data)
+	}
+}
+
+// waiting reports the position in the run queue.
+func (e *apiEvents) waiting(position int) {
+	e.send("waiting", APIWaiting{Position: position})
+}
+
+func (e *apiEvents) sectionChanged(section string, c *answerCollector) {
+	e.content[section] = true
+	switch section {
+	case sectionPresidents:
+		e.send(section, apiPresidents(c))
+	case sectionLeaders:
+		e.send(section, LeadersResponse{Quotes: c.leaders})
+	case sectionScriptures:
+		e.send(section, apiScriptures(c))
+	case sectionSummary:
+		e.send(section, SummaryResponse{Summary: c.summary})
+	}
+}
+
+func (e *apiEvents) sectionFailed(section string) { e.failed[section] = true }
+
+// sectionFinished settles the section's status, the same way the kiosk
+// picks its terminal card. Repeated calls are no-ops.
+func (e *apiEvents) sectionFinished(section string) {
+	if _, ok := e.status[section]; ok {
+		return
+	}
+	status := apiSectionEmpty
+	switch {
+	case e.content[section]:
+		status = apiSectionComplete
+	case e.failed[section]:
+		status = apiSectionFailed
+	}
+	e.status[section] = status
+	e.send("section_status", APISectionStatus{Section: section, Status: status})
+}
+
+// summaryProgress streams the summary as it is written; the summary event
+// replaces it.
+func (e *apiEvents) summaryProgress(paragraphs []string) {
+	if e.emit == nil || time.Since(e.lastUpdate) < summaryUpdateInterval {
+		return
+	}
+	e.lastUpdate = time.Now()
+	if paragraphs = sanitizeSummary(paragraphs); len(paragraphs) > 0 {
+		e.send("summary_progress", SummaryResponse{Summary: paragraphs})
+	}
+}
+
+// answer is the collected answer with each section's status.
+func (e *apiEvents) answer(question string, c *answerCollector) APIAnswer {
+	answer := newAPIAnswer(question, c)
+	answer.Sections = APISections{
+		Presidents: e.status[sectionPresidents],
+		Leaders:    e.status[sectionLeaders],
+		Scriptures: e.status[sectionScriptures],
+		Summary:    e.status[sectionSummary],
+	}
+	return answer
+}
+
+// wantsEventStream reports whether the client asked for a stream.
+func wantsEventStream(r *http.Request) bool {
+	if stream, err := strconv.ParseBool(r.URL.Query().Get("stream")); err == nil {
+		return stream
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// sendAPIEvents streams the events produced by run. Events may be emitted
+// from other goroutines (e.g. queue positions) until run returns.
+func sendAPIEvents(w http.ResponseWriter, run func(emit func(event string, data any))) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeAPIError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	var mu sync.Mutex
+	done := false
+	run(func(event string, data any) {
+		mu.Lock()
+		defer mu.Unlock()
+		if done {
+			return
+		}
+		if err := writeAPIEvent(w, event, data); err != nil {
+			log.Printf("API: Failed to send %s event: %v", event, err)
+			return
+		}
+		flusher.Flush()
+	})
+	mu.Lock()
+	done = true
+	mu.Unlock()
+}
+
+// writeAPIEvent writes one SSE event with JSON data. JSON never contains a
+// raw newline, so the data fits on one line.
+func writeAPIEvent(w io.Writer, event string, data any) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b)
+	return err
+}
+
+func writeAPIJSON(w http.ResponseWriter, status int, v any) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("API: Failed to encode response: %v", err)
+		status, b = http.StatusInternalServerError, []byte(`{"error":"failed to encode response"}`)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	w.Write(append(b, '\n'))
+}
+
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	writeAPIJSON(w, status, APIError{Error: message})
+}
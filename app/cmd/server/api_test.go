@@ -0,0 +1,325 @@
+// cmd/server/api_test.go
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func withAPIKeys(t *testing.T, spec string) {
+	t.Helper()
+	ring, err := parseAPIKeys(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prevKeys, prevLimiter := apiKeys, apiLimiter
+	apiKeys, apiLimiter = ring, newRateLimiter(600, 100)
+	t.Cleanup(func() { apiKeys, apiLimiter = prevKeys, prevLimiter })
+}
+
+func TestParseAPIKeys(t *testing.T) {
+	ring, err := parseAPIKeys(" mobile:abc123 , slack:def456,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{"abc123": "mobile", "def456": "slack"} {
+		if got, ok := ring.client(key); !ok || got != want {
+			t.Errorf("client(%q) = %q, %v; want %q", key, got, ok, want)
+		}
+	}
+	for _, key := range []string{"", "abc12", "abc1234", "mobile"} {
+		if name, ok := ring.client(key); ok {
+			t.Errorf("client(%q) = %q, want no client", key, name)
+		}
+	}
+
+	for _, bad := range []string{"nokey", "name:", ":key"} {
+		if _, err := parseAPIKeys(bad); err == nil {
+			t.Errorf("parseAPIKeys(%q) should fail", bad)
+		}
+	}
+	if ring, err := parseAPIKeys(""); err != nil || len(ring.keys) != 0 {
+		t.Errorf("empty API_KEYS = %v, %v", ring, err)
+	}
+}
+
+func TestRequestAPIKey(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/answers", nil)
+	r.Header.Set("Authorization", "bearer  abc123")
+	if got := requestAPIKey(r); got != "abc123" {
+		t.Errorf("Bearer key = %q", got)
+	}
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/answers", nil)
+	r.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+	r.Header.Set("X-API-Key", "def456")
+	if got := requestAPIKey(r); got != "def456" {
+		t.Errorf("X-API-Key = %q", got)
+	}
+}
+
+func postAPIAnswer(t *testing.T, key, body string, header map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	mux := http.NewServeMux()
+	registerAPI(mux, nil)
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/answers", strings.NewReader(body))
+	if key != "" {
+		r.Header.Set("Authorization", "Bearer "+key)
+	}
+	for k, v := range header {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	return w
+}
+
+func TestAPIAnswerRejectsRequests(t *testing.T) {
+	withAPIKeys(t, "test:secret")
+	tests := []struct {
+		name   string
+		key    string
+		body   string
+		status int
+	}{
+		{"no key", "", `{"question":"What is faith?"}`, http.StatusUnauthorized},
+		{"wrong key", "guess", `{"question":"What is faith?"}`, http.StatusUnauthorized},
+		{"not JSON", "secret", `question=faith`, http.StatusBadRequest},
+		{"no question", "secret", `{"question":"  "}`, http.StatusBadRequest},
+		{"too long", "secret", `{"question":"` + strings.Repeat("a", maxAPIQuestionLength+1) + `"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := postAPIAnswer(t, tt.key, tt.body, nil)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.status, w.Body)
+			}
+			var apiErr APIError
+			if err := json.Unmarshal(w.Body.Bytes(), &apiErr); err != nil || apiErr.Error == "" {
+				t.Errorf("body = %s, want an APIError", w.Body)
+			}
+		})
+	}
+}
+
+func TestAPIAnswerDisabledWithoutKeys(t *testing.T) {
+	withAPIKeys(t, "")
+	if w := postAPIAnswer(t, "anything", `{"question":"What is faith?"}`, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", w.Code)
+	}
+}
+
+func TestAPIAnswerRateLimitsPerClient(t *testing.T) {
+	withAPIKeys(t, "a:key-a,b:key-b")
+	apiLimiter = newRateLimiter(1, 1)
+	body := `{"question":"Tell me about polygamy"}`
+	if w := postAPIAnswer(t, "key-a", body, nil); w.Code != http.StatusOK {
+		t.Fatalf("first request status = %d", w.Code)
+	}
+	w := postAPIAnswer(t, "key-a", body, nil)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("second request status = %d, Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := postAPIAnswer(t, "key-b", body, nil); w.Code != http.StatusOK {
+		t.Errorf("other client status = %d", w.Code)
+	}
+}
+
+// Redirected questions are answered without running the agents, so they
+// exercise the whole response shape.
+func TestAPIAnswerRedirect(t *testing.T) {
+	withAPIKeys(t, "test:secret")
+	w := postAPIAnswer(t, "secret", `{"question":"What about polygamy?"}`, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d (%s)", w.Code, w.Body)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	var answer APIAnswer
+	if err := json.Unmarshal(w.Body.Bytes(), &answer); err != nil {
+		t.Fatal(err)
+	}
+	if answer.Status != apiRedirected || answer.Redirect == nil || answer.Redirect.Message == "" {
+		t.Errorf("answer = %+v", answer)
+	}
+	if answer.Sections.Presidents != apiSectionSkipped || answer.Sections.Summary != apiSectionSkipped {
+		t.Errorf("sections = %+v", answer.Sections)
+	}
+	// Empty sections are lists, not null
+	var raw map[string]json.RawMessage
+	json.Unmarshal(w.Body.Bytes(), &raw)
+	for _, field := range []string{"summary", "related"} {
+		if got := string(raw[field]); got != "[]" {
+			t.Errorf("%s = %s, want []", field, got)
+		}
+	}
+	if !strings.Contains(string(raw["presidents"]), `"quotes":[]`) {
+		t.Errorf("presidents = %s", raw["presidents"])
+	}
+}
+
+func TestAPIAnswerRedirectStream(t *testing.T) {
+	withAPIKeys(t, "test:secret")
+	w := postAPIAnswer(t, "secret", `{"question":"What about polygamy?"}`, map[string]string{"Accept": "text/event-stream"})
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	events := readAPIEvents(t, w.Body.String())
+	if len(events) != 1 || events[0].name != "answer" {
+		t.Fatalf("events = %+v", events)
+	}
+	var answer APIAnswer
+	if err := json.Unmarshal([]byte(events[0].data), &answer); err != nil || answer.Status != apiRedirected {
+		t.Errorf("answer = %+v, %v", answer, err)
+	}
+}
+
+func TestWantsEventStream(t *testing.T) {
+	tests := []struct {
+		target, accept string
+		want           bool
+	}{
+		{"/api/v1/answers", "", false},
+		{"/api/v1/answers", "application/json", false},
+		{"/api/v1/answers", "text/event-stream", true},
+		{"/api/v1/answers?stream=true", "", true},
+		{"/api/v1/answers?stream=1", "application/json", true},
+		{"/api/v1/answers?stream=false", "text/event-stream", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, tt.target, nil)
+		if tt.accept != "" {
+			r.Header.Set("Accept", tt.accept)
+		}
+		if got := wantsEventStream(r); got != tt.want {
+			t.Errorf("wantsEventStream(%s, Accept %q) = %v, want %v", tt.target, tt.accept, got, tt.want)
+		}
+	}
+}
+
+type apiEvent struct{ name, data string }
+
+func readAPIEvents(t *testing.T, body string) []apiEvent {
+	t.Helper()
+	var events []apiEvent
+	var current apiEvent
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			current.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			current.data = strings.TrimPrefix(line, "data: ")
+		case line == "" && current.name != "":
+			events = append(events, current)
+			current = apiEvent{}
+		}
+	}
+	return events
+}
+
+func TestAPIEventsSectionStatus(t *testing.T) {
+	var sent []apiEvent
+	ev := newAPIEvents(func(event string, data any) {
+		b, _ := json.Marshal(data)
+		sent = append(sent, apiEvent{event, string(b)})
+	})
+	c := &answerCollector{}
+	if _, changed, err := c.add("leaders_agent", `{"quotes":[{"speaker":"A","title":"T","conference":"C","quote":"Q"}]}`); err != nil || !changed {
+		t.Fatalf("add = %v, %v", changed, err)
+	}
+	ev.sectionChanged(sectionLeaders, c)
+	ev.sectionFailed(sectionLeaders) // content already sent wins
+	ev.sectionFailed(sectionScriptures)
+	for _, section := range answerSections {
+		ev.sectionFinished(section)
+		ev.sectionFinished(section)
+	}
+
+	answer := ev.answer("q", c)
+	want := APISections{Presidents: apiSectionEmpty, Leaders: apiSectionComplete, Scriptures: apiSectionFailed, Summary: apiSectionEmpty}
+	if answer.Sections != want {
+		t.Errorf("sections = %+v, want %+v", answer.Sections, want)
+	}
+	if len(answer.Leaders.Quotes) != 1 || answer.Presidents.Quotes == nil {
+		t.Errorf("answer = %+v", answer)
+	}
+
+	names := []string{}
+	for _, e := range sent {
+		names = append(names, e.name)
+	}
+	if got := strings.Join(names, ","); got != "leaders,section_status,section_status,section_status,section_status" {
+		t.Errorf("events = %s", got)
+	}
+}
+
+func TestAPISpec(t *testing.T) {
+	spec := apiSpec()
+	op := spec.Paths["/api/v1/answers"].Post
+	if op == nil || op.RequestBody == nil {
+		t.Fatalf("missing POST /api/v1/answers: %+v", spec.Paths)
+	}
+	if got := op.Responses["200"].Content["application/json"].Schema.Ref; got != "#/components/schemas/APIAnswer" {
+		t.Errorf("200 schema = %q", got)
+	}
+	for _, name := range []string{"APIAnswer", "APIAnswerRequest", "PresidentsResponse", "LeadersResponse",
+		"APIScriptures", "StructuredQuote", "StructuredScripture", "APISectionStatus", "APIWaiting", "SummaryResponse", "APIError"} {
+		if spec.Components.Schemas[name] == nil {
+			t.Errorf("missing schema %s", name)
+		}
+	}
+	answer := spec.Components.Schemas["APIAnswer"]
+	if _, ok := answer.Properties["scriptures"]; !ok {
+		t.Errorf("APIAnswer properties = %v", answer.Properties)
+	}
+	if got := answer.Properties["status"].Enum; len(got) != 3 {
+		t.Errorf("status enum = %v", got)
+	}
+
+	mux := http.NewServeMux()
+	registerAPI(mux, nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+	var decoded map[string]any
+	if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &decoded) != nil || decoded["openapi"] == nil {
+		t.Errorf("GET openapi.json = %d %s", w.Code, w.Body)
+	}
+}
+
+func TestStatsRequireKey(t *testing.T) {
+	withAPIKeys(t, "ops:api-key")
+	t.Setenv("ANSWERS_ADMIN_KEY", "admin-key")
+	handler := statsAuthMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	cases := []struct {
+		path, header, key string
+		want              int
+	}{
+		{"/api/llm/stats", "", "", http.StatusUnauthorized},
+		{"/api/suggestions/stats", "", "", http.StatusUnauthorized},
+		{"/api/llm/stats", "X-API-Key", "wrong", http.StatusUnauthorized},
+		{"/api/llm/stats", "X-API-Key", "api-key", http.StatusOK},
+		{"/api/suggestions/stats", "X-API-Key", "admin-key", http.StatusOK},
+		{"/api/suggestions/stats", "Authorization", "Bearer api-key", http.StatusOK},
+		{"/", "", "", http.StatusOK},
+	}
+	for _, tc := range cases {
+		r := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.header != "" {
+			r.Header.Set(tc.header, tc.key)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != tc.want {
+			t.Errorf("GET %s with %s %q = %d, want %d", tc.path, tc.header, tc.key, w.Code, tc.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// cmd/server/conversation.go
+// Follow-up questions: an answered session hands its conversation to the next
+package main
+
+import (
+	"bytes"
+	"context"
+	"log"
+
+	prophetagent "github.com/temple-square/prophet-agent/internal/agent"
+	"github.com/temple-square/prophet-agent/internal/ui/components"
+)
+
+// followUpHistory returns the conversation a follow-up to sessionID
+// continues. The token must be the session's own stream token, so only the
+// page that showed an answer can follow it up. Without a usable session the
+// follow-up is answered on its own.
+func followUpHistory(sessionID, token string) *prophetagent.Conversation {
+	prev := sessions.get(sessionID)
+	if prev == nil {
+		log.Printf("Follow-up: Session %s is gone, answering on its own", sessionID)
+		return nil
+	}
+	if err := signer.verify(prev.id, prev.question, token); err != nil {
+		log.Printf("Follow-up: Rejected session=%s: %v", sessionID, err)
+		return nil
+	}
+	return prev.followUpHistory()
+}
+
+// publishFollowUp offers a follow-up question below a finished answer.
+func publishFollowUp(sess *streamSession) {
+	var buf bytes.Buffer
+	err := components.FollowUpForm(followUpProps(sess)).Render(sessionContext(context.Background(), sess), &buf)
+	if err != nil {
+		log.Printf("SSE: Failed to render follow-up form: %v", err)
+		return
+	}
+	sess.publish("followup", buf.String())
+}
+
+// followUpProps lets a follow-up continue sess.
+func followUpProps(sess *streamSession) components.FollowUpProps {
+	return components.FollowUpProps{
+		SessionID: sess.id,
+		Token:     signer.sign(sess.id, sess.question),
+	}
+}
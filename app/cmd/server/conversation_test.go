@@ -0,0 +1,96 @@
+// cmd/server/conversation_test.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"html"
+	"strings"
+	"testing"
+	"time"
+
+	prophetagent "github.com/temple-square/prophet-agent/internal/agent"
+	"github.com/temple-square/prophet-agent/internal/ui/components"
+)
+
+// answered starts a session on the global manager that records turn, and
+// waits for it to finish.
+func answered(t *testing.T, id, question string, history *prophetagent.Conversation) *streamSession {
+	t.Helper()
+	sess, _ := sessions.start(id, question, history, func(ctx context.Context, sess *streamSession) {
+		sess.recordTurn(prophetagent.Turn{Question: question})
+	})
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, done, _ := sess.eventsAfter(0); done {
+			return sess
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("session did not finish")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestFollowUpHistory verifies a follow-up continues the conversation of the
+// session it names, and only with that session's token
+func TestFollowUpHistory(t *testing.T) {
+	answered(t, "conv-1", "What is faith?", nil)
+	history := followUpHistory("conv-1", signer.sign("conv-1", "What is faith?"))
+	if got := strings.Join(history.Questions(), ","); got != "What is faith?" {
+		t.Fatalf("history = %q", got)
+	}
+
+	answered(t, "conv-2", "How do I develop that?", history)
+	history = followUpHistory("conv-2", signer.sign("conv-2", "How do I develop that?"))
+	if got := strings.Join(history.Questions(), ","); got != "What is faith?,How do I develop that?" {
+		t.Errorf("chained history = %q", got)
+	}
+
+	if followUpHistory("conv-2", signer.sign("conv-1", "What is faith?")) != nil {
+		t.Error("another session's token should not continue the conversation")
+	}
+	if followUpHistory("missing", "") != nil {
+		t.Error("an unknown session should have no history")
+	}
+}
+
+// TestRelatedQuestionsFollowUp verifies a related question chip posts as a
+// follow-up to the session it ends, appended like the follow-up form's
+func TestRelatedQuestionsFollowUp(t *testing.T) {
+	sess := answered(t, "conv-related", "What is faith?", nil)
+	var buf strings.Builder
+	chips := []components.SuggestedQuestion{{Text: "How does faith grow?"}}
+	if err := components.RelatedQuestions(chips, followUpProps(sess)).Render(context.Background(), &buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, `hx-swap="beforeend"`) || !strings.Contains(out, "data-follow-up") {
+		t.Errorf("Expected a follow-up chip, got %s", out)
+	}
+	_, vals, _ := strings.Cut(out, `hx-vals="`)
+	vals, _, _ = strings.Cut(vals, `"`)
+	var posted map[string]string
+	if err := json.Unmarshal([]byte(html.UnescapeString(vals)), &posted); err != nil {
+		t.Fatalf("hx-vals %q: %v", vals, err)
+	}
+	if posted["question"] != "How does faith grow?" {
+		t.Errorf("question = %q", posted["question"])
+	}
+	if followUpHistory(posted["follow_up"], posted["token"]) == nil {
+		t.Errorf("Expected the chip to continue the conversation, posted %v", posted)
+	}
+}
+
+func TestAnswerCollectorTurn(t *testing.T) {
+	c := &answerCollector{
+		presidents: []StructuredQuote{{Speaker: "A", Quote: "one"}},
+		leaders:    []StructuredQuote{{Speaker: "B", Quote: "two"}},
+		bible:      []StructuredScripture{{Reference: "John 3:16"}},
+		other:      []StructuredScripture{{Reference: "Moses 1:39"}},
+	}
+	turn := c.turn("What is faith?")
+	if turn.Question != "What is faith?" || len(turn.Quotes) != 2 || len(turn.Scriptures) != 2 {
+		t.Errorf("turn = %+v", turn)
+	}
+}
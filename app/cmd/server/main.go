@@ -4,6 +4,7 @@ package main
 import (
 	"bytes"
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
@@ -11,6 +12,7 @@ import (
 	"net/url"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -26,133 +28,25 @@ import (
 // assetsBaseURL is the base URL for static assets including headshots
 var assetsBaseURL = getEnv("ASSETS_BASE_URL", "https://storage.googleapis.com/temple-square-assets")
 
-// speakerHeadshots maps speaker names (and common variations) to their headshot slug.
-// The full URL is constructed as: assetsBaseURL + "/headshots/" + slug + "-square.webp"
-var speakerHeadshots = map[string]string{
-	// First Presidency
-	"Russell M. Nelson":           "russell-nelson",
-	"President Russell M. Nelson": "russell-nelson",
-	"President Nelson":            "russell-nelson",
-	"Dallin H. Oaks":              "dallin-oaks",
-	"President Dallin H. Oaks":    "dallin-oaks",
-	"President Oaks":              "dallin-oaks",
-	"Henry B. Eyring":             "henry-eyring",
-	"President Henry B. Eyring":   "henry-eyring",
-	"President Eyring":            "henry-eyring",
-
-	// Quorum of the Twelve Apostles
-	"Jeffrey R. Holland":           "jeffrey-holland",
-	"Elder Jeffrey R. Holland":     "jeffrey-holland",
-	"Elder Holland":                "jeffrey-holland",
-	"Dieter F. Uchtdorf":           "dieter-uchtdorf",
-	"Elder Dieter F. Uchtdorf":     "dieter-uchtdorf",
-	"Elder Uchtdorf":               "dieter-uchtdorf",
-	"David A. Bednar":              "david-bednar",
-	"Elder David A. Bednar":        "david-bednar",
-	"Elder Bednar":                 "david-bednar",
-	"Quentin L. Cook":              "quentin-cook",
-	"Elder Quentin L. Cook":        "quentin-cook",
-	"Elder Cook":                   "quentin-cook",
-	"D. Todd Christofferson":       "todd-christofferson",
-	"Elder D. Todd Christofferson": "todd-christofferson",
-	"Elder Christofferson":         "todd-christofferson",
-	"Neil L. Andersen":             "neil-andersen",
-	"Elder Neil L. Andersen":       "neil-andersen",
-	"Elder Andersen":               "neil-andersen",
-	"Ronald A. Rasband":            "ronald-rasband",
-	"Elder Ronald A. Rasband":      "ronald-rasband",
-	"Elder Rasband":                "ronald-rasband",
-	"Gary E. Stevenson":            "gary-stevenson",
-	"Elder Gary E. Stevenson":      "gary-stevenson",
-	"Elder Stevenson":              "gary-stevenson",
-	"Dale G. Renlund":              "dale-renlund",
-	"Elder Dale G. Renlund":        "dale-renlund",
-	"Elder Renlund":                "dale-renlund",
-	"Gerrit W. Gong":               "gerrit-gong",
-	"Elder Gerrit W. Gong":         "gerrit-gong",
-	"Elder Gong":                   "gerrit-gong",
-	"Ulisses Soares":               "ulisses-soares",
-	"Elder Ulisses Soares":         "ulisses-soares",
-	"Elder Soares":                 "ulisses-soares",
-	"Patrick Kearon":               "patrick-kearon",
-	"Elder Patrick Kearon":         "patrick-kearon",
-	"Elder Kearon":                 "patrick-kearon",
-
-	// Past Church Presidents (commonly quoted)
-	"Gordon B. Hinckley":           "gordon-hinckley",
-	"President Gordon B. Hinckley": "gordon-hinckley",
-	"President Hinckley":           "gordon-hinckley",
-	"Thomas S. Monson":             "thomas-monson",
-	"President Thomas S. Monson":   "thomas-monson",
-	"President Monson":             "thomas-monson",
-	"Howard W. Hunter":             "howard-hunter",
-	"President Howard W. Hunter":   "howard-hunter",
-	"President Hunter":             "howard-hunter",
-	"Ezra Taft Benson":             "ezra-benson",
-	"President Ezra Taft Benson":   "ezra-benson",
-	"President Benson":             "ezra-benson",
-	"Spencer W. Kimball":           "spencer-kimball",
-	"President Spencer W. Kimball": "spencer-kimball",
-	"President Kimball":            "spencer-kimball",
-	"Joseph Smith":                 "joseph-smith",
-	"Prophet Joseph Smith":         "joseph-smith",
-	"Joseph Smith Jr.":             "joseph-smith",
-
-	// Relief Society General Presidents
-	"Camille N. Johnson":        "camille-johnson",
-	"Sister Camille N. Johnson": "camille-johnson",
-	"Jean B. Bingham":           "jean-bingham",
-	"Sister Jean B. Bingham":    "jean-bingham",
-
-	// Young Women General Presidents
-	"Emily Belle Freeman":        "emily-freeman",
-	"Sister Emily Belle Freeman": "emily-freeman",
-
-	// Primary General Presidents
-	"Susan H. Porter":        "susan-porter",
-	"Sister Susan H. Porter": "susan-porter",
-
-	// Presiding Bishopric
-	"Gerald Causse":                 "gerald-causse",
-	"Bishop Gerald Causse":          "gerald-causse",
-	"W. Christopher Waddell":        "christopher-waddell",
-	"Bishop W. Christopher Waddell": "christopher-waddell",
-	"L. Todd Budge":                 "todd-budge",
-	"Bishop L. Todd Budge":          "todd-budge",
-}
+// speakerRegistry resolves speaker names to slugs, display names and
+// headshots. main replaces the seed registry with the agent's, which is
+// loaded from the speakers table.
+var speakerRegistry = prophetagent.NewSpeakerRegistry()
 
-// lookupSpeakerHeadshot returns the headshot URL for a speaker name.
-// It tries exact match first, then partial matching for common variations.
+// lookupSpeakerHeadshot returns the headshot URL for a speaker name, or ""
+// if the speaker is unknown or has no headshot.
 func lookupSpeakerHeadshot(name string) string {
-	// Clean up the name
-	name = strings.TrimSpace(name)
-
-	// Try exact match first
-	if slug, ok := speakerHeadshots[name]; ok {
-		return assetsBaseURL + "/headshots/" + slug + "-square.webp"
-	}
-
-	// Try case-insensitive match
-	nameLower := strings.ToLower(name)
-	for key, slug := range speakerHeadshots {
-		if strings.ToLower(key) == nameLower {
-			return assetsBaseURL + "/headshots/" + slug + "-square.webp"
-		}
-	}
-
-	// Try partial match - check if name contains a known speaker's full name
-	for key, slug := range speakerHeadshots {
-		// Skip short keys (Elder Oaks, etc.) for contains matching to avoid false positives
-		if len(key) > 15 && strings.Contains(nameLower, strings.ToLower(key)) {
-			return assetsBaseURL + "/headshots/" + slug + "-square.webp"
-		}
+	sp, ok := speakerRegistry.Lookup(name)
+	if !ok || sp.HeadshotFile() == "" {
+		return ""
 	}
-
-	// No match found
-	return ""
+	return assetsBaseURL + "/headshots/" + sp.HeadshotFile()
 }
 
 func main() {
+	mcpStdio := flag.Bool("mcp-stdio", false, "serve the MCP tools over stdin/stdout instead of the web app")
+	flag.Parse()
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -180,6 +74,33 @@ func main() {
 		log.Fatalf("Failed to create agent: %v", err)
 	}
 	log.Println("Prophet agent initialized (Gemini REST API)")
+	speakerRegistry = prophetAgent.Speakers()
+
+	if err := initSuggestions(); err != nil {
+		log.Fatalf("Failed to load suggestion catalog: %v", err)
+	}
+
+	initSessionSigner()
+	sessions = newSessionManager(
+		getEnvDuration("SESSION_RUN_TIMEOUT", 5*time.Minute),
+		getEnvDuration("SESSION_ORPHAN_TIMEOUT", time.Minute),
+	)
+
+	initRateLimits()
+	initAnswers(ctx, prophetAgent)
+	if err := initAPI(); err != nil {
+		log.Fatalf("Invalid API_KEYS: %v", err)
+	}
+
+	// MCP over stdio: the client that started the process talks to the
+	// tools directly and no web server runs (logs go to stderr)
+	if *mcpStdio {
+		log.Println("Serving MCP tools over stdio")
+		if err := newMCPServer(prophetAgent).ServeStdio(ctx, os.Stdin, os.Stdout); err != nil {
+			log.Fatalf("MCP stdio failed: %v", err)
+		}
+		return
+	}
 
 	// Create GoFr app
 	gofrApp := gofr.New()
@@ -202,7 +123,13 @@ func main() {
 		// This endpoint handles the initial POST and returns HTML for HTMX
 		// GoFr Bind() supports both JSON and form-urlencoded
 		var req struct {
-			Question string `json:"question" form:"question"`
+			Question     string `json:"question" form:"question"`
+			SuggestionID string `json:"suggestion_id" form:"suggestion_id"`
+			Locale       string `json:"locale" form:"locale"`
+			// FollowUp is the answered session this question follows up,
+			// with that session's stream token
+			FollowUp string `json:"follow_up" form:"follow_up"`
+			Token    string `json:"token" form:"token"`
 		}
 
 		if err := ctx.Bind(&req); err != nil {
@@ -214,33 +141,46 @@ func main() {
 		}
 
 		question := req.Question
+		locale := req.Locale
+		if locale == "" {
+			locale = string(prophetagent.DetectLanguage(question))
+		}
+
+		// Track click-throughs from suggested questions
+		recordSuggestionClick(req.SuggestionID, locale)
 
 		// Validate and classify content
-		classification := prophetagent.ClassifyContent(question)
+		classification, category := prophetagent.ClassifyContentCategory(question)
 		if classification != prophetagent.ContentSafe {
-			redirect := prophetagent.GetRedirectResponse(classification)
 			// Render the RedirectResponse templ component as HTML
-			var buf bytes.Buffer
-			err := components.RedirectResponse(redirect.Message, redirect.SuggestedQuestions).Render(ctx.Request.Context(), &buf)
+			html, err := renderRedirect(ctx.Request.Context(), classification, category, locale)
 			if err != nil {
-				return nil, fmt.Errorf("failed to render redirect response: %w", err)
+				return nil, err
 			}
 			// Use File response type to return raw HTML without JSON encoding
 			return response.File{
-				Content:     buf.Bytes(),
+				Content:     html,
 				ContentType: "text/html; charset=utf-8",
 			}, nil
 		}
 
-		// Generate session ID for this question
-		sessionID := fmt.Sprintf("session-%d", time.Now().UnixNano())
+		// Generate session ID for this question and start the agent right away,
+		// so the run doesn't depend on the lifetime of any SSE connection
+		var history *prophetagent.Conversation
+		if req.FollowUp != "" {
+			history = followUpHistory(req.FollowUp, req.Token)
+		}
+		sessionID := newSessionID()
+		sess, _ := startAgentSession(sessionID, question, history, prophetAgent)
 
 		// Render the StreamContainer templ component as HTML
 		var buf bytes.Buffer
 		err := components.StreamContainer(components.StreamContainerProps{
 			SessionID: sessionID,
 			Question:  question,
-		}).Render(ctx.Request.Context(), &buf)
+			Token:     signer.sign(sessionID, question),
+			FollowUp:  req.FollowUp != "",
+		}).Render(components.WithLocale(ctx.Request.Context(), string(sess.lang)), &buf)
 		if err != nil {
 			return nil, fmt.Errorf("failed to render stream container: %w", err)
 		}
@@ -252,6 +192,68 @@ func main() {
 		}, nil
 	})
 
+	// Re-render the stream container for a session that is still running or
+	// recently finished, so a refreshed kiosk page can reattach and replay it
+	gofrApp.GET("/resume", func(ctx *gofr.Context) (interface{}, error) {
+		sess := sessions.get(ctx.Param("session"))
+		if sess == nil {
+			return response.File{ContentType: "text/html; charset=utf-8"}, nil
+		}
+		var buf bytes.Buffer
+		err := components.StreamContainer(components.StreamContainerProps{
+			SessionID: sess.id,
+			Question:  sess.question,
+			Token:     signer.sign(sess.id, sess.question),
+			FollowUp:  sess.history != nil,
+		}).Render(components.WithLocale(ctx.Request.Context(), string(sess.lang)), &buf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render stream container: %w", err)
+		}
+		return response.File{
+			Content:     buf.Bytes(),
+			ContentType: "text/html; charset=utf-8",
+		}, nil
+	})
+
+	// Explicitly abandon a running session (e.g. the visitor asked something else)
+	gofrApp.POST("/api/abandon", func(ctx *gofr.Context) (interface{}, error) {
+		var req struct {
+			Session string `json:"session" form:"session"`
+			Token   string `json:"token" form:"token"`
+		}
+		if err := ctx.Bind(&req); err != nil {
+			return nil, fmt.Errorf("failed to parse request: %w", err)
+		}
+		sess := sessions.get(req.Session)
+		if sess == nil {
+			return map[string]bool{"abandoned": false}, nil
+		}
+		if err := signer.verify(sess.id, sess.question, req.Token); err != nil {
+			return nil, fmt.Errorf("cannot abandon session: %w", err)
+		}
+		return map[string]bool{"abandoned": sessions.abandon(sess.id)}, nil
+	})
+
+	// QR codes for links kiosk visitors can't click
+	gofrApp.GET("/qr", handleQR)
+
+	// Saved answers, opened from a take-home QR code or a shared link
+	gofrApp.GET("/a/{id}", handleAnswerPage)
+	gofrApp.POST("/api/answers/delete", handleDeleteAnswer)
+
+	// Suggestion analytics for the content team (statsAuthMiddleware checks the key)
+	gofrApp.GET("/api/suggestions/stats", func(ctx *gofr.Context) (interface{}, error) {
+		return map[string]interface{}{
+			"catalog_version": suggestionCatalog.Version,
+			"suggestions":     suggestionStats.Snapshot(),
+		}, nil
+	})
+
+	// Gemini scheduler queue-wait metrics per priority class (also keyed)
+	gofrApp.GET("/api/llm/stats", func(ctx *gofr.Context) (interface{}, error) {
+		return prophetAgent.SchedulerStats(), nil
+	})
+
 	// Start servers
 	apiPort := getEnv("API_PORT", "8081")
 
@@ -263,6 +265,9 @@ func main() {
 			handleSSEStream(w, r, prophetAgent)
 		})
 
+		// Versioned JSON API, which sets its own status codes
+		registerAPI(sseMux, prophetAgent)
+
 		// Test endpoint to debug Gemini API latency from Cloud Run
 		sseMux.HandleFunc("/api/test-gemini", func(w http.ResponseWriter, r *http.Request) {
 			handleTestGemini(w, r)
@@ -329,6 +334,12 @@ func main() {
 	// Add middleware to proxy /api/stream requests to internal SSE server
 	gofrApp.UseMiddleware(sseProxyMiddleware(sseProxy))
 
+	// Limit how often each client may ask before the request reaches GoFr
+	gofrApp.UseMiddleware(rateLimitMiddleware())
+
+	// Stats endpoints need an API key or the admin key
+	gofrApp.UseMiddleware(statsAuthMiddleware())
+
 	// Start GoFr server (SSE requests are proxied to internal server)
 	log.Printf("GoFr server starting (SSE streaming proxied via /api/stream)")
 	gofrApp.Run()
@@ -392,8 +403,8 @@ func (frw *flushingResponseWriter) Flush() {
 	}
 }
 
-// sseProxyMiddleware creates middleware that proxies /api/stream requests
-// to the internal SSE server, enabling SSE streaming through GoFr.
+// sseProxyMiddleware creates middleware that proxies /api/stream and the
+// JSON API to the internal SSE server, enabling SSE streaming through GoFr.
 func sseProxyMiddleware(proxy *httputil.ReverseProxy) gofrHTTP.Middleware {
 	return func(inner http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -416,6 +427,11 @@ func sseProxyMiddleware(proxy *httputil.ReverseProxy) gofrHTTP.Middleware {
 				proxy.ServeHTTP(frw, r)
 				return
 			}
+			// The JSON API sets its own headers and streams only when asked
+			if strings.HasPrefix(r.URL.Path, apiBasePath) {
+				proxy.ServeHTTP(&flushingResponseWriter{w: w, flusher: extractFlusher(w)}, r)
+				return
+			}
 			// Pass through to GoFr for all other requests
 			inner.ServeHTTP(w, r)
 		})
@@ -460,3 +476,29 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Printf("Invalid %s=%q, using %v", key, v, fallback)
+		return fallback
+	}
+	return d
+}
+
+func getEnvInt(key string, fallback int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Printf("Invalid %s=%q, using %d", key, v, fallback)
+		return fallback
+	}
+	return n
+}
@@ -0,0 +1,210 @@
+// cmd/server/mcp.go
+// MCP server: the answer pipeline and its lookups as tools for other
+// assistants, over stdio (-mcp-stdio) or streamable HTTP at /api/v1/mcp
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	prophetagent "github.com/temple-square/prophet-agent/internal/agent"
+	"github.com/temple-square/prophet-agent/internal/mcp"
+	"github.com/temple-square/prophet-agent/internal/openapi"
+)
+
+const mcpInstructions = "Answers questions about the teachings of The Church of Jesus Christ of Latter-day Saints " +
+	"with exact quotes from recent General Conference talks and scriptures, each with a link to its source. " +
+	"Use ask_prophet_question for a full answer; use find_leader_quote or find_scriptures_for_topic for one " +
+	"quote or a few verses. Quote the results verbatim and cite their sources."
+
+// askInput is the input of ask_prophet_question.
+type askInput struct {
+	Question string `json:"question" doc:"The visitor's question, up to 1000 characters"`
+}
+
+// leaderQuoteInput is the input of find_leader_quote.
+type leaderQuoteInput struct {
+	Topic   string `json:"topic" doc:"What the quote should be about"`
+	Speaker string `json:"speaker,omitempty" doc:"A Church leader's name, e.g. Dallin H. Oaks; any leader when empty"`
+}
+
+// scripturesInput is the input of find_scriptures_for_topic.
+type scripturesInput struct {
+	Topic  string `json:"topic" doc:"What the verses should be about"`
+	Volume string `json:"volume,omitempty" enum:"bible,book_of_mormon,other" doc:"Limit to the Bible, the Book of Mormon, or other (Doctrine and Covenants and Pearl of Great Price); any volume when empty"`
+}
+
+// errTopicRedirected is returned by the lookups for topics the kiosk
+// redirects rather than answers.
+var errTopicRedirected = errors.New("this topic isn't one we answer with quotes; ask_prophet_question offers related questions instead")
+
+// newMCPServer exposes the agent's pipeline as MCP tools.
+func newMCPServer(agent *prophetagent.ProphetAgent) *mcp.Server {
+	server := mcp.NewServer("ask-a-prophet", "1.0.0", mcpInstructions)
+
+	server.AddTool(mcp.Tool{
+		Name:  "ask_prophet_question",
+		Title: "Ask a prophet",
+		Description: "Answers a question like the Temple Square kiosk: remarks from Church Presidents and other " +
+			"Church leaders, related scriptures from the Bible, Book of Mormon and other volumes, a short summary, " +
+			"and questions to explore next. " +
+			"Takes up to a minute. Questions on some topics are redirected with suggested questions instead.",
+		InputSchema:  openapi.InlineSchema(askInput{}),
+		OutputSchema: openapi.InlineSchema(APIAnswer{}),
+		Call: func(ctx context.Context, args json.RawMessage) (*mcp.Result, error) {
+			var in askInput
+			if err := decodeToolInput(args, &in); err != nil {
+				return nil, err
+			}
+			question, err := checkToolText("question", in.Question)
+			if err != nil {
+				return nil, err
+			}
+			if err := allowMCPClient(ctx); err != nil {
+				return nil, err
+			}
+			answer, err := answerQuestion(ctx, agent, question, string(prophetagent.DetectLanguage(question)), newAPIEvents(nil))
+			if err != nil {
+				return nil, err
+			}
+			return mcp.StructuredResult(answer)
+		},
+	})
+
+	server.AddTool(mcp.Tool{
+		Name:  "find_leader_quote",
+		Title: "Find a Church leader's quote",
+		Description: "Finds the quote most relevant to a topic in recent General Conference talks, " +
+			"optionally by a given Church leader.",
+		InputSchema:  openapi.InlineSchema(leaderQuoteInput{}),
+		OutputSchema: openapi.InlineSchema(prophetagent.LeadersResponse{}),
+		Call: func(ctx context.Context, args json.RawMessage) (*mcp.Result, error) {
+			var in leaderQuoteInput
+			if err := decodeToolInput(args, &in); err != nil {
+				return nil, err
+			}
+			topic, err := checkToolTopic(ctx, in.Topic)
+			if err != nil {
+				return nil, err
+			}
+			var quotes []prophetagent.StructuredQuote
+			err = withRunSlot(ctx, func(ctx context.Context) (err error) {
+				quotes, err = agent.FindLeaderQuote(ctx, topic, strings.TrimSpace(in.Speaker))
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+			return mcp.StructuredResult(prophetagent.LeadersResponse{Quotes: orEmpty(quotes)})
+		},
+	})
+
+	server.AddTool(mcp.Tool{
+		Name:         "find_scriptures_for_topic",
+		Title:        "Find scriptures for a topic",
+		Description:  "Finds verses on a topic from the standard works, optionally from one volume.",
+		InputSchema:  openapi.InlineSchema(scripturesInput{}),
+		OutputSchema: openapi.InlineSchema(prophetagent.ScripturesResponse{}),
+		Call: func(ctx context.Context, args json.RawMessage) (*mcp.Result, error) {
+			var in scripturesInput
+			if err := decodeToolInput(args, &in); err != nil {
+				return nil, err
+			}
+			topic, err := checkToolTopic(ctx, in.Topic)
+			if err != nil {
+				return nil, err
+			}
+			var verses []prophetagent.StructuredScripture
+			err = withRunSlot(ctx, func(ctx context.Context) (err error) {
+				verses, err = agent.FindScriptures(ctx, topic, prophetagent.ScriptureVolume(in.Volume))
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+			return mcp.StructuredResult(prophetagent.ScripturesResponse{Scriptures: orEmpty(verses)})
+		},
+	})
+
+	return server
+}
+
+func decodeToolInput(args json.RawMessage, out any) error {
+	if err := json.Unmarshal(args, out); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+// checkToolText trims a required text argument and checks its length.
+func checkToolText(name, text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", fmt.Errorf("%s is required", name)
+	}
+	if utf8.RuneCountInString(text) > maxAPIQuestionLength {
+		return "", fmt.Errorf("%s is longer than %d characters", name, maxAPIQuestionLength)
+	}
+	return text, nil
+}
+
+// checkToolTopic checks a lookup's topic. The lookups skip the orchestrator,
+// so the topic is screened here like a question, and they count against
+// the client's rate limit.
+func checkToolTopic(ctx context.Context, topic string) (string, error) {
+	topic, err := checkToolText("topic", topic)
+	if err != nil {
+		return "", err
+	}
+	if prophetagent.ClassifyContent(topic) != prophetagent.ContentSafe {
+		return "", errTopicRedirected
+	}
+	return topic, allowMCPClient(ctx)
+}
+
+// withRunSlot runs fn in an agent run slot, like the kiosk's questions.
+func withRunSlot(ctx context.Context, fn func(context.Context) error) error {
+	release, err := runs.acquire(ctx, func(int) {})
+	if errors.Is(err, errRunQueueFull) {
+		return errAPIBusy
+	}
+	if err != nil {
+		return err
+	}
+	defer release()
+	return fn(ctx)
+}
+
+type mcpClientKey struct{}
+
+// allowMCPClient applies the API rate limit to an HTTP client's tool call.
+// Over stdio there is no client: whoever started the process is trusted.
+func allowMCPClient(ctx context.Context) error {
+	client, ok := ctx.Value(mcpClientKey{}).(string)
+	if !ok {
+		return nil
+	}
+	if ok, wait := apiLimiter.allow(client); !ok {
+		log.Printf("MCP: Rate limited client=%s (retry in %v)", client, wait)
+		return fmt.Errorf("too many requests, try again in %v", wait.Round(time.Second)+time.Second)
+	}
+	return nil
+}
+
+// mcpHTTPHandler serves the MCP server to clients with an API key.
+func mcpHTTPHandler(server *mcp.Server) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client, ok := authenticateAPI(w, r)
+		if !ok {
+			return
+		}
+		server.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), mcpClientKey{}, client)))
+	})
+}
@@ -0,0 +1,116 @@
+// cmd/server/mcp_test.go
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func postMCP(t *testing.T, key, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	mux := http.NewServeMux()
+	registerAPI(mux, nil)
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/mcp", strings.NewReader(body))
+	if key != "" {
+		r.Header.Set("Authorization", "Bearer "+key)
+	}
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	return w
+}
+
+func TestMCPRequiresAPIKey(t *testing.T) {
+	withAPIKeys(t, "test:secret")
+	if w := postMCP(t, "", `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", w.Code)
+	}
+}
+
+func TestMCPToolsList(t *testing.T) {
+	withAPIKeys(t, "test:secret")
+	w := postMCP(t, "secret", `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+	var resp struct {
+		Result struct {
+			Tools []struct {
+				Name         string         `json:"name"`
+				InputSchema  map[string]any `json:"inputSchema"`
+				OutputSchema map[string]any `json:"outputSchema"`
+			} `json:"tools"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("body = %s: %v", w.Body, err)
+	}
+	var names []string
+	for _, tool := range resp.Result.Tools {
+		names = append(names, tool.Name)
+		// MCP requires object schemas, with nothing left to resolve
+		if tool.InputSchema["type"] != "object" || tool.OutputSchema["type"] != "object" {
+			t.Errorf("%s schemas = %v, %v", tool.Name, tool.InputSchema, tool.OutputSchema)
+		}
+		if strings.Contains(w.Body.String(), "$ref") {
+			t.Errorf("%s schemas use $ref", tool.Name)
+		}
+	}
+	if got := strings.Join(names, ","); got != "ask_prophet_question,find_leader_quote,find_scriptures_for_topic" {
+		t.Errorf("tools = %s", got)
+	}
+}
+
+type mcpCallResponse struct {
+	Result struct {
+		Content           []struct{ Text string } `json:"content"`
+		StructuredContent json.RawMessage         `json:"structuredContent"`
+		IsError           bool                    `json:"isError"`
+	} `json:"result"`
+}
+
+func callMCPTool(t *testing.T, name, args string) mcpCallResponse {
+	t.Helper()
+	w := postMCP(t, "secret", `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"`+name+`","arguments":`+args+`}}`)
+	var resp mcpCallResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("body = %s: %v", w.Body, err)
+	}
+	return resp
+}
+
+// Redirected questions are answered without running the agents.
+func TestMCPAskRedirect(t *testing.T) {
+	withAPIKeys(t, "test:secret")
+	resp := callMCPTool(t, "ask_prophet_question", `{"question":"What about polygamy?"}`)
+	var answer APIAnswer
+	if err := json.Unmarshal(resp.Result.StructuredContent, &answer); err != nil || resp.Result.IsError {
+		t.Fatalf("result = %+v, %v", resp.Result, err)
+	}
+	if answer.Status != apiRedirected || answer.Redirect == nil {
+		t.Errorf("answer = %+v", answer)
+	}
+
+	// The redirect is in the question's language
+	resp = callMCPTool(t, "ask_prophet_question", `{"question":"¿Qué enseña la Iglesia sobre la poligamia?"}`)
+	if err := json.Unmarshal(resp.Result.StructuredContent, &answer); err != nil || answer.Redirect == nil || !strings.Contains(answer.Redirect.Message, "misioneros") {
+		t.Errorf("Spanish answer = %+v, %v", answer, err)
+	}
+}
+
+func TestMCPToolsRejectInput(t *testing.T) {
+	withAPIKeys(t, "test:secret")
+	tests := []struct{ name, tool, args, want string }{
+		{"no question", "ask_prophet_question", `{"question":" "}`, "question is required"},
+		{"no topic", "find_leader_quote", `{}`, "topic is required"},
+		{"redirected topic", "find_scriptures_for_topic", `{"topic":"polygamy"}`, errTopicRedirected.Error()},
+		{"bad arguments", "find_leader_quote", `{"topic":7}`, "invalid arguments"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := callMCPTool(t, tt.tool, tt.args)
+			if !resp.Result.IsError || len(resp.Result.Content) == 0 || !strings.Contains(resp.Result.Content[0].Text, tt.want) {
+				t.Errorf("result = %+v, want error %q", resp.Result, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+// cmd/server/openapi.go
+// OpenAPI spec of the JSON API, generated from the Go types it encodes
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/temple-square/prophet-agent/internal/openapi"
+)
+
+// apiSpec describes the JSON API.
+func apiSpec() *openapi.Document {
+	doc := openapi.New(openapi.Info{
+		Title:   "Ask a Prophet API",
+		Version: "1.0.0",
+		Description: "Answers questions with remarks from Church Presidents and other Church leaders, " +
+			"related scriptures and a summary, as structured JSON.",
+	})
+	doc.Servers = []openapi.Server{{URL: publicBaseURL}}
+	doc.Components.SecuritySchemes["bearer"] = &openapi.SecurityScheme{
+		Type:        "http",
+		Scheme:      "bearer",
+		Description: "An API key from API_KEYS",
+	}
+	doc.Components.SecuritySchemes["apiKey"] = &openapi.SecurityScheme{
+		Type: "apiKey",
+		In:   "header",
+		Name: "X-API-Key",
+	}
+	doc.Security = []openapi.Requirement{{"bearer": {}}, {"apiKey": {}}}
+
+	jsonBody := func(v any) map[string]openapi.MediaType {
+		return map[string]openapi.MediaType{"application/json": {Schema: doc.Schema(v)}}
+	}
+	errorResponse := func(description string) *openapi.Response {
+		return &openapi.Response{Description: description, Content: jsonBody(APIError{})}
+	}
+	retryAfter := map[string]openapi.Header{
+		"Retry-After": {Description: "Seconds to wait before retrying", Schema: &openapi.Schema{Type: "integer"}},
+	}
+
+	// Stream event payloads, referenced from the description below
+	for _, event := range []any{APIWaiting{}, APISectionStatus{}, SummaryResponse{}} {
+		doc.Schema(event)
+	}
+
+	doc.Path(apiBasePath + "answers").Post = &openapi.Operation{
+		OperationID: "createAnswer",
+		Summary:     "Answer a question",
+		Description: "Runs the agents for the question and returns the finished answer. " +
+			"With `Accept: text/event-stream` or `stream=true` the answer is streamed as server-sent events " +
+			"whose data is JSON: `waiting` (APIWaiting) while queued; `presidents` (PresidentsResponse), " +
+			"`leaders` (LeadersResponse), `scriptures` (APIScriptures) and `summary` (SummaryResponse) " +
+			"each time a section changes; `summary_progress` (SummaryResponse) while the summary is written; " +
+			"`section_status` (APISectionStatus) when a section is final; and last `answer` (APIAnswer) " +
+			"or `error` (APIError).",
+		Parameters: []openapi.Parameter{{
+			Name:        "stream",
+			In:          "query",
+			Description: "Stream the answer as server-sent events",
+			Schema:      &openapi.Schema{Type: "boolean"},
+		}},
+		RequestBody: &openapi.RequestBody{Required: true, Content: jsonBody(APIAnswerRequest{})},
+		Responses: map[string]*openapi.Response{
+			"200": {
+				Description: "The answer, or its event stream",
+				Content: map[string]openapi.MediaType{
+					"application/json":  {Schema: doc.Schema(APIAnswer{})},
+					"text/event-stream": {Schema: &openapi.Schema{Type: "string"}},
+				},
+			},
+			"400": errorResponse("The question is missing or too long"),
+			"401": errorResponse("The API key is missing or invalid"),
+			"429": {Description: "The client asked too often", Headers: retryAfter, Content: jsonBody(APIError{})},
+			"503": {Description: "Too many questions are being answered", Headers: retryAfter, Content: jsonBody(APIError{})},
+		},
+	}
+
+	doc.Path(apiBasePath + "openapi.json").Get = &openapi.Operation{
+		OperationID: "getOpenAPISpec",
+		Summary:     "This document",
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The OpenAPI document", Content: map[string]openapi.MediaType{
+				"application/json": {Schema: &openapi.Schema{Type: "object"}},
+			}},
+		},
+		Security: []openapi.Requirement{{}},
+	}
+	return doc
+}
+
+// apiSpecJSON is the encoded spec; it only changes with the code.
+var apiSpecJSON = sync.OnceValue(func() []byte {
+	b, err := json.MarshalIndent(apiSpec(), "", "  ")
+	if err != nil {
+		log.Printf("API: Failed to encode OpenAPI spec: %v", err)
+		return nil
+	}
+	return b
+})
+
+// handleOpenAPISpec serves the spec. It is public so clients can be
+// generated before a key is issued.
+func handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
+	spec := apiSpecJSON()
+	if spec == nil {
+		writeAPIError(w, http.StatusInternalServerError, "spec unavailable")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(spec)
+}
@@ -0,0 +1,67 @@
+// cmd/server/qr.go
+// QR code images for links kiosk visitors can't click
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gofr.dev/pkg/gofr"
+	"gofr.dev/pkg/gofr/http/response"
+
+	"github.com/temple-square/prophet-agent/internal/qr"
+)
+
+const (
+	// maxQRData bounds the encoded link; real links are well under it
+	maxQRData = 512
+	// defaultQRScale is the PNG size of one module in pixels
+	defaultQRScale = 8
+	maxQRScale     = 20
+)
+
+// qrTargetAllowed reports whether data is a link the app would print: one
+// of its own take-home links or a Church site page. The endpoint is not a
+// general-purpose QR generator.
+func qrTargetAllowed(data string) bool {
+	if len(data) > maxQRData {
+		return false
+	}
+	return strings.HasPrefix(data, publicBaseURL+"/") || sourceURL(data) != ""
+}
+
+// handleQR draws a QR code for the data parameter as SVG (the default) or,
+// with format=png, as a PNG scaled by the scale parameter.
+func handleQR(ctx *gofr.Context) (interface{}, error) {
+	data := ctx.Param("data")
+	if !qrTargetAllowed(data) {
+		return nil, fmt.Errorf("qr: data must be a link to this site or churchofjesuschrist.org")
+	}
+	// Medium survives glare on the kiosk screen and keeps links in small versions
+	code, err := qr.Encode(data, qr.Medium)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format := ctx.Param("format"); format {
+	case "", "svg":
+		return response.File{Content: code.SVG(qr.DefaultBorder), ContentType: "image/svg+xml"}, nil
+	case "png":
+		scale := defaultQRScale
+		if raw := ctx.Param("scale"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 1 || n > maxQRScale {
+				return nil, fmt.Errorf("qr: scale must be between 1 and %d", maxQRScale)
+			}
+			scale = n
+		}
+		png, err := code.PNG(scale, qr.DefaultBorder)
+		if err != nil {
+			return nil, err
+		}
+		return response.File{Content: png, ContentType: "image/png"}, nil
+	default:
+		return nil, fmt.Errorf("qr: unknown format %q", format)
+	}
+}
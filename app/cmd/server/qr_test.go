@@ -0,0 +1,22 @@
+// cmd/server/qr_test.go
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQRTargetAllowed(t *testing.T) {
+	cases := map[string]bool{
+		publicBaseURL + "/a/0123456789abcdef01234567":                                          true,
+		"https://www.churchofjesuschrist.org/study/general-conference/2024/10/11oaks?lang=eng": true,
+		"https://example.com/":                               false,
+		"javascript:alert(1)":                                false,
+		publicBaseURL + "/" + strings.Repeat("a", maxQRData): false,
+	}
+	for data, want := range cases {
+		if got := qrTargetAllowed(data); got != want {
+			t.Errorf("qrTargetAllowed(%.60q) = %v, want %v", data, got, want)
+		}
+	}
+}
@@ -0,0 +1,360 @@
+// cmd/server/ratelimit.go
+// Per-client rate limiting and the global cap on concurrent agent runs
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	gofrHTTP "gofr.dev/pkg/gofr/http"
+
+	"github.com/temple-square/prophet-agent/internal/ui/components"
+)
+
+// rateLimiter is a token bucket per client key. Buckets refill continuously
+// at rate tokens/second up to burst, and idle buckets are swept periodically.
+type rateLimiter struct {
+	mu        sync.Mutex
+	rate      float64
+	burst     float64
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(perMinute, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rate:    float64(perMinute) / 60,
+		burst:   float64(burst),
+		buckets: map[string]*tokenBucket{},
+		now:     time.Now,
+	}
+}
+
+// allow takes a token for key. When the bucket is empty it reports how long
+// until the next token is available.
+func (l *rateLimiter) allow(key string) (bool, time.Duration) {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > time.Minute {
+		l.sweepLocked(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	if l.rate <= 0 {
+		return false, time.Minute
+	}
+	wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	return false, wait
+}
+
+// sweepLocked drops buckets that have refilled completely.
+func (l *rateLimiter) sweepLocked(now time.Time) {
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// proxySecretHeader carries the shared secret the Cloudflare workers add to
+// requests they forward, so their CF-Connecting-IP can be believed.
+const proxySecretHeader = "X-Proxy-Secret"
+
+// proxyTrust decides which forwarding headers name the real client. Any
+// caller can send them, so they are only read from requests that carry the
+// workers' secret or come from a trusted proxy address.
+type proxyTrust struct {
+	secret  string
+	proxies []*net.IPNet
+}
+
+var trust proxyTrust
+
+// newProxyTrust parses TRUSTED_PROXY_SECRET and the comma-separated CIDRs
+// of TRUSTED_PROXIES (bare addresses are single hosts). Invalid entries are
+// logged and skipped. Loopback is always trusted: /api/stream reaches the
+// internal server through the in-process proxy, which appends the client.
+func newProxyTrust(secret, cidrs string) proxyTrust {
+	t := proxyTrust{secret: secret}
+	for _, c := range strings.Split("127.0.0.0/8,::1,"+cidrs, ",") {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		if !strings.Contains(c, "/") {
+			if ip := net.ParseIP(c); ip != nil && ip.To4() != nil {
+				c += "/32"
+			} else {
+				c += "/128"
+			}
+		}
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			log.Printf("Rate limit: ignoring invalid trusted proxy %q: %v", c, err)
+			continue
+		}
+		t.proxies = append(t.proxies, n)
+	}
+	return t
+}
+
+func (t proxyTrust) trusted(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, n := range t.proxies {
+		if n.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the visitor's address. Behind the Cloudflare workers the
+// real client is in CF-Connecting-IP, which is used when the request has the
+// workers' secret. Behind a trusted proxy it is the last X-Forwarded-For
+// entry not added by a trusted proxy: earlier entries come from the client.
+// Otherwise the forwarding headers are ignored and RemoteAddr is the client.
+func clientIP(r *http.Request) string {
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+	if trust.secret != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get(proxySecretHeader)), []byte(trust.secret)) == 1 {
+		if ip := strings.TrimSpace(r.Header.Get("CF-Connecting-IP")); ip != "" {
+			return ip
+		}
+	}
+	if !trust.trusted(remote) {
+		return remote
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := strings.TrimSpace(hops[i])
+		if ip == "" {
+			continue
+		}
+		if !trust.trusted(ip) {
+			return ip
+		}
+	}
+	return remote
+}
+
+var (
+	askLimiter    = newRateLimiter(6, 3)
+	streamLimiter = newRateLimiter(60, 20)
+)
+
+// initRateLimits configures proxy trust, the limiters and the run queue from
+// the environment.
+func initRateLimits() {
+	trust = newProxyTrust(getEnv("TRUSTED_PROXY_SECRET", ""), getEnv("TRUSTED_PROXIES", ""))
+	askLimiter = newRateLimiter(getEnvInt("RATE_LIMIT_ASK_PER_MIN", 6), getEnvInt("RATE_LIMIT_ASK_BURST", 3))
+	streamLimiter = newRateLimiter(getEnvInt("RATE_LIMIT_STREAM_PER_MIN", 60), getEnvInt("RATE_LIMIT_STREAM_BURST", 20))
+	runs = newRunQueue(getEnvInt("MAX_CONCURRENT_RUNS", 8), getEnvInt("MAX_QUEUED_RUNS", 32))
+}
+
+// rateLimitMiddleware limits POST /ask per client and renders a friendly
+// notice instead of starting another agent run.
+func rateLimitMiddleware() gofrHTTP.Middleware {
+	return func(inner http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost || r.URL.Path != "/ask" {
+				inner.ServeHTTP(w, r)
+				return
+			}
+			ip := clientIP(r)
+			if denied := admitRun(ip); denied != nil {
+				log.Printf("Rate limit: /ask denied for %s (%s)", ip, denied.reason)
+				writeBusyNotice(w, r, denied.wait, denied.title, denied.message)
+				return
+			}
+			inner.ServeHTTP(w, r)
+		})
+	}
+}
+
+// runDenial is why a client may not start another agent run right now.
+type runDenial struct {
+	reason         string // for the log
+	title, message string
+	wait           time.Duration
+}
+
+// admitRun applies the per-client question limit and the run queue cap to a
+// new agent run for ip, taking a token when it's allowed.
+func admitRun(ip string) *runDenial {
+	if ok, wait := askLimiter.allow(ip); !ok {
+		return &runDenial{
+			reason:  fmt.Sprintf("retry in %v", wait),
+			title:   "Let's take a moment",
+			message: "You've asked several questions in a short time. Please take a moment to read through the answers, then ask again in a little while.",
+			wait:    wait,
+		}
+	}
+	if runs.full() {
+		return &runDenial{
+			reason:  "run queue full",
+			title:   "Many visitors are asking right now",
+			message: "We're answering a lot of questions at the moment. Please try again in a minute.",
+			wait:    30 * time.Second,
+		}
+	}
+	return nil
+}
+
+// writeBusyNotice renders the BusyNotice component. It is sent with 200 so
+// HTMX swaps it into the response area like any other answer.
+func writeBusyNotice(w http.ResponseWriter, r *http.Request, retryAfter time.Duration, title, message string) {
+	var buf bytes.Buffer
+	if err := components.BusyNotice(title, message).Render(r.Context(), &buf); err != nil {
+		http.Error(w, message, http.StatusTooManyRequests)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Round(time.Second).Seconds())+1))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+var errRunQueueFull = errors.New("agent run queue is full")
+
+// runQueue caps concurrent agent runs. Extra runs wait in FIFO order and are
+// told their position whenever it changes.
+type runQueue struct {
+	mu       sync.Mutex
+	max      int
+	maxQueue int
+	running  int
+	waiters  []*runWaiter
+}
+
+type runWaiter struct {
+	ready      chan struct{}
+	onPosition func(position int)
+}
+
+var runs = newRunQueue(8, 32)
+
+func newRunQueue(maxRunning, maxQueued int) *runQueue {
+	if maxRunning < 1 {
+		maxRunning = 1
+	}
+	return &runQueue{max: maxRunning, maxQueue: maxQueued}
+}
+
+// full reports whether a new run would be rejected.
+func (q *runQueue) full() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.running >= q.max && len(q.waiters) >= q.maxQueue
+}
+
+// acquire blocks until a run slot is free. onPosition is called with the
+// 1-based queue position while waiting. The returned release must be called
+// when the run finishes.
+func (q *runQueue) acquire(ctx context.Context, onPosition func(position int)) (func(), error) {
+	q.mu.Lock()
+	if q.running < q.max && len(q.waiters) == 0 {
+		q.running++
+		q.mu.Unlock()
+		return q.release, nil
+	}
+	if len(q.waiters) >= q.maxQueue {
+		q.mu.Unlock()
+		return nil, errRunQueueFull
+	}
+	waiter := &runWaiter{ready: make(chan struct{}), onPosition: onPosition}
+	q.waiters = append(q.waiters, waiter)
+	position := len(q.waiters)
+	q.mu.Unlock()
+
+	onPosition(position)
+
+	select {
+	case <-waiter.ready:
+		return q.release, nil
+	case <-ctx.Done():
+		q.mu.Lock()
+		removed := false
+		for i, w := range q.waiters {
+			if w == waiter {
+				q.waiters = append(q.waiters[:i], q.waiters[i+1:]...)
+				removed = true
+				break
+			}
+		}
+		notify := q.positionsLocked()
+		q.mu.Unlock()
+		if !removed {
+			// Slot was handed over concurrently with cancellation.
+			q.release()
+		}
+		notify()
+		return nil, ctx.Err()
+	}
+}
+
+// release frees a run slot, handing it straight to the next waiter.
+func (q *runQueue) release() {
+	q.mu.Lock()
+	if len(q.waiters) == 0 {
+		q.running--
+		q.mu.Unlock()
+		return
+	}
+	next := q.waiters[0]
+	q.waiters = q.waiters[1:]
+	close(next.ready)
+	notify := q.positionsLocked()
+	q.mu.Unlock()
+	notify()
+}
+
+// positionsLocked snapshots waiter positions so callbacks run outside the lock.
+func (q *runQueue) positionsLocked() func() {
+	waiters := append([]*runWaiter(nil), q.waiters...)
+	return func() {
+		for i, w := range waiters {
+			w.onPosition(i + 1)
+		}
+	}
+}
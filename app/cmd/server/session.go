@@ -0,0 +1,327 @@
+// cmd/server/session.go
+// Session manager: background agent runs with resumable SSE subscribers
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	prophetagent "github.com/temple-square/prophet-agent/internal/agent"
+)
+
+const (
+	// sessionTTL is how long a finished session stays available for replay
+	sessionTTL = 10 * time.Minute
+	// maxSessionEvents bounds the replay buffer kept per session
+	maxSessionEvents = 64
+	// sseKeepAlive is how often an idle stream gets a comment line so proxies
+	// don't close it while the agent is still working
+	sseKeepAlive = 15 * time.Second
+)
+
+// sseEvent is a rendered SSE event retained for Last-Event-ID replay.
+type sseEvent struct {
+	ID   uint64
+	Name string
+	Data string
+}
+
+// streamSession holds the rendered events for one question. Events get
+// monotonically increasing IDs so a reconnecting browser can resume. The agent
+// run belongs to the session, not to any SSE connection.
+type streamSession struct {
+	id       string
+	question string
+	history  *prophetagent.Conversation // earlier questions this one follows up
+	lang     prophetagent.Language      // language the answer is shown in
+	cancel   context.CancelFunc
+
+	mu            sync.Mutex
+	events        []sseEvent
+	nextID        uint64
+	done          bool
+	expires       time.Time
+	notify        chan struct{}
+	subscribers   int
+	orphanTimeout time.Duration
+	orphanTimer   *time.Timer
+	turn          *prophetagent.Turn // what the finished answer showed
+}
+
+func newStreamSession(id, question string) *streamSession {
+	return &streamSession{
+		id:       id,
+		question: question,
+		lang:     prophetagent.DetectLanguage(question),
+		cancel:   func() {},
+		expires:  time.Now().Add(sessionTTL),
+		notify:   make(chan struct{}),
+	}
+}
+
+// recordTurn keeps what the answer showed, for follow-up questions.
+func (s *streamSession) recordTurn(turn prophetagent.Turn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.turn = &turn
+}
+
+// followUpHistory is the conversation a follow-up to this session
+// continues: its history plus its own answer, if it finished.
+func (s *streamSession) followUpHistory() *prophetagent.Conversation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.turn == nil {
+		return s.history
+	}
+	return s.history.With(*s.turn)
+}
+
+// attach registers an SSE subscriber and stops any pending orphan timeout.
+func (s *streamSession) attach() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers++
+	if s.orphanTimer != nil {
+		s.orphanTimer.Stop()
+		s.orphanTimer = nil
+	}
+}
+
+// detach unregisters an SSE subscriber. When the last one leaves an unfinished
+// session, the run is cancelled unless someone reattaches within the orphan timeout.
+func (s *streamSession) detach() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers--
+	if s.subscribers == 0 {
+		s.armOrphanTimerLocked()
+	}
+}
+
+func (s *streamSession) armOrphanTimerLocked() {
+	if s.done || s.orphanTimeout <= 0 || s.orphanTimer != nil {
+		return
+	}
+	s.orphanTimer = time.AfterFunc(s.orphanTimeout, func() {
+		s.mu.Lock()
+		orphaned := s.subscribers == 0 && !s.done
+		s.orphanTimer = nil
+		s.mu.Unlock()
+		if orphaned {
+			log.Printf("Session %s: no subscribers for %v, cancelling run", s.id, s.orphanTimeout)
+			s.cancel()
+		}
+	})
+}
+
+// publish appends an event and wakes every subscriber.
+func (s *streamSession) publish(name, data string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done {
+		return
+	}
+	s.nextID++
+	s.events = append(s.events, sseEvent{ID: s.nextID, Name: name, Data: data})
+	s.compactLocked()
+	s.broadcastLocked()
+}
+
+// finish publishes the terminal done event and marks the session complete.
+func (s *streamSession) finish() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done {
+		return
+	}
+	s.nextID++
+	// Note: htmx-ext-sse requires non-empty data to avoid swap errors
+	s.events = append(s.events, sseEvent{ID: s.nextID, Name: "done", Data: "complete"})
+	s.compactLocked()
+	s.done = true
+	s.expires = time.Now().Add(sessionTTL)
+	if s.orphanTimer != nil {
+		s.orphanTimer.Stop()
+		s.orphanTimer = nil
+	}
+	s.broadcastLocked()
+}
+
+// eventsAfter returns events newer than lastID, whether the session is done,
+// and a channel that is closed on the next publish.
+func (s *streamSession) eventsAfter(lastID uint64) ([]sseEvent, bool, <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []sseEvent
+	for _, ev := range s.events {
+		if ev.ID > lastID {
+			out = append(out, ev)
+		}
+	}
+	return out, s.done, s.notify
+}
+
+func (s *streamSession) broadcastLocked() {
+	close(s.notify)
+	s.notify = make(chan struct{})
+}
+
+// compactLocked keeps the buffer within maxSessionEvents. Every section event
+// replaces its target's innerHTML, so an older event with the same name as a
+// newer one is superseded and can be dropped without losing replay fidelity.
+func (s *streamSession) compactLocked() {
+	if len(s.events) <= maxSessionEvents {
+		return
+	}
+	latest := make(map[string]uint64, len(s.events))
+	for _, ev := range s.events {
+		latest[ev.Name] = ev.ID
+	}
+	kept := s.events[:0]
+	for _, ev := range s.events {
+		if latest[ev.Name] == ev.ID {
+			kept = append(kept, ev)
+		}
+	}
+	s.events = kept
+	if len(s.events) > maxSessionEvents {
+		s.events = s.events[len(s.events)-maxSessionEvents:]
+	}
+}
+
+func (s *streamSession) expired(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.done && now.After(s.expires)
+}
+
+// sessionManager owns every in-flight and recently finished session.
+type sessionManager struct {
+	mu            sync.Mutex
+	sessions      map[string]*streamSession
+	runTimeout    time.Duration
+	orphanTimeout time.Duration
+}
+
+var sessions = newSessionManager(5*time.Minute, time.Minute)
+
+func newSessionManager(runTimeout, orphanTimeout time.Duration) *sessionManager {
+	return &sessionManager{
+		sessions:      map[string]*streamSession{},
+		runTimeout:    runTimeout,
+		orphanTimeout: orphanTimeout,
+	}
+}
+
+// start creates the session, following up history if set, and runs
+// produce in the background with a context bounded only by the run timeout.
+// If the session already exists it is returned as-is and started is false.
+func (m *sessionManager) start(id, question string, history *prophetagent.Conversation, produce func(ctx context.Context, sess *streamSession)) (sess *streamSession, started bool) {
+	now := time.Now()
+	m.mu.Lock()
+	for key, s := range m.sessions {
+		if s.expired(now) {
+			delete(m.sessions, key)
+		}
+	}
+	if s, ok := m.sessions[id]; ok {
+		m.mu.Unlock()
+		return s, false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), m.runTimeout)
+	s := newStreamSession(id, question)
+	s.history = history
+	s.cancel = cancel
+	s.orphanTimeout = m.orphanTimeout
+	m.sessions[id] = s
+	m.mu.Unlock()
+
+	// Nobody is attached yet; the browser has orphanTimeout to connect.
+	s.mu.Lock()
+	s.armOrphanTimerLocked()
+	s.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		defer s.finish()
+		produce(ctx, s)
+	}()
+	return s, true
+}
+
+// get returns the session for id, or nil if it is unknown or expired.
+func (m *sessionManager) get(id string) *streamSession {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[id]
+	if !ok || s.expired(time.Now()) {
+		return nil
+	}
+	return s
+}
+
+// abandon cancels a session's run at the visitor's request.
+func (m *sessionManager) abandon(id string) bool {
+	s := m.get(id)
+	if s == nil {
+		return false
+	}
+	log.Printf("Session %s: abandoned", id)
+	s.cancel()
+	return true
+}
+
+// lastEventID reads the resume position from the Last-Event-ID header that
+// EventSource sends on reconnect, falling back to a last_event_id query
+// parameter for clients that recreate the EventSource from scratch.
+func lastEventID(r *http.Request) uint64 {
+	raw := strings.TrimSpace(r.Header.Get("Last-Event-ID"))
+	if raw == "" {
+		raw = r.URL.Query().Get("last_event_id")
+	}
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+// streamSessionEvents replays events after lastID and then follows the
+// session live until it is done or the client goes away.
+func streamSessionEvents(ctx context.Context, w http.ResponseWriter, flusher http.Flusher, sess *streamSession, lastID uint64) {
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		events, done, wait := sess.eventsAfter(lastID)
+		for _, ev := range events {
+			writeSSEEvent(w, ev)
+			lastID = ev.ID
+		}
+		flusher.Flush()
+		if done {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+			flusher.Flush()
+		case <-wait:
+		}
+	}
+}
+
+// writeSSEEvent writes a single event with its ID.
+func writeSSEEvent(w http.ResponseWriter, ev sseEvent) {
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Name, escapeSSEData(ev.Data))
+}
@@ -0,0 +1,279 @@
+// cmd/server/session_test.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestStreamSessionReplay verifies that a reconnect only receives events after Last-Event-ID
+func TestStreamSessionReplay(t *testing.T) {
+	sess := newStreamSession("test", "question")
+	sess.publish("presidents", "<p>one</p>")
+	sess.publish("leaders", "<p>two</p>")
+	sess.publish("scriptures", "<p>three</p>")
+	sess.finish()
+
+	w := httptest.NewRecorder()
+	streamSessionEvents(context.Background(), w, w, sess, 1)
+
+	body := w.Body.String()
+	if strings.Contains(body, "event: presidents") {
+		t.Errorf("Expected presidents event to be skipped, got %q", body)
+	}
+	for _, want := range []string{"id: 2\nevent: leaders", "id: 3\nevent: scriptures", "id: 4\nevent: done"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in replay, got %q", want, body)
+		}
+	}
+}
+
+// TestStreamSessionFollow verifies that a subscriber receives events published after it attached
+func TestStreamSessionFollow(t *testing.T) {
+	sess := newStreamSession("follow", "question")
+	w := httptest.NewRecorder()
+
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		streamSessionEvents(context.Background(), w, w, sess, 0)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	sess.publish("summary", "<p>late</p>")
+	sess.finish()
+
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("Subscriber did not finish after session completed")
+	}
+	if !strings.Contains(w.Body.String(), "event: summary") {
+		t.Errorf("Expected live summary event, got %q", w.Body.String())
+	}
+}
+
+// TestStreamSessionCompaction verifies superseded section events are dropped first
+func TestStreamSessionCompaction(t *testing.T) {
+	sess := newStreamSession("compact", "question")
+	sess.publish("presidents", "first")
+	for i := 0; i < maxSessionEvents+5; i++ {
+		sess.publish("leaders", fmt.Sprintf("leaders %d", i))
+	}
+
+	events, _, _ := sess.eventsAfter(0)
+	if len(events) > maxSessionEvents {
+		t.Fatalf("Expected at most %d events, got %d", maxSessionEvents, len(events))
+	}
+	if events[0].Name != "presidents" {
+		t.Errorf("Expected the only presidents event to survive compaction, got %q first", events[0].Name)
+	}
+	last := events[len(events)-1]
+	if last.Data != fmt.Sprintf("leaders %d", maxSessionEvents+4) {
+		t.Errorf("Expected latest leaders event to be kept, got %q", last.Data)
+	}
+}
+
+// TestSessionManagerAbandon verifies the run outlives subscribers until it is explicitly abandoned
+func TestSessionManagerAbandon(t *testing.T) {
+	manager := newSessionManager(time.Minute, time.Minute)
+	sess, started := manager.start("abandon", "question", nil, func(ctx context.Context, sess *streamSession) {
+		<-ctx.Done()
+		sess.publish("server-error", "cancelled")
+	})
+	if !started {
+		t.Fatal("Expected a new session to start")
+	}
+
+	sess.attach()
+	sess.detach()
+	if manager.get("abandon") == nil {
+		t.Fatal("Expected session to survive subscriber detach")
+	}
+
+	if !manager.abandon("abandon") {
+		t.Fatal("Expected abandon to find the session")
+	}
+
+	w := httptest.NewRecorder()
+	streamSessionEvents(context.Background(), w, w, sess, 0)
+	if !strings.Contains(w.Body.String(), "event: server-error") || !strings.Contains(w.Body.String(), "event: done") {
+		t.Errorf("Expected cancellation and done events, got %q", w.Body.String())
+	}
+}
+
+// TestSessionSigner verifies stream tokens are bound to the session, question and expiry
+func TestSessionSigner(t *testing.T) {
+	s := newSessionSigner([]byte("test-key"), time.Minute)
+	token := s.sign("s-abc", "What is faith?")
+
+	if err := s.verify("s-abc", "What is faith?", token); err != nil {
+		t.Fatalf("Expected valid token, got %v", err)
+	}
+	if err := s.verify("s-abc", "What is hope?", token); err != errTokenInvalid {
+		t.Errorf("Expected question mismatch to be rejected, got %v", err)
+	}
+	if err := s.verify("s-other", "What is faith?", token); err != errTokenInvalid {
+		t.Errorf("Expected session mismatch to be rejected, got %v", err)
+	}
+	if err := s.verify("s-abc", "What is faith?", ""); err != errTokenMissing {
+		t.Errorf("Expected unsigned stream to be rejected, got %v", err)
+	}
+
+	s.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	if err := s.verify("s-abc", "What is faith?", token); err != errTokenExpired {
+		t.Errorf("Expected expired token to be rejected, got %v", err)
+	}
+}
+
+// TestRateLimiter verifies the token bucket allows a burst, then refills over time
+func TestRateLimiter(t *testing.T) {
+	now := time.Now()
+	l := newRateLimiter(60, 2)
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.allow("1.2.3.4"); !ok {
+			t.Fatalf("Expected burst request %d to be allowed", i+1)
+		}
+	}
+	if ok, wait := l.allow("1.2.3.4"); ok || wait <= 0 {
+		t.Fatalf("Expected third request to be limited with a wait, got ok=%v wait=%v", ok, wait)
+	}
+	if ok, _ := l.allow("5.6.7.8"); !ok {
+		t.Error("Expected a different client to have its own bucket")
+	}
+
+	now = now.Add(time.Second)
+	if ok, _ := l.allow("1.2.3.4"); !ok {
+		t.Error("Expected a token to refill after one second")
+	}
+}
+
+// TestClientIPForwardingHeaders verifies forwarding headers are only read
+// from the workers (by secret) and trusted proxies
+func TestClientIPForwardingHeaders(t *testing.T) {
+	prev := trust
+	t.Cleanup(func() { trust = prev })
+	trust = newProxyTrust("worker-secret", "10.0.0.0/8, 192.168.1.1")
+
+	cases := []struct {
+		name    string
+		remote  string
+		headers map[string]string
+		want    string
+	}{
+		{"direct", "203.0.113.5:1234", nil, "203.0.113.5"},
+		{"spoofed CF header", "203.0.113.5:1234", map[string]string{"CF-Connecting-IP": "1.1.1.1"}, "203.0.113.5"},
+		{"spoofed XFF", "203.0.113.5:1234", map[string]string{"X-Forwarded-For": "1.1.1.1"}, "203.0.113.5"},
+		{"wrong secret", "203.0.113.5:1234", map[string]string{"CF-Connecting-IP": "1.1.1.1", "X-Proxy-Secret": "guess"}, "203.0.113.5"},
+		{"worker", "203.0.113.5:1234", map[string]string{"CF-Connecting-IP": "198.51.100.7", "X-Proxy-Secret": "worker-secret"}, "198.51.100.7"},
+		{"trusted proxy", "10.1.2.3:80", map[string]string{"X-Forwarded-For": "1.1.1.1, 198.51.100.7"}, "198.51.100.7"},
+		{"trusted proxy chain", "10.1.2.3:80", map[string]string{"X-Forwarded-For": "1.1.1.1, 198.51.100.7, 192.168.1.1"}, "198.51.100.7"},
+		{"trusted proxy without XFF", "10.1.2.3:80", nil, "10.1.2.3"},
+		{"internal stream proxy", "127.0.0.1:5555", map[string]string{"X-Forwarded-For": "1.1.1.1, 203.0.113.5"}, "203.0.113.5"},
+	}
+	for _, tc := range cases {
+		r := httptest.NewRequest("POST", "/ask", nil)
+		r.RemoteAddr = tc.remote
+		for k, v := range tc.headers {
+			r.Header.Set(k, v)
+		}
+		if got := clientIP(r); got != tc.want {
+			t.Errorf("%s: clientIP = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+// TestRateLimitIgnoresSpoofedHeaders verifies a client can't get a fresh
+// bucket by sending a different forwarding header on each request
+func TestRateLimitIgnoresSpoofedHeaders(t *testing.T) {
+	prevTrust, prevLimiter := trust, askLimiter
+	t.Cleanup(func() { trust, askLimiter = prevTrust, prevLimiter })
+	trust = newProxyTrust("worker-secret", "")
+	askLimiter = newRateLimiter(1, 1)
+
+	served := 0
+	handler := rateLimitMiddleware()(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { served++ }))
+	for i := 0; i < 3; i++ {
+		r := httptest.NewRequest("POST", "/ask", nil)
+		r.RemoteAddr = "203.0.113.5:1234"
+		r.Header.Set("CF-Connecting-IP", fmt.Sprintf("1.1.1.%d", i))
+		r.Header.Set("X-Forwarded-For", fmt.Sprintf("2.2.2.%d", i))
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	if served != 1 {
+		t.Errorf("Expected spoofed headers to share one bucket, served %d requests", served)
+	}
+}
+
+// TestRunQueue verifies runs beyond the cap wait in order and see their position
+func TestRunQueue(t *testing.T) {
+	q := newRunQueue(1, 1)
+	release, err := q.acquire(context.Background(), func(int) {})
+	if err != nil {
+		t.Fatalf("Expected first run to start, got %v", err)
+	}
+
+	positions := make(chan int, 4)
+	acquired := make(chan func(), 1)
+	go func() {
+		r, err := q.acquire(context.Background(), func(p int) { positions <- p })
+		if err != nil {
+			t.Errorf("Expected queued run to start, got %v", err)
+		}
+		acquired <- r
+	}()
+
+	if p := <-positions; p != 1 {
+		t.Errorf("Expected queue position 1, got %d", p)
+	}
+	if !q.full() {
+		t.Error("Expected queue to report full")
+	}
+	if _, err := q.acquire(context.Background(), func(int) {}); err != errRunQueueFull {
+		t.Errorf("Expected errRunQueueFull, got %v", err)
+	}
+
+	release()
+	select {
+	case r := <-acquired:
+		r()
+	case <-time.After(time.Second):
+		t.Fatal("Queued run did not start after release")
+	}
+	if q.running != 0 || len(q.waiters) != 0 {
+		t.Errorf("Expected idle queue, got running=%d waiters=%d", q.running, len(q.waiters))
+	}
+}
+
+// TestUnknownSessionCountsAsQuestion verifies a valid token for a session
+// this instance no longer has only runs the agent again within the /ask limit
+func TestUnknownSessionCountsAsQuestion(t *testing.T) {
+	prev := askLimiter
+	askLimiter = newRateLimiter(0, 1)
+	t.Cleanup(func() { askLimiter = prev })
+
+	sessionID, question := newSessionID(), "What is faith?"
+	r := httptest.NewRequest(http.MethodGet, "/api/stream?"+url.Values{
+		"session": {sessionID},
+		"q":       {question},
+		"token":   {signer.sign(sessionID, question)},
+	}.Encode(), nil)
+	askLimiter.allow(clientIP(r))
+
+	w := httptest.NewRecorder()
+	handleSSEStream(w, r, nil)
+	if body := w.Body.String(); !strings.Contains(body, "event: server-error") || !strings.Contains(body, "several questions") {
+		t.Errorf("Expected the ask limit notice, got %q", body)
+	}
+	if sessions.get(sessionID) != nil {
+		t.Error("Expected no session to start past the limit")
+	}
+}
@@ -0,0 +1,103 @@
+// cmd/server/sessiontoken.go
+// Random session IDs and HMAC-signed stream tokens bound to the question
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// streamTokenTTL bounds how long a rendered stream container may (re)connect.
+// It covers the run timeout plus the replay window for finished sessions.
+const streamTokenTTL = 20 * time.Minute
+
+var (
+	errTokenMissing   = errors.New("missing stream token")
+	errTokenMalformed = errors.New("malformed stream token")
+	errTokenExpired   = errors.New("stream token expired")
+	errTokenInvalid   = errors.New("invalid stream token signature")
+)
+
+// sessionSigner issues and verifies stream tokens of the form
+// "<expiry unix>.<base64url HMAC-SHA256(session|sha256(question)|expiry)>".
+type sessionSigner struct {
+	key []byte
+	ttl time.Duration
+	now func() time.Time
+}
+
+var signer = newSessionSigner(nil, streamTokenTTL)
+
+func newSessionSigner(key []byte, ttl time.Duration) *sessionSigner {
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			panic(fmt.Sprintf("failed to generate session signing key: %v", err))
+		}
+	}
+	return &sessionSigner{key: key, ttl: ttl, now: time.Now}
+}
+
+// initSessionSigner loads SESSION_SIGNING_KEY. Without it a random key is used,
+// which means tokens don't survive restarts or work across instances.
+func initSessionSigner() {
+	key := os.Getenv("SESSION_SIGNING_KEY")
+	if key == "" {
+		log.Println("WARNING: SESSION_SIGNING_KEY not set, using a random per-process key")
+	}
+	signer = newSessionSigner([]byte(key), getEnvDuration("STREAM_TOKEN_TTL", streamTokenTTL))
+}
+
+// newSessionID returns an unguessable session identifier.
+func newSessionID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("failed to generate session ID: %v", err))
+	}
+	return "s-" + hex.EncodeToString(b)
+}
+
+// sign issues a token binding the session to the question.
+func (s *sessionSigner) sign(sessionID, question string) string {
+	expiry := s.now().Add(s.ttl).Unix()
+	return strconv.FormatInt(expiry, 10) + "." + s.mac(sessionID, question, expiry)
+}
+
+// verify checks the token's signature and expiry for the session and question.
+func (s *sessionSigner) verify(sessionID, question, token string) error {
+	if token == "" {
+		return errTokenMissing
+	}
+	rawExpiry, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return errTokenMalformed
+	}
+	expiry, err := strconv.ParseInt(rawExpiry, 10, 64)
+	if err != nil {
+		return errTokenMalformed
+	}
+	if !hmac.Equal([]byte(sig), []byte(s.mac(sessionID, question, expiry))) {
+		return errTokenInvalid
+	}
+	if s.now().Unix() > expiry {
+		return errTokenExpired
+	}
+	return nil
+}
+
+func (s *sessionSigner) mac(sessionID, question string, expiry int64) string {
+	questionHash := sha256.Sum256([]byte(question))
+	m := hmac.New(sha256.New, s.key)
+	fmt.Fprintf(m, "%s|%x|%d", sessionID, questionHash, expiry)
+	return base64.RawURLEncoding.EncodeToString(m.Sum(nil))
+}
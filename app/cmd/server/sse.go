@@ -6,15 +6,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
-	"sync"
 	"time"
 
+	"github.com/a-h/templ"
+
 	prophetagent "github.com/temple-square/prophet-agent/internal/agent"
 	"github.com/temple-square/prophet-agent/internal/ui/components"
 )
@@ -26,6 +29,9 @@ type StructuredQuote struct {
 	Conference string `json:"conference"`
 	Quote      string `json:"quote"`
 	Headshot   string `json:"headshot,omitempty"`
+	TalkID     string `json:"talk_id,omitempty"`
+	Paragraph  string `json:"paragraph,omitempty"`
+	SourceURL  string `json:"source_url,omitempty"`
 }
 
 // StructuredScripture matches the JSON schema from the agent
@@ -34,13 +40,16 @@ type StructuredScripture struct {
 	Reference   string            `json:"reference"`
 	Text        string            `json:"text"`
 	RelatedTalk *RelatedTalkQuote `json:"related_talk,omitempty"`
+	VerseID     string            `json:"verse_id,omitempty"`
+	SourceURL   string            `json:"source_url,omitempty"`
 }
 
 // RelatedTalkQuote is a smaller quote from a talk
 type RelatedTalkQuote struct {
-	Speaker string `json:"speaker"`
-	Title   string `json:"title"`
-	Quote   string `json:"quote"`
+	Speaker   string `json:"speaker"`
+	Title     string `json:"title"`
+	Quote     string `json:"quote"`
+	SourceURL string `json:"source_url,omitempty"`
 }
 
 // PresidentsResponse is the structured output for presidents agent
@@ -63,40 +72,9 @@ type SummaryResponse struct {
 	Summary []string `json:"summary"`
 }
 
-const sessionTTL = 10 * time.Minute
-
-var (
-	sessionMu      sync.Mutex
-	sessionExpires = map[string]time.Time{}
-)
-
-func claimSession(sessionID string) bool {
-	if sessionID == "" {
-		return false
-	}
-	now := time.Now()
-	sessionMu.Lock()
-	defer sessionMu.Unlock()
-	if exp, ok := sessionExpires[sessionID]; ok {
-		if exp.After(now) {
-			return true
-		}
-		delete(sessionExpires, sessionID)
-	}
-	sessionExpires[sessionID] = now.Add(sessionTTL)
-	return false
-}
-
-func markSessionDone(sessionID string) {
-	if sessionID == "" {
-		return
-	}
-	sessionMu.Lock()
-	sessionExpires[sessionID] = time.Now().Add(sessionTTL)
-	sessionMu.Unlock()
-}
-
-// handleSSEStream handles SSE streaming for parallel agent architecture
+// handleSSEStream handles SSE streaming for parallel agent architecture.
+// The agent run is started by /ask; every connection (including reconnects
+// carrying Last-Event-ID) attaches to the session's event log.
 func handleSSEStream(w http.ResponseWriter, r *http.Request, agent *prophetagent.ProphetAgent) {
 	// Set SSE headers
 	w.Header().Set("Content-Type", "text/event-stream")
@@ -114,149 +92,477 @@ func handleSSEStream(w http.ResponseWriter, r *http.Request, agent *prophetagent
 	ctx := r.Context()
 	question := r.URL.Query().Get("q")
 	sessionID := r.URL.Query().Get("session")
+	token := r.URL.Query().Get("token")
 
 	if question == "" {
 		sendSSEError(w, flusher, "missing question")
+		sendSSEDone(w, flusher)
+		return
+	}
+	if sessionID == "" {
+		sendSSEError(w, flusher, "missing session")
+		sendSSEDone(w, flusher)
 		return
 	}
-	if claimSession(sessionID) {
-		log.Printf("SSE: Duplicate session, closing session=%s question=%q remote=%s", sessionID, question, r.RemoteAddr)
+	// Only streams minted by /ask may run the agent: the token binds the
+	// session ID to this exact question and expires.
+	if err := signer.verify(sessionID, question, token); err != nil {
+		log.Printf("SSE: Rejected session=%s remote=%s: %v", sessionID, r.RemoteAddr, err)
+		sendSSEError(w, flusher, "This answer link has expired. Please ask your question again.")
 		sendSSEDone(w, flusher)
 		return
 	}
-	defer markSessionDone(sessionID)
-	log.Printf("SSE: Open session=%s question=%q remote=%s", sessionID, question, r.RemoteAddr)
 
-	// Classify content (defense in depth)
-	classification := prophetagent.ClassifyContent(question)
-	if classification != prophetagent.ContentSafe {
-		redirect := prophetagent.GetRedirectResponse(classification)
-		var buf bytes.Buffer
-		if err := components.RedirectResponse(redirect.Message, redirect.SuggestedQuestions).Render(ctx, &buf); err != nil {
-			sendSSEError(w, flusher, "Error rendering response")
+	if ok, wait := streamLimiter.allow(clientIP(r)); !ok {
+		// EventSource retries on its own; Retry-After hints at the backoff.
+		log.Printf("SSE: Rate limited session=%s remote=%s", sessionID, clientIP(r))
+		w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+		http.Error(w, "too many requests", http.StatusTooManyRequests)
+		return
+	}
+
+	resumeFrom := lastEventID(r)
+	sess := sessions.get(sessionID)
+	if sess == nil {
+		// Signed by /ask but not running on this instance (e.g. after a
+		// restart, or once a finished session expired); a follow-up loses its
+		// conversation here. Running it again is a new question, so it
+		// counts against the same limits as /ask
+		if denied := admitRun(clientIP(r)); denied != nil {
+			log.Printf("SSE: Not restarting session=%s remote=%s (%s)", sessionID, clientIP(r), denied.reason)
+			sendSSEError(w, flusher, denied.message)
+			sendSSEDone(w, flusher)
 			return
 		}
-		fmt.Fprintf(w, "event: server-error\ndata: %s\n\n", escapeSSEData(buf.String()))
-		flusher.Flush()
-		sendSSEDone(w, flusher)
+		log.Printf("SSE: Starting unknown session=%s question=%q remote=%s", sessionID, question, r.RemoteAddr)
+		sess, _ = startAgentSession(sessionID, question, nil, agent)
+	}
+	log.Printf("SSE: Attach session=%s last_event_id=%d remote=%s", sessionID, resumeFrom, r.RemoteAddr)
+
+	sess.attach()
+	defer sess.detach()
+	streamSessionEvents(ctx, w, flusher, sess, resumeFrom)
+}
+
+// startAgentSession starts the agent for a question in the background,
+// as a follow-up to history if set.
+func startAgentSession(sessionID, question string, history *prophetagent.Conversation, agent *prophetagent.ProphetAgent) (*streamSession, bool) {
+	return sessions.start(sessionID, question, history, func(ctx context.Context, sess *streamSession) {
+		ctx = sessionContext(ctx, sess)
+		release, err := runs.acquire(ctx, func(position int) {
+			publishWaiting(ctx, sess, position)
+		})
+		if err != nil {
+			log.Printf("SSE: Session %s did not get a run slot: %v", sessionID, err)
+			publishError(sess, "We're answering a lot of questions at the moment. Please try again in a minute.")
+			return
+		}
+		defer release()
+		// Note: htmx-ext-sse requires non-empty data to avoid swap errors
+		sess.publish("waiting", "<div hidden></div>")
+
+		produceSession(ctx, sess, agent, question)
+	})
+}
+
+// sessionContext answers and renders the session in its question's language.
+func sessionContext(ctx context.Context, sess *streamSession) context.Context {
+	return components.WithLocale(prophetagent.WithLanguage(ctx, sess.lang), string(sess.lang))
+}
+
+// publishWaiting tells the visitor their position in the run queue.
+func publishWaiting(ctx context.Context, sess *streamSession, position int) {
+	var buf bytes.Buffer
+	if err := components.QueueWaiting(position).Render(ctx, &buf); err != nil {
+		log.Printf("SSE: Failed to render queue position: %v", err)
+		return
+	}
+	sess.publish("waiting", buf.String())
+}
+
+// produceSession runs the agent for a question and publishes every rendered
+// section into the session's event log. The session manager publishes the
+// terminal done event when it returns.
+func produceSession(ctx context.Context, sess *streamSession, agent *prophetagent.ProphetAgent, question string) {
+	askedAt := time.Now()
+
+	// Classify content (defense in depth)
+	classification, category := prophetagent.ClassifyContentCategory(question)
+	if classification != prophetagent.ContentSafe {
+		publishRedirect(ctx, sess, classification, category)
+		return
+	}
+
+	ctx = prophetagent.WithConversation(ctx, sess.history)
+	collected := collectAnswer(ctx, agent, question, newSessionEvents(ctx, sess))
+	if collected.blocked {
+		// The orchestrator's safety check caught what the classifier missed
+		publishRedirect(ctx, sess, prophetagent.ContentControversial, "")
 		return
 	}
+	publishTakeHome(sess, collected.snapshot(question, askedAt))
+	turn := collected.turn(question)
+	sess.recordTurn(turn)
+	publishFollowUp(sess)
+	publishRelatedQuestions(ctx, sess, agent, turn)
 
-	// Run parallel agents
-	log.Printf("SSE: Starting parallel agent execution for question: %s", question)
-	results := agent.Run(ctx, question)
+	log.Printf("SSE: Completed streaming for question: %s", question)
+}
 
-	var presidentsQuotes []StructuredQuote
-	var leadersQuotes []StructuredQuote
-	var bibleScriptures []StructuredScripture
-	var bomScriptures []StructuredScripture
-	var otherScriptures []StructuredScripture
+// publishRedirect replaces the answer with the redirect message and
+// suggested questions. The sections' loaders are cleared, not given
+// no-results cards: there is no answer to wait for.
+func publishRedirect(ctx context.Context, sess *streamSession, classification prophetagent.ContentClassification, category string) {
+	html, err := renderRedirect(ctx, classification, category, string(sess.lang))
+	if err != nil {
+		publishError(sess, "Error rendering response")
+		return
+	}
+	for _, section := range answerSections {
+		sess.publish(section, clearedSection)
+	}
+	sess.publish("server-error", string(html))
+}
+
+// clearedSection empties a section's SSE target; the page collapses it.
+// Note: htmx-ext-sse requires non-empty data to avoid swap errors
+const clearedSection = "<div data-cleared hidden></div>"
+
+// sessionEvents renders an answer's progress into a stream session.
+type sessionEvents struct {
+	ctx        context.Context
+	sess       *streamSession
+	sections   *sectionStates
+	lastUpdate time.Time
+}
+
+func newSessionEvents(ctx context.Context, sess *streamSession) *sessionEvents {
+	return &sessionEvents{ctx: ctx, sess: sess, sections: newSectionStates(sess)}
+}
+
+func (e *sessionEvents) sectionChanged(section string, c *answerCollector) {
+	var err error
+	switch section {
+	case sectionPresidents:
+		err = publishPresidentsSection(e.ctx, e.sess, c.presidents)
+	case sectionLeaders:
+		err = publishLeadersSection(e.ctx, e.sess, c.leaders)
+	case sectionScriptures:
+		err = publishScripturesSection(e.ctx, e.sess, c.bible, c.bom, c.other)
+	case sectionSummary:
+		err = publishSummarySection(e.ctx, e.sess, c.summary)
+	}
+	if err != nil {
+		log.Printf("SSE: Failed to render %s section: %v", section, err)
+		return
+	}
+	e.sections.published(section)
+}
+
+func (e *sessionEvents) sectionFailed(section string) { e.sections.fail(section) }
+
+func (e *sessionEvents) sectionFinished(section string) { e.sections.finish(section) }
+
+// summaryProgress streams the summary as it is written; the final
+// sectionChanged replaces it.
+func (e *sessionEvents) summaryProgress(paragraphs []string) {
+	if time.Since(e.lastUpdate) < summaryUpdateInterval {
+		return
+	}
+	e.lastUpdate = time.Now()
+	if err := publishSummaryProgress(e.ctx, e.sess, sanitizeSummary(paragraphs)); err != nil {
+		log.Printf("SSE: Failed to render summary progress: %v", err)
+	}
+}
+
+// answerEvents receives an answer's progress from collectAnswer. Every
+// section gets exactly one sectionFinished, after any sectionChanged and
+// sectionFailed calls for it.
+type answerEvents interface {
+	// sectionChanged reports that the section gained content; c holds all
+	// of it so far.
+	sectionChanged(section string, c *answerCollector)
+	sectionFailed(section string)
+	sectionFinished(section string)
+	summaryProgress(paragraphs []string)
+}
+
+// runAgents starts the agents for a question; tests replace it to script
+// the results.
+var runAgents = (*prophetagent.ProphetAgent).Run
+
+// answerSections lists the sections in the order they are shown.
+var answerSections = []string{sectionPresidents, sectionLeaders, sectionScriptures, sectionSummary}
+
+// collectAnswer runs the agents for a safe question, then the summary, and
+// reports their progress to ev. Everything, summary included, shares the
+// session budget.
+func collectAnswer(ctx context.Context, agent *prophetagent.ProphetAgent, question string, ev answerEvents) *answerCollector {
+	ctx, cancel := agent.WithSessionBudget(ctx)
+	defer cancel()
+
+	log.Printf("Answer: Starting parallel agent execution for question: %s", question)
+	collected := &answerCollector{}
 
 	// Process results as they come in
-	for result := range results {
+	for result := range runAgents(agent, ctx, question) {
+		if result.Done {
+			ev.sectionFinished(result.Section)
+			continue
+		}
+		if errors.Is(result.Error, context.DeadlineExceeded) {
+			// The section keeps what it has; an empty one gets a no-results card
+			log.Printf("Answer: Agent %s ran out of budget: %v", result.AgentName, result.Error)
+			continue
+		}
 		if result.Error != nil {
-			log.Printf("SSE: Agent %s error: %v", result.AgentName, result.Error)
-			sendSSEError(w, flusher, fmt.Sprintf("Agent %s failed: %v", result.AgentName, result.Error))
+			log.Printf("Answer: Agent %s error: %v", result.AgentName, result.Error)
+			switch {
+			case result.AgentName == "orchestrator":
+				collected.blocked = true
+			case result.Section == "":
+				// Not attributable to one section (e.g. tools failed to load)
+				for _, section := range answerSections {
+					ev.sectionFailed(section)
+				}
+			default:
+				ev.sectionFailed(result.Section)
+			}
 			continue
 		}
 
+		// Partial results are single cards streamed ahead of the agent's
+		// full response; merging de-duplicates them when it arrives.
 		if result.Content == "" {
-			log.Printf("SSE: Agent %s returned empty content", result.AgentName)
+			log.Printf("Answer: Agent %s returned empty content", result.AgentName)
 			continue
 		}
 
-		switch result.AgentName {
-		case "presidents_agent":
-			quotes, err := parseQuotesFromContent(result.Content)
-			if err != nil {
-				log.Printf("SSE: Failed to parse presidents result: %v", err)
-				sendSSEError(w, flusher, "Presidents section returned malformed JSON")
-				continue
-			}
-			presidentsQuotes = mergeUniqueQuotes(presidentsQuotes, quotes)
-			if len(presidentsQuotes) > 0 {
-				if err := sendPresidentsSection(ctx, w, flusher, presidentsQuotes); err != nil {
-					log.Printf("SSE: Failed to render presidents section: %v", err)
-				}
+		section, changed, err := collected.add(result.AgentName, result.Content)
+		switch {
+		case section == "":
+			log.Printf("DEBUG: Ignoring content from unknown agent: %s", result.AgentName)
+		case err != nil:
+			log.Printf("Answer: Failed to parse %s result: %v", result.AgentName, err)
+			if !result.Partial {
+				ev.sectionFailed(section)
 			}
+		case changed:
+			ev.sectionChanged(section, collected)
+		}
+	}
 
-		case "leaders_agent":
-			quotes, err := parseLeadersFromContent(result.Content)
-			if err != nil {
-				log.Printf("SSE: Failed to parse leaders result: %v", err)
-				sendSSEError(w, flusher, "Leaders section returned malformed JSON")
-				continue
-			}
-			leadersQuotes = mergeUniqueQuotes(leadersQuotes, quotes)
-			if len(leadersQuotes) > 0 {
-				if err := sendLeadersSection(ctx, w, flusher, leadersQuotes); err != nil {
-					log.Printf("SSE: Failed to render leaders section: %v", err)
-				}
-			}
+	if collected.blocked {
+		// No terminal cards: the caller answers with a redirect instead
+		log.Printf("Answer: Question blocked by orchestrator, skipping sections and summary")
+		return collected
+	}
 
-		case "scriptures_bible":
-			items, err := parseScripturesFromContent(result.Content)
-			if err != nil {
-				log.Printf("SSE: Failed to parse bible scriptures result: %v", err)
-				sendSSEError(w, flusher, "Scripture section returned malformed JSON")
-				continue
-			}
-			bibleScriptures = mergeUniqueScriptures(bibleScriptures, items)
-			if err := sendScripturesSection(ctx, w, flusher, bibleScriptures, bomScriptures, otherScriptures); err != nil {
-				log.Printf("SSE: Failed to render scriptures section: %v", err)
-			}
+	// Sections whose Done marker never arrived (run cancelled)
+	ev.sectionFinished(sectionPresidents)
+	ev.sectionFinished(sectionLeaders)
+	ev.sectionFinished(sectionScriptures)
 
-		case "scriptures_bom":
-			items, err := parseScripturesFromContent(result.Content)
-			if err != nil {
-				log.Printf("SSE: Failed to parse Book of Mormon result: %v", err)
-				sendSSEError(w, flusher, "Scripture section returned malformed JSON")
-				continue
-			}
-			bomScriptures = mergeUniqueScriptures(bomScriptures, items)
-			if err := sendScripturesSection(ctx, w, flusher, bibleScriptures, bomScriptures, otherScriptures); err != nil {
-				log.Printf("SSE: Failed to render scriptures section: %v", err)
-			}
+	// Final summary (2-3 paragraphs)
+	summary, err := collected.summarize(ctx, agent, question, ev.summaryProgress)
+	if err != nil {
+		log.Printf("Answer: Summary failed: %v", err)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			ev.sectionFailed(sectionSummary)
+		}
+	} else if len(summary) > 0 {
+		collected.summary = summary
+		ev.sectionChanged(sectionSummary, collected)
+	}
+	ev.sectionFinished(sectionSummary)
+	return collected
+}
 
-		case "scriptures_other":
-			items, err := parseScripturesFromContent(result.Content)
-			if err != nil {
-				log.Printf("SSE: Failed to parse other scriptures result: %v", err)
-				sendSSEError(w, flusher, "Scripture section returned malformed JSON")
-				continue
-			}
-			otherScriptures = mergeUniqueScriptures(otherScriptures, items)
-			if err := sendScripturesSection(ctx, w, flusher, bibleScriptures, bomScriptures, otherScriptures); err != nil {
-				log.Printf("SSE: Failed to render scriptures section: %v", err)
-			}
+// answerCollector merges the agents' results into an answer's sections.
+type answerCollector struct {
+	presidents []StructuredQuote
+	leaders    []StructuredQuote
+	bible      []StructuredScripture
+	bom        []StructuredScripture
+	other      []StructuredScripture
+	summary    []string
+	blocked    bool // the orchestrator refused the question
+}
+
+// add parses an agent's content and merges it into its section. It returns
+// the section ("" for an unknown agent) and whether the section gained
+// anything.
+func (c *answerCollector) add(agentName, content string) (string, bool, error) {
+	switch agentName {
+	case "presidents_agent":
+		quotes, err := parseQuotesFromContent(content)
+		if err != nil {
+			return sectionPresidents, false, err
+		}
+		before := len(c.presidents)
+		c.presidents = mergeUniqueQuotes(c.presidents, quotes)
+		return sectionPresidents, len(c.presidents) > before, nil
 
+	case "leaders_agent":
+		quotes, err := parseLeadersFromContent(content)
+		if err != nil {
+			return sectionLeaders, false, err
+		}
+		before := len(c.leaders)
+		c.leaders = mergeUniqueQuotes(c.leaders, quotes)
+		return sectionLeaders, len(c.leaders) > before, nil
+
+	case "scriptures_bible", "scriptures_bom", "scriptures_other":
+		items, err := parseScripturesFromContent(content)
+		if err != nil {
+			return sectionScriptures, false, err
+		}
+		before := len(c.bible) + len(c.bom) + len(c.other)
+		switch agentName {
+		case "scriptures_bible":
+			c.bible = mergeUniqueScriptures(c.bible, items)
+		case "scriptures_bom":
+			c.bom = mergeUniqueScriptures(c.bom, items)
 		default:
-			log.Printf("DEBUG: Ignoring content from unknown agent: %s", result.AgentName)
+			c.other = mergeUniqueScriptures(c.other, items)
 		}
+		return sectionScriptures, len(c.bible)+len(c.bom)+len(c.other) > before, nil
 	}
+	return "", false, nil
+}
 
-	// Final summary (2-3 paragraphs)
-	allScriptures := append(append([]StructuredScripture{}, bibleScriptures...), bomScriptures...)
-	allScriptures = append(allScriptures, otherScriptures...)
-	summaryContent, err := agent.GenerateSummary(ctx, question,
-		toAgentQuotes(presidentsQuotes),
-		toAgentQuotes(leadersQuotes),
-		toAgentScriptures(allScriptures))
+// summarize writes the summary of the collected sections. onProgress
+// receives the paragraphs written so far.
+func (c *answerCollector) summarize(ctx context.Context, agent *prophetagent.ProphetAgent, question string, onProgress func([]string)) ([]string, error) {
+	allScriptures := append(append([]StructuredScripture{}, c.bible...), c.bom...)
+	allScriptures = append(allScriptures, c.other...)
+	content, err := agent.GenerateSummary(ctx, question,
+		toAgentQuotes(c.presidents),
+		toAgentQuotes(c.leaders),
+		toAgentScriptures(allScriptures),
+		onProgress)
+	if err != nil || content == "" {
+		return nil, err
+	}
+	summary, err := parseSummaryFromContent(content)
 	if err != nil {
-		log.Printf("SSE: Summary generation failed: %v", err)
-	} else if summaryContent != "" {
-		paras, err := parseSummaryFromContent(summaryContent)
-		if err != nil {
-			log.Printf("SSE: Failed to parse summary: %v", err)
-		} else if len(paras) > 0 {
-			if err := sendSummarySection(ctx, w, flusher, paras); err != nil {
-				log.Printf("SSE: Failed to render summary section: %v", err)
-			}
-		}
+		return nil, fmt.Errorf("failed to parse summary: %w", err)
 	}
+	return summary, nil
+}
 
-	sendSSEDone(w, flusher)
-	log.Printf("SSE: Completed streaming for question: %s", question)
+// snapshot is the collected answer as saved for take-home links.
+func (c *answerCollector) snapshot(question string, askedAt time.Time) *answerSnapshot {
+	return &answerSnapshot{
+		Question:   question,
+		AskedAt:    askedAt,
+		Presidents: c.presidents,
+		Leaders:    c.leaders,
+		Bible:      c.bible,
+		BOM:        c.bom,
+		Other:      c.other,
+		Summary:    c.summary,
+	}
+}
+
+// turn is what the collected answer showed, for follow-up questions.
+func (c *answerCollector) turn(question string) prophetagent.Turn {
+	scriptures := append(append(append([]StructuredScripture{}, c.bible...), c.bom...), c.other...)
+	return prophetagent.Turn{
+		Question:   question,
+		Quotes:     toAgentQuotes(append(append([]StructuredQuote{}, c.presidents...), c.leaders...)),
+		Scriptures: toAgentScriptures(scriptures),
+	}
+}
+
+// SSE event names of the answer sections; the first three match the agent's
+// section names.
+const (
+	sectionPresidents = prophetagent.SectionPresidents
+	sectionLeaders    = prophetagent.SectionLeaders
+	sectionScriptures = prophetagent.SectionScriptures
+	sectionSummary    = "summary"
+)
+
+// sectionCopy is the heading and fallback text for a section's terminal cards.
+type sectionCopy struct {
+	title   string
+	empty   string
+	failure string
+}
+
+var sectionCopies = map[string]sectionCopy{
+	sectionPresidents: {
+		title:   "Recent Remarks From Church Presidents",
+		empty:   "We couldn't find remarks from Church Presidents on this topic in time. Try rephrasing your question.",
+		failure: "We couldn't load remarks from Church Presidents right now. The rest of your answer is below.",
+	},
+	sectionLeaders: {
+		title:   "Recent Remarks From Other Church Leaders",
+		empty:   "We couldn't find remarks from other Church leaders on this topic in time.",
+		failure: "We couldn't load remarks from other Church leaders right now.",
+	},
+	sectionScriptures: {
+		title:   "Related Scriptures",
+		empty:   "We couldn't find related scriptures in time.",
+		failure: "We couldn't load related scriptures right now.",
+	},
+	sectionSummary: {
+		title:   "Summary",
+		empty:   "A summary isn't available for this question right now.",
+		failure: "We couldn't put together a summary right now. The quotes above still answer your question.",
+	},
+}
+
+// sectionStates guarantees every section ends in a terminal state: its
+// content, a no-results card, or a SectionError card.
+type sectionStates struct {
+	sess     *streamSession
+	content  map[string]bool
+	failed   map[string]bool
+	terminal map[string]bool
+}
+
+func newSectionStates(sess *streamSession) *sectionStates {
+	return &sectionStates{
+		sess:     sess,
+		content:  map[string]bool{},
+		failed:   map[string]bool{},
+		terminal: map[string]bool{},
+	}
+}
+
+func (s *sectionStates) published(section string) { s.content[section] = true }
+
+func (s *sectionStates) fail(section string) { s.failed[section] = true }
+
+// finish replaces the section's skeleton loader unless content was already
+// published. Repeated calls are no-ops.
+func (s *sectionStates) finish(section string) {
+	if s.terminal[section] {
+		return
+	}
+	s.terminal[section] = true
+	if s.content[section] {
+		return
+	}
+	text := sectionCopies[section]
+	if s.failed[section] {
+		publishSectionCard(s.sess, section, components.SectionError(text.title, text.failure))
+		return
+	}
+	publishSectionCard(s.sess, section, components.NoResults(text.title, text.empty))
+}
+
+// publishSectionCard renders a terminal card into a section's SSE target.
+func publishSectionCard(sess *streamSession, section string, card templ.Component) {
+	// Rendered outside the session context, which may be the budget that just expired
+	var buf bytes.Buffer
+	if err := card.Render(sessionContext(context.Background(), sess), &buf); err != nil {
+		log.Printf("SSE: Failed to render %s card: %v", section, err)
+		return
+	}
+	sess.publish(section, buf.String())
 }
 
 func parseQuotesFromContent(content string) ([]StructuredQuote, error) {
@@ -308,50 +614,42 @@ func parseSummaryFromContent(content string) ([]string, error) {
 	if err := json.Unmarshal([]byte(jsonContent), &resp); err != nil {
 		return nil, err
 	}
-	return resp.Summary, nil
+	return sanitizeSummary(resp.Summary), nil
 }
 
 func mergeUniqueQuotes(existing, incoming []StructuredQuote) []StructuredQuote {
 	seen := make(map[string]struct{}, len(existing))
-	for _, q := range existing {
-		seen[quoteKey(q)] = struct{}{}
+	for _, q := range toAgentQuotes(existing) {
+		seen[prophetagent.QuoteKey(q)] = struct{}{}
 	}
-	for _, q := range incoming {
-		key := quoteKey(q)
+	for i, q := range toAgentQuotes(incoming) {
+		key := prophetagent.QuoteKey(q)
 		if _, ok := seen[key]; ok {
 			continue
 		}
 		seen[key] = struct{}{}
-		existing = append(existing, q)
+		existing = append(existing, incoming[i])
 	}
 	return existing
 }
 
 func mergeUniqueScriptures(existing, incoming []StructuredScripture) []StructuredScripture {
 	seen := make(map[string]struct{}, len(existing))
-	for _, s := range existing {
-		seen[scriptureKey(s)] = struct{}{}
+	for _, s := range toAgentScriptures(existing) {
+		seen[prophetagent.ScriptureKey(s)] = struct{}{}
 	}
-	for _, s := range incoming {
-		key := scriptureKey(s)
+	for i, s := range toAgentScriptures(incoming) {
+		key := prophetagent.ScriptureKey(s)
 		if _, ok := seen[key]; ok {
 			continue
 		}
 		seen[key] = struct{}{}
-		existing = append(existing, s)
+		existing = append(existing, incoming[i])
 	}
 	return existing
 }
 
-func quoteKey(q StructuredQuote) string {
-	return fmt.Sprintf("%s|%s|%s|%s", q.Speaker, q.Title, q.Conference, q.Quote)
-}
-
-func scriptureKey(s StructuredScripture) string {
-	return fmt.Sprintf("%s|%s|%s", s.Volume, s.Reference, s.Text)
-}
-
-func sendPresidentsSection(ctx context.Context, w http.ResponseWriter, flusher http.Flusher, quotes []StructuredQuote) error {
+func publishPresidentsSection(ctx context.Context, sess *streamSession, quotes []StructuredQuote) error {
 	orderedQuotes := append([]StructuredQuote(nil), quotes...)
 	sortPresidentsQuotes(orderedQuotes)
 	speakers := convertQuotesToSpeakers(orderedQuotes)
@@ -359,44 +657,35 @@ func sendPresidentsSection(ctx context.Context, w http.ResponseWriter, flusher h
 	if err := components.PresidentsSection(speakers).Render(ctx, &buf); err != nil {
 		return err
 	}
-	fmt.Fprintf(w, "event: presidents\ndata: %s\n\n", escapeSSEData(buf.String()))
-	flusher.Flush()
+	sess.publish("presidents", buf.String())
 	return nil
 }
 
+// sortPresidentsQuotes puts the President of the Church first.
 func sortPresidentsQuotes(quotes []StructuredQuote) {
 	sort.SliceStable(quotes, func(i, j int) bool {
-		return oaksPriority(quotes[i]) < oaksPriority(quotes[j])
+		return presidentPriority(quotes[i]) < presidentPriority(quotes[j])
 	})
 }
 
-func oaksPriority(q StructuredQuote) int {
-	if isOaksSpeaker(q.Speaker) {
+func presidentPriority(q StructuredQuote) int {
+	if sp, ok := speakerRegistry.Lookup(q.Speaker); ok && sp.IsPresident() {
 		return 0
 	}
 	return 1
 }
 
-func isOaksSpeaker(name string) bool {
-	name = strings.ToLower(strings.TrimSpace(name))
-	if name == "" {
-		return false
-	}
-	return strings.Contains(name, "oaks")
-}
-
-func sendLeadersSection(ctx context.Context, w http.ResponseWriter, flusher http.Flusher, quotes []StructuredQuote) error {
+func publishLeadersSection(ctx context.Context, sess *streamSession, quotes []StructuredQuote) error {
 	speakers := convertQuotesToSpeakers(quotes)
 	var buf bytes.Buffer
 	if err := components.LeadersSection(speakers).Render(ctx, &buf); err != nil {
 		return err
 	}
-	fmt.Fprintf(w, "event: leaders\ndata: %s\n\n", escapeSSEData(buf.String()))
-	flusher.Flush()
+	sess.publish("leaders", buf.String())
 	return nil
 }
 
-func sendScripturesSection(ctx context.Context, w http.ResponseWriter, flusher http.Flusher, bible []StructuredScripture, bom []StructuredScripture, other []StructuredScripture) error {
+func publishScripturesSection(ctx context.Context, sess *streamSession, bible []StructuredScripture, bom []StructuredScripture, other []StructuredScripture) error {
 	bibleCards := convertStructuredScriptures(bible)
 	bomCards := convertStructuredScriptures(bom)
 	otherCards := convertStructuredScriptures(other)
@@ -404,21 +693,72 @@ func sendScripturesSection(ctx context.Context, w http.ResponseWriter, flusher h
 	if err := components.ScripturesSection(bibleCards, bomCards, otherCards).Render(ctx, &buf); err != nil {
 		return err
 	}
-	fmt.Fprintf(w, "event: scriptures\ndata: %s\n\n", escapeSSEData(buf.String()))
-	flusher.Flush()
+	sess.publish("scriptures", buf.String())
 	return nil
 }
 
-func sendSummarySection(ctx context.Context, w http.ResponseWriter, flusher http.Flusher, paragraphs []string) error {
+func publishSummarySection(ctx context.Context, sess *streamSession, paragraphs []string) error {
 	var buf bytes.Buffer
 	if err := components.SummarySection(paragraphs).Render(ctx, &buf); err != nil {
 		return err
 	}
-	fmt.Fprintf(w, "event: summary\ndata: %s\n\n", escapeSSEData(buf.String()))
-	flusher.Flush()
+	sess.publish("summary", buf.String())
 	return nil
 }
 
+// publishRelatedQuestions ends the answer with questions to explore next,
+// grounded in what it showed. They are optional: on failure nothing is shown.
+func publishRelatedQuestions(ctx context.Context, sess *streamSession, agent *prophetagent.ProphetAgent, turn prophetagent.Turn) {
+	questions, err := agent.RelatedQuestions(ctx, turn.Question, turn.Quotes, turn.Scriptures)
+	if err != nil {
+		log.Printf("SSE: Related questions failed: %v", err)
+		return
+	}
+	if len(questions) == 0 {
+		return
+	}
+	chips := make([]components.SuggestedQuestion, len(questions))
+	for i, q := range questions {
+		chips[i] = components.SuggestedQuestion{Text: q}
+	}
+	var buf bytes.Buffer
+	if err := components.RelatedQuestions(chips, followUpProps(sess)).Render(ctx, &buf); err != nil {
+		log.Printf("SSE: Failed to render related questions: %v", err)
+		return
+	}
+	sess.publish("related", buf.String())
+}
+
+// summaryUpdateInterval spaces out streamed summary updates. Each update
+// re-renders the whole section, so per-token events would flood the session.
+const summaryUpdateInterval = 100 * time.Millisecond
+
+// publishSummaryProgress publishes the summary written so far. Progress
+// doesn't count as section content: if generation fails, the terminal card
+// replaces the partial text.
+func publishSummaryProgress(ctx context.Context, sess *streamSession, paragraphs []string) error {
+	if len(paragraphs) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := components.SummaryStreaming(paragraphs).Render(ctx, &buf); err != nil {
+		return err
+	}
+	sess.publish("summary", buf.String())
+	return nil
+}
+
+// sanitizeSummary trims the paragraphs and drops empty ones.
+func sanitizeSummary(paragraphs []string) []string {
+	cleaned := make([]string, 0, len(paragraphs))
+	for _, p := range paragraphs {
+		if p = strings.TrimSpace(p); p != "" {
+			cleaned = append(cleaned, p)
+		}
+	}
+	return cleaned
+}
+
 // extractFirstJSON finds and extracts the first complete JSON object from a string.
 // This is needed because the agent may produce multiple JSON objects concatenated together
 // (e.g., from multiple tool calls or turns).
@@ -469,108 +809,6 @@ func extractFirstJSON(content string) (string, error) {
 	return "", fmt.Errorf("incomplete JSON object")
 }
 
-// tryParseAndSendSection attempts to parse JSON and send the rendered section.
-// Returns (sent bool, err error) - fail-fast on malformed JSON.
-func tryParseAndSendSection(ctx context.Context, w http.ResponseWriter, flusher http.Flusher, agentName, content string) (bool, error) {
-	var buf bytes.Buffer
-	var eventName string
-
-	// Extract the first complete JSON object from the content
-	// This handles cases where multiple JSON objects are concatenated
-	jsonContent, err := extractFirstJSON(content)
-	if err != nil {
-		// Log but don't error - might still be accumulating
-		log.Printf("DEBUG: Cannot extract JSON from %s: %v", agentName, err)
-		return false, nil
-	}
-	content = jsonContent
-
-	// Truncate content for logging (max 200 chars)
-	contentSnippet := content
-	if len(contentSnippet) > 200 {
-		contentSnippet = contentSnippet[:200] + "..."
-	}
-
-	switch agentName {
-	case "presidents_agent":
-		eventName = "presidents"
-		var resp PresidentsResponse
-		if err := json.Unmarshal([]byte(content), &resp); err != nil {
-			errMsg := fmt.Sprintf("JSON parse error from %s: %v", agentName, err)
-			log.Printf("ERROR: %s | Content: %s", errMsg, contentSnippet)
-			sendSSEError(w, flusher, fmt.Sprintf("Agent %s returned malformed JSON - this is an agent configuration error", agentName))
-			return false, fmt.Errorf("%s", errMsg)
-		}
-		if len(resp.Quotes) == 0 {
-			log.Printf("WARN: %s returned empty quotes array | Content: %s", agentName, contentSnippet)
-			return false, nil // Empty is valid, just nothing to render
-		}
-		speakers := convertQuotesToSpeakers(resp.Quotes)
-		if err := components.PresidentsSection(speakers).Render(ctx, &buf); err != nil {
-			errMsg := fmt.Sprintf("Render error for %s: %v", agentName, err)
-			log.Printf("ERROR: %s", errMsg)
-			sendSSEError(w, flusher, fmt.Sprintf("Failed to render %s section", eventName))
-			return false, fmt.Errorf("%s", errMsg)
-		}
-
-	case "leaders_agent":
-		eventName = "leaders"
-		var resp LeadersResponse
-		if err := json.Unmarshal([]byte(content), &resp); err != nil {
-			errMsg := fmt.Sprintf("JSON parse error from %s: %v", agentName, err)
-			log.Printf("ERROR: %s | Content: %s", errMsg, contentSnippet)
-			sendSSEError(w, flusher, fmt.Sprintf("Agent %s returned malformed JSON - this is an agent configuration error", agentName))
-			return false, fmt.Errorf("%s", errMsg)
-		}
-		if len(resp.Quotes) == 0 {
-			log.Printf("WARN: %s returned empty quotes array | Content: %s", agentName, contentSnippet)
-			return false, nil // Empty is valid, just nothing to render
-		}
-		speakers := convertQuotesToSpeakers(resp.Quotes)
-		if err := components.LeadersSection(speakers).Render(ctx, &buf); err != nil {
-			errMsg := fmt.Sprintf("Render error for %s: %v", agentName, err)
-			log.Printf("ERROR: %s", errMsg)
-			sendSSEError(w, flusher, fmt.Sprintf("Failed to render %s section", eventName))
-			return false, fmt.Errorf("%s", errMsg)
-		}
-
-	case "scriptures_agent":
-		eventName = "scriptures"
-		var resp ScripturesResponse
-		if err := json.Unmarshal([]byte(content), &resp); err != nil {
-			errMsg := fmt.Sprintf("JSON parse error from %s: %v", agentName, err)
-			log.Printf("ERROR: %s | Content: %s", errMsg, contentSnippet)
-			sendSSEError(w, flusher, fmt.Sprintf("Agent %s returned malformed JSON - this is an agent configuration error", agentName))
-			return false, fmt.Errorf("%s", errMsg)
-		}
-		if len(resp.Scriptures) == 0 {
-			log.Printf("WARN: %s returned empty scriptures array | Content: %s", agentName, contentSnippet)
-			return false, nil // Empty is valid, just nothing to render
-		}
-		scriptures := convertStructuredScriptures(resp.Scriptures)
-		if err := components.ScripturesSection(scriptures, nil, nil).Render(ctx, &buf); err != nil {
-			errMsg := fmt.Sprintf("Render error for %s: %v", agentName, err)
-			log.Printf("ERROR: %s", errMsg)
-			sendSSEError(w, flusher, fmt.Sprintf("Failed to render %s section", eventName))
-			return false, fmt.Errorf("%s", errMsg)
-		}
-
-	default:
-		// Unknown agent - log but don't error (might be orchestrator or other internal agent)
-		log.Printf("DEBUG: Ignoring content from unknown agent: %s", agentName)
-		return false, nil
-	}
-
-	if buf.Len() > 0 {
-		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", eventName, escapeSSEData(buf.String()))
-		flusher.Flush()
-		log.Printf("Streamed %s section (%d bytes)", eventName, buf.Len())
-		return true, nil
-	}
-
-	return false, nil
-}
-
 // convertQuotesToSpeakers converts structured quotes to component speakers
 func convertQuotesToSpeakers(quotes []StructuredQuote) []components.SpeakerQuote {
 	speakers := make([]components.SpeakerQuote, len(quotes))
@@ -581,17 +819,38 @@ func convertQuotesToSpeakers(quotes []StructuredQuote) []components.SpeakerQuote
 		if headshot == "" && isValidHeadshotURL(q.Headshot, allowedPrefix) {
 			headshot = q.Headshot
 		}
+		name, calling := q.Speaker, ""
+		if sp, ok := speakerRegistry.Lookup(q.Speaker); ok {
+			name, calling = sp.DisplayName(), sp.Calling
+			// Show the calling the speaker held when they gave the talk
+			if talkDate, ok := prophetagent.ConferenceDate(q.Conference); ok {
+				then, _ := speakerRegistry.LookupAt(q.Speaker, talkDate)
+				calling = then.Calling
+			}
+		}
 		speakers[i] = components.SpeakerQuote{
-			Name:       q.Speaker,
+			Name:       name,
+			Calling:    calling,
 			TalkTitle:  q.Title,
 			Conference: q.Conference,
 			Quotes:     []string{q.Quote},
 			Headshot:   headshot,
+			TalkID:     q.TalkID,
+			SourceURL:  sourceURL(q.SourceURL),
 		}
 	}
 	return speakers
 }
 
+// sourceURL passes through only links to the Church's site, which is where
+// the agent builds them; anything else renders no link.
+func sourceURL(url string) string {
+	if strings.HasPrefix(url, "https://www.churchofjesuschrist.org/") && !strings.ContainsAny(url, " \t\r\n\"'<>") {
+		return url
+	}
+	return ""
+}
+
 func isValidHeadshotURL(url string, allowedPrefix string) bool {
 	if url == "" {
 		return false
@@ -742,13 +1001,15 @@ func convertStructuredScriptures(scriptures []StructuredScripture) []components.
 				Volume:    s.Volume,
 				Reference: s.Reference,
 				Text:      s.Text,
+				SourceURL: sourceURL(s.SourceURL),
 			},
 		}
 		if s.RelatedTalk != nil {
 			result[i].RelatedTalk = &components.TalkPullQuote{
-				Speaker: s.RelatedTalk.Speaker,
-				Title:   s.RelatedTalk.Title,
-				Quote:   s.RelatedTalk.Quote,
+				Speaker:   s.RelatedTalk.Speaker,
+				Title:     s.RelatedTalk.Title,
+				Quote:     s.RelatedTalk.Quote,
+				SourceURL: sourceURL(s.RelatedTalk.SourceURL),
 			}
 		}
 	}
@@ -764,6 +1025,9 @@ func toAgentQuotes(quotes []StructuredQuote) []prophetagent.StructuredQuote {
 			Conference: q.Conference,
 			Quote:      q.Quote,
 			Headshot:   q.Headshot,
+			TalkID:     q.TalkID,
+			Paragraph:  q.Paragraph,
+			SourceURL:  q.SourceURL,
 		}
 	}
 	return out
@@ -775,9 +1039,10 @@ func toAgentScriptures(items []StructuredScripture) []prophetagent.StructuredScr
 		var related *prophetagent.RelatedTalkQuote
 		if s.RelatedTalk != nil {
 			related = &prophetagent.RelatedTalkQuote{
-				Speaker: s.RelatedTalk.Speaker,
-				Title:   s.RelatedTalk.Title,
-				Quote:   s.RelatedTalk.Quote,
+				Speaker:   s.RelatedTalk.Speaker,
+				Title:     s.RelatedTalk.Title,
+				Quote:     s.RelatedTalk.Quote,
+				SourceURL: s.RelatedTalk.SourceURL,
 			}
 		}
 		out[i] = prophetagent.StructuredScripture{
@@ -785,14 +1050,26 @@ func toAgentScriptures(items []StructuredScripture) []prophetagent.StructuredScr
 			Reference:   s.Reference,
 			Text:        s.Text,
 			RelatedTalk: related,
+			VerseID:     s.VerseID,
+			SourceURL:   s.SourceURL,
 		}
 	}
 	return out
 }
 
+// errorHTML renders the inline error fragment used for server-error events.
+func errorHTML(message string) string {
+	return fmt.Sprintf("<div class=\"text-red-600\">Error: %s</div>", message)
+}
+
+// publishError publishes an error event into the session, in its language.
+func publishError(sess *streamSession, message string) {
+	sess.publish("server-error", errorHTML(components.Translate(string(sess.lang), message)))
+}
+
 // sendSSEError sends an error event
 func sendSSEError(w http.ResponseWriter, flusher http.Flusher, message string) {
-	fmt.Fprintf(w, "event: server-error\ndata: <div class=\"text-red-600\">Error: %s</div>\n\n", escapeSSEData(message))
+	fmt.Fprintf(w, "event: server-error\ndata: %s\n\n", escapeSSEData(errorHTML(message)))
 	flusher.Flush()
 }
 
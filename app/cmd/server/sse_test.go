@@ -3,6 +3,8 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -10,6 +12,9 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	prophetagent "github.com/temple-square/prophet-agent/internal/agent"
+	"github.com/temple-square/prophet-agent/internal/ui/components"
 )
 
 // TestSSEProxyStreaming verifies that the SSE proxy correctly streams events
@@ -127,3 +132,146 @@ func TestFlushingResponseWriter(t *testing.T) {
 	// Test Flush()
 	frw.Flush() // Should not panic
 }
+
+func TestAnswerCollectorMergesSections(t *testing.T) {
+	c := &answerCollector{}
+	quote := `{"quotes":[{"speaker":"Russell M. Nelson","title":"T","conference":"October 2024","quote":"Q"}]}`
+	if section, changed, err := c.add("presidents_agent", quote); section != sectionPresidents || !changed || err != nil {
+		t.Fatalf("first add = %q, %v, %v", section, changed, err)
+	}
+	// The full response repeats the partial card
+	if _, changed, _ := c.add("presidents_agent", quote); changed {
+		t.Error("duplicate quote reported as a change")
+	}
+	if section, _, err := c.add("leaders_agent", "not json"); section != sectionLeaders || err == nil {
+		t.Errorf("bad leaders content = %q, %v", section, err)
+	}
+
+	verse := `{"scriptures":[{"volume":"Book of Mormon","reference":"Alma 32:21","text":"faith"}]}`
+	for _, agentName := range []string{"scriptures_bom", "scriptures_bible"} {
+		if section, changed, err := c.add(agentName, verse); section != sectionScriptures || !changed || err != nil {
+			t.Errorf("add %s = %q, %v, %v", agentName, section, changed, err)
+		}
+	}
+	if _, changed, _ := c.add("scriptures_bom", verse); changed {
+		t.Error("duplicate verse reported as a change")
+	}
+	if section, changed, err := c.add("mystery_agent", quote); section != "" || changed || err != nil {
+		t.Errorf("unknown agent = %q, %v, %v", section, changed, err)
+	}
+
+	snap := c.snapshot("What is faith?", time.Time{})
+	if len(snap.Presidents) != 1 || len(snap.Leaders) != 0 || len(snap.BOM) != 1 || len(snap.Bible) != 1 || len(snap.Other) != 0 {
+		t.Errorf("snapshot = %+v", snap)
+	}
+}
+
+// TestSectionCardsInQuestionLanguage verifies that a session's terminal
+// cards and errors are shown in the language of its question
+func TestSectionCardsInQuestionLanguage(t *testing.T) {
+	sess := newStreamSession("es", "¿Cómo puedo sentir paz?")
+	states := newSectionStates(sess)
+	states.finish(sectionScriptures)
+	states.fail(sectionSummary)
+	states.finish(sectionSummary)
+	publishError(sess, "Error rendering response")
+
+	sess.mu.Lock()
+	events := append([]sseEvent(nil), sess.events...)
+	sess.mu.Unlock()
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(events))
+	}
+	for i, want := range []string{
+		"No encontramos a tiempo pasajes de las Escrituras relacionados.",
+		"Resumen",
+		"Error al mostrar la respuesta",
+	} {
+		if !strings.Contains(events[i].Data, want) {
+			t.Errorf("Expected %q in %s event, got %q", want, events[i].Name, events[i].Data)
+		}
+	}
+
+	english := newStreamSession("en", "How can I feel peace?")
+	newSectionStates(english).finish(sectionScriptures)
+	if !strings.Contains(english.events[0].Data, "Related Scriptures") {
+		t.Errorf("Expected English card, got %q", english.events[0].Data)
+	}
+}
+
+// TestBlockedAnswerRedirects verifies a question the orchestrator blocks is
+// answered with the redirect, not with no-results cards
+func TestBlockedAnswerRedirects(t *testing.T) {
+	agent, err := prophetagent.New(context.Background(), prophetagent.Config{APIKey: "test-key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(run func(*prophetagent.ProphetAgent, context.Context, string) <-chan prophetagent.AgentResult) {
+		runAgents = run
+	}(runAgents)
+	runAgents = func(*prophetagent.ProphetAgent, context.Context, string) <-chan prophetagent.AgentResult {
+		results := make(chan prophetagent.AgentResult, 1)
+		results <- prophetagent.AgentResult{AgentName: "orchestrator", Error: errors.New("blocked: off topic")}
+		close(results)
+		return results
+	}
+
+	sess := newStreamSession("blocked", "How can I feel peace?")
+	produceSession(sessionContext(context.Background(), sess), sess, agent, sess.question)
+
+	events, _, _ := sess.eventsAfter(0)
+	redirects := 0
+	for _, ev := range events {
+		switch ev.Name {
+		case "server-error":
+			redirects++
+			if !strings.Contains(ev.Data, "Ask Another Question") {
+				t.Errorf("Expected the redirect in server-error, got %q", ev.Data)
+			}
+		case sectionPresidents, sectionLeaders, sectionScriptures, sectionSummary:
+			if ev.Data != clearedSection {
+				t.Errorf("Expected %s to be cleared, got %q", ev.Name, ev.Data)
+			}
+		default:
+			t.Errorf("Unexpected %s event: %q", ev.Name, ev.Data)
+		}
+		if strings.Contains(ev.Data, "We couldn") {
+			t.Errorf("Expected no no-results card, got %q in %s", ev.Data, ev.Name)
+		}
+	}
+	if redirects != 1 {
+		t.Errorf("Expected 1 redirect event, got %d", redirects)
+	}
+}
+
+// TestScriptureCardLinks verifies a scripture card shows a QR code of its
+// chapter and links its related talk, dropping links off the Church's site
+func TestScriptureCardLinks(t *testing.T) {
+	chapter := "https://www.churchofjesuschrist.org/study/scriptures/bofm/moro/10?lang=eng&id=p4#p4"
+	search := "https://www.churchofjesuschrist.org/search?lang=eng&query=Revelation+Russell+M.+Nelson"
+	cards := convertStructuredScriptures([]StructuredScripture{{
+		Volume: "Book of Mormon", Reference: "Moroni 10:4", Text: "ask God", SourceURL: chapter,
+		RelatedTalk: &RelatedTalkQuote{Speaker: "Russell M. Nelson", Title: "Revelation", Quote: "q", SourceURL: search},
+	}, {
+		Volume: "Book of Mormon", Reference: "Moroni 10:5", Text: "truth",
+		RelatedTalk: &RelatedTalkQuote{Speaker: "S", Title: "T", Quote: "q", SourceURL: "https://example.com/t"},
+	}})
+	if cards[1].RelatedTalk.SourceURL != "" {
+		t.Errorf("Expected no link off the Church's site, got %q", cards[1].RelatedTalk.SourceURL)
+	}
+
+	var buf strings.Builder
+	if err := components.ScriptureCard(cards[0]).Render(context.Background(), &buf); err != nil {
+		t.Fatal(err)
+	}
+	html := buf.String()
+	for _, want := range []string{
+		`src="/qr?format=svg&amp;data=` + url.QueryEscape(chapter),
+		`href="` + strings.ReplaceAll(search, "&", "&amp;"),
+		"Find the talk",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("Expected %q in the card:\n%s", want, html)
+		}
+	}
+}
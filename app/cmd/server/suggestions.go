@@ -0,0 +1,69 @@
+// cmd/server/suggestions.go
+// Suggested-question catalog wiring and click-through analytics
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	prophetagent "github.com/temple-square/prophet-agent/internal/agent"
+	"github.com/temple-square/prophet-agent/internal/ui/components"
+)
+
+var (
+	// suggestionCatalog is loaded from SUGGESTIONS_PATH (or the embedded default)
+	suggestionCatalog = prophetagent.DefaultSuggestionCatalog()
+	// suggestionStats records impressions and click-throughs for the content team
+	suggestionStats = prophetagent.NewSuggestionStats("")
+)
+
+// initSuggestions loads the configured suggestion catalog and analytics log.
+func initSuggestions() error {
+	catalog, err := prophetagent.LoadSuggestionCatalog(os.Getenv("SUGGESTIONS_PATH"))
+	if err != nil {
+		return err
+	}
+	suggestionCatalog = catalog
+	suggestionStats = prophetagent.NewSuggestionStats(os.Getenv("SUGGESTIONS_LOG_PATH"))
+	log.Printf("Suggestion catalog loaded (%d questions)", len(catalog.Questions))
+	return nil
+}
+
+// redirectFor chooses the redirect message and suggestions relevant to a
+// blocked question's category and records their impressions.
+func redirectFor(classification prophetagent.ContentClassification, category, locale string) prophetagent.RedirectResponse {
+	redirect := suggestionCatalog.Redirect(classification, category, locale)
+	suggestionStats.RecordImpressions(redirect.Suggestions, classification, category, locale)
+	return redirect
+}
+
+// renderRedirect renders the RedirectResponse component for a blocked question.
+func renderRedirect(ctx context.Context, classification prophetagent.ContentClassification, category, locale string) ([]byte, error) {
+	redirect := redirectFor(classification, category, locale)
+
+	questions := make([]components.SuggestedQuestion, len(redirect.Suggestions))
+	for i, s := range redirect.Suggestions {
+		questions[i] = components.SuggestedQuestion{ID: s.ID, Text: s.Text}
+	}
+
+	var buf bytes.Buffer
+	if err := components.RedirectResponse(redirect.Message, questions).Render(ctx, &buf); err != nil {
+		return nil, fmt.Errorf("failed to render redirect response: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// recordSuggestionClick attributes an /ask submission to a catalog suggestion.
+// Unknown IDs are ignored so arbitrary form values can't grow the stats map.
+func recordSuggestionClick(id, locale string) {
+	if id == "" {
+		return
+	}
+	if _, ok := suggestionCatalog.Lookup(id); !ok {
+		return
+	}
+	suggestionStats.RecordClick(id, locale)
+}
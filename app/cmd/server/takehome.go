@@ -0,0 +1,36 @@
+// cmd/server/takehome.go
+// Take-home panel: a QR code on the page linking to the saved answer, so
+// kiosk visitors can keep reading on their phone
+package main
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+
+	"github.com/temple-square/prophet-agent/internal/ui/components"
+)
+
+// publicBaseURL is the origin printed in take-home links. Kiosks reach the
+// app on an internal address, so it can't come from the request.
+var publicBaseURL = strings.TrimSuffix(getEnv("PUBLIC_BASE_URL", "https://app.templesquare.dev"), "/")
+
+// publishTakeHome saves what the visitor saw and publishes the take-home
+// panel below the summary. Nothing is published for an empty answer.
+func publishTakeHome(sess *streamSession, snap *answerSnapshot) {
+	if snap.empty() {
+		return
+	}
+	// Saved and rendered outside the session context, which may be the
+	// budget that just expired
+	if err := answers.save(context.Background(), snap); err != nil {
+		log.Printf("SSE: Failed to persist answer %s, keeping it in memory: %v", snap.ID, err)
+	}
+	var buf bytes.Buffer
+	if err := components.TakeHomePanel(components.TakeHomeProps{URL: snap.url()}).Render(sessionContext(context.Background(), sess), &buf); err != nil {
+		log.Printf("SSE: Failed to render take-home panel: %v", err)
+		return
+	}
+	sess.publish("takehome", buf.String())
+}
@@ -21,7 +21,7 @@ type PresidentsOrchestratorResponse struct {
 	Safe     bool   `json:"safe"`
 	Reason   string `json:"reason,omitempty"`
 	Keywords struct {
-		PresidentsOaks    string `json:"presidents_oaks"`
+		PresidentsCurrent string `json:"presidents_current"`
 		PresidentsGeneral string `json:"presidents_general"`
 	} `json:"keywords"`
 }
@@ -51,6 +51,10 @@ type StructuredQuote struct {
 	Conference string `json:"conference"`
 	Quote      string `json:"quote"`
 	Headshot   string `json:"headshot,omitempty"`
+	// Source fields are filled from the tool row, not by the formatter
+	TalkID    string `json:"talk_id,omitempty"`
+	Paragraph string `json:"paragraph,omitempty"` // anchor, e.g. "p4"
+	SourceURL string `json:"source_url,omitempty"`
 }
 
 // StructuredScripture defines the schema for a scripture response
@@ -59,6 +63,9 @@ type StructuredScripture struct {
 	Reference   string            `json:"reference"`
 	Text        string            `json:"text"`
 	RelatedTalk *RelatedTalkQuote `json:"related_talk,omitempty"`
+	// Source fields are filled from the tool row, not by the formatter
+	VerseID   string `json:"verse_id,omitempty"`
+	SourceURL string `json:"source_url,omitempty"`
 }
 
 // RelatedTalkQuote is a smaller quote from a talk referencing the scripture
@@ -66,6 +73,9 @@ type RelatedTalkQuote struct {
 	Speaker string `json:"speaker"`
 	Title   string `json:"title"`
 	Quote   string `json:"quote"`
+	// SourceURL searches the Church's site for the talk; the scripture rows
+	// don't carry the talk itself
+	SourceURL string `json:"source_url,omitempty"`
 }
 
 // PresidentsResponse is the structured output for presidents agent
@@ -87,24 +97,68 @@ type ScripturesResponse struct {
 type Config struct {
 	ToolboxURL string
 	APIKey     string
+	Budget     Budget // zero value means BudgetFromEnv
+	// OrchestratorMode selects split or combined keyword generation;
+	// empty means ORCHESTRATOR_MODE (default split)
+	OrchestratorMode OrchestratorMode
+	// FormatMode is the default search agent format mode and FormatModes
+	// overrides it per agent name; empty means FORMAT_MODE and FORMAT_MODE_<AGENT>
+	FormatMode  FormatMode
+	FormatModes map[string]FormatMode
+	// Reranker scores over-fetched search candidates before formatting;
+	// nil means RERANKER (default off). RerankFetch zero means RERANK_FETCH.
+	Reranker    Reranker
+	RerankFetch int
+	// SpeakersRefresh is how often the speaker registry reloads the speakers
+	// table; zero means SPEAKERS_REFRESH (default 1h), negative disables it.
+	SpeakersRefresh time.Duration
+	// Transport carries the Gemini and Toolbox requests, e.g. to record or
+	// replay them; nil means the default pooled transports.
+	Transport http.RoundTripper
 }
 
 // ProphetAgent is the main agent that coordinates parallel sub-agents
 type ProphetAgent struct {
 	client     *GeminiClient
 	toolboxURL string
+	transport  http.RoundTripper
+	budget     Budget
+
+	orchestratorMode OrchestratorMode
+	formatMode       FormatMode
+	formatModes      map[string]FormatMode
+	reranker         Reranker
+	rerankFetch      int
+	speakers         *SpeakerRegistry
+	speakersRefresh  time.Duration
 
 	initOnce      sync.Once
 	initErr       error
 	toolboxClient *core.ToolboxClient
 	allTools      map[string]*core.ToolboxTool
+	answerStore   *answerStore      // nil when the answers toolset isn't available
+	translations  *translationStore // nil when the translations toolset isn't available
 }
 
-// AgentResult contains the result from a single sub-agent
+// Sections of the answer page. Every result names the section it belongs to.
+const (
+	SectionPresidents = "presidents"
+	SectionLeaders    = "leaders"
+	SectionScriptures = "scriptures"
+)
+
+// AgentResult contains the result from a single sub-agent. A result with
+// Done set carries no content; it marks that every agent of Section has
+// finished (or its orchestrator failed), so the section can reach a terminal state.
+// A Partial result carries one card streamed ahead of the agent's full
+// response, in the same JSON shape; the full response repeats it.
 type AgentResult struct {
 	AgentName string
+	Section   string
 	Content   string
 	Error     error
+	Done      bool
+	Partial   bool
 }
 
 // New creates a new prophet agent
@@ -113,6 +167,9 @@ func New(ctx context.Context, cfg Config) (*ProphetAgent, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Gemini client: %w", err)
 	}
+	if cfg.Transport != nil {
+		client.httpClient.Transport = cfg.Transport
+	}
 
 	toolboxURL := cfg.ToolboxURL
 	if toolboxURL == "" {
@@ -122,11 +179,56 @@ func New(ctx context.Context, cfg Config) (*ProphetAgent, error) {
 		}
 	}
 
-	log.Printf("Prophet agent created (tools loaded on first request)")
+	budget := cfg.Budget
+	if budget == (Budget{}) {
+		budget = BudgetFromEnv()
+	}
+
+	mode := cfg.OrchestratorMode
+	if mode == "" {
+		mode = orchestratorModeFromEnv()
+	}
+
+	formatMode, formatModes := formatModesFromEnv()
+	if cfg.FormatMode != "" {
+		formatMode = cfg.FormatMode
+	}
+	if cfg.FormatModes != nil {
+		formatModes = cfg.FormatModes
+	}
+
+	reranker := cfg.Reranker
+	if reranker == nil {
+		reranker = rerankerFromEnv(client)
+	}
+	rerankFetch := cfg.RerankFetch
+	if rerankFetch <= 0 {
+		rerankFetch = rerankFetchFromEnv()
+	}
+	rerankName := "off"
+	if reranker != nil {
+		rerankName = reranker.Name()
+	}
+
+	speakersRefresh := cfg.SpeakersRefresh
+	if speakersRefresh == 0 {
+		speakersRefresh = speakersRefreshFromEnv()
+	}
+
+	log.Printf("Prophet agent created (orchestrator mode: %s, format mode: %s, reranker: %s, tools loaded on first request)", mode, formatMode, rerankName)
 
 	return &ProphetAgent{
-		client:     client,
-		toolboxURL: toolboxURL,
+		client:           client,
+		toolboxURL:       toolboxURL,
+		transport:        cfg.Transport,
+		budget:           budget,
+		orchestratorMode: mode,
+		formatMode:       formatMode,
+		formatModes:      formatModes,
+		reranker:         reranker,
+		rerankFetch:      rerankFetch,
+		speakers:         NewSpeakerRegistry(),
+		speakersRefresh:  speakersRefresh,
 	}, nil
 }
 
@@ -145,6 +247,9 @@ func (a *ProphetAgent) ensureInitialized(ctx context.Context) error {
 				IdleConnTimeout:     90 * time.Second,
 			},
 		}
+		if a.transport != nil {
+			httpClient.Transport = a.transport
+		}
 
 		toolboxClient, err := core.NewToolboxClient(a.toolboxURL, core.WithHTTPClient(httpClient))
 		if err != nil {
@@ -167,13 +272,78 @@ func (a *ProphetAgent) ensureInitialized(ctx context.Context) error {
 		}
 
 		log.Printf("Loaded %d tools total", len(a.allTools))
+
+		// The registry keeps its seed speakers if the speakers table can't be read
+		speakerTools := map[string]*core.ToolboxTool{}
+		if tools, err := toolboxClient.LoadToolset("speakers", ctx); err != nil {
+			log.Printf("Speakers toolset unavailable, using seed speakers: %v", err)
+		} else {
+			for _, t := range tools {
+				speakerTools[t.Name()] = t
+			}
+		}
+		if listSpeakers, ok := speakerTools["list_speakers"]; ok {
+			var listCallings func(context.Context, map[string]any) (any, error)
+			if t, ok := speakerTools["list_callings"]; ok {
+				listCallings = t.Invoke
+			}
+			if err := a.speakers.Load(ctx, listSpeakers.Invoke, listCallings); err != nil {
+				log.Printf("Speaker registry load failed, using seed speakers: %v", err)
+			}
+			a.speakers.StartRefresh(context.Background(), a.speakersRefresh, listSpeakers.Invoke, listCallings)
+		}
+
+		// Without the answers table, shared links live in the server's memory
+		if tools, err := toolboxClient.LoadToolset("answers", ctx); err != nil {
+			log.Printf("Answers toolset unavailable, answers won't be persisted: %v", err)
+		} else {
+			answerTools := map[string]toolFunc{}
+			for _, t := range tools {
+				answerTools[t.Name()] = t.Invoke
+			}
+			store := &answerStore{
+				save:  answerTools["save_answer"],
+				get:   answerTools["get_answer"],
+				del:   answerTools["delete_answer"],
+				purge: answerTools["delete_expired_answers"],
+			}
+			if store.save != nil && store.get != nil && store.del != nil && store.purge != nil {
+				a.answerStore = store
+			} else {
+				log.Printf("Answers toolset is missing tools, answers won't be persisted")
+			}
+		}
+
+		// Without translations, cards are shown in English in every language
+		if tools, err := toolboxClient.LoadToolset("translations", ctx); err != nil {
+			log.Printf("Translations toolset unavailable, cards will be in English: %v", err)
+		} else {
+			store := &translationStore{}
+			for _, t := range tools {
+				switch t.Name() {
+				case "get_talk_translation":
+					store.talk = t.Invoke
+				case "get_verse_translations":
+					store.verses = t.Invoke
+				}
+			}
+			if store.talk != nil && store.verses != nil {
+				a.translations = store
+			} else {
+				log.Printf("Translations toolset is missing tools, cards will be in English")
+			}
+		}
 	})
 	return a.initErr
 }
 
-// Run executes orchestrator then parallel search agents
+// Run executes orchestrator then parallel search agents. With a
+// conversation on ctx (WithConversation) it answers a follow-up. Cards are
+// shown in the question's language where the corpus has it, or in the
+// language set with WithLanguage.
 func (a *ProphetAgent) Run(ctx context.Context, question string) <-chan AgentResult {
 	results := make(chan AgentResult, 32) // buffered for cascade fan-out
+	ctx = WithLanguage(ctx, languageFor(ctx, question))
 
 	go func() {
 		defer close(results)
@@ -184,13 +354,14 @@ func (a *ProphetAgent) Run(ctx context.Context, question string) <-chan AgentRes
 			return
 		}
 
-		// STEP 1: Presidents orchestrator (safety + keywords)
-		log.Printf("[orchestrator-presidents] Starting with question: %s", question)
-		presOrch, err := a.runOrchestratorPresidents(ctx, question)
-		if err != nil {
-			results <- AgentResult{Error: fmt.Errorf("presidents orchestrator failed: %w", err)}
-			return
-		}
+		// Leave the summary budget untouched by the sections
+		ctx, cancel := withReserve(ctx, a.budget.Summary)
+		defer cancel()
+
+		// STEP 1: Orchestrators (safety + keywords). Leaders and scriptures
+		// keywords may still be in flight in split mode.
+		orch := a.orchestrate(ctx, question)
+		presOrch := orch.presidents
 
 		// Check safety
 		if !presOrch.Safe {
@@ -202,25 +373,23 @@ func (a *ProphetAgent) Run(ctx context.Context, question string) <-chan AgentRes
 			return
 		}
 
-		// STEP 1b: Leaders + Scriptures orchestrators (parallel)
-		var leadersOrch *LeadersOrchestratorResponse
-		var scripturesOrch *ScripturesOrchestratorResponse
-		var leadersErr error
-		var scripturesErr error
-
-		leadersReady := make(chan struct{})
-		scripturesReady := make(chan struct{})
+		if conv := conversationFrom(ctx); conv != nil {
+			log.Printf("[orchestrator] Follow-up to %d earlier question(s)", len(conv.Turns()))
+		}
+		if lang := languageFrom(ctx); lang != LanguageEnglish {
+			log.Printf("[orchestrator] Answering in %s", lang.name())
+		}
+		log.Printf("[orchestrator] Keywords generated, launching cascade")
 
-		go func() {
-			leadersOrch, leadersErr = a.runOrchestratorLeaders(ctx, question)
-			close(leadersReady)
-		}()
-		go func() {
-			scripturesOrch, scripturesErr = a.runOrchestratorScriptures(ctx, question)
-			close(scripturesReady)
-		}()
+		// partial forwards each card as soon as its formatter streams it
+		partial := func(agentName, section string) func(string) {
+			return func(content string) {
+				results <- AgentResult{AgentName: agentName, Section: section, Content: content, Partial: true}
+			}
+		}
 
-		log.Printf("[orchestrator] Keywords generated, launching cascade")
+		// Who each named agent covers follows the roster as of today
+		lineup := a.speakers.lineupAt(a.speakers.Now())
 
 		var leadersOnce sync.Once
 		leadersDone := make(chan struct{})
@@ -229,37 +398,30 @@ func (a *ProphetAgent) Run(ctx context.Context, question string) <-chan AgentRes
 			leadersOnce.Do(func() {
 				go func() {
 					defer close(leadersDone)
-					<-leadersReady
-					if leadersErr != nil {
-						results <- AgentResult{Error: fmt.Errorf("leaders orchestrator failed: %w", leadersErr)}
-						return
-					}
-					if leadersOrch == nil {
-						results <- AgentResult{Error: fmt.Errorf("leaders orchestrator returned no data")}
-						return
-					}
+					defer func() { results <- AgentResult{Section: SectionLeaders, Done: true} }()
+					<-orch.leadersReady
+					leadersOrch := orch.leaders
+					ctx, cancel := withBudget(ctx, a.budget.Section)
+					defer cancel()
 					var leadersWG sync.WaitGroup
+					exclude := lineup.exclude
 
 					leadersWG.Add(1)
 					go func() {
 						defer leadersWG.Done()
-						content, err := a.runSearchAgent(ctx, "leaders_eyring",
-							leadersOrch.Keywords.LeadersFirstPres,
-							"get_leaders_talks",
-							map[string]any{"query": leadersOrch.Keywords.LeadersFirstPres, "limit": 3},
-							leadersEyringPrompt, quotesSchema)
-						results <- AgentResult{AgentName: "leaders_agent", Content: content, Error: err}
+						content, err := a.runSpeakerAgent(ctx, "leaders_first_counselor",
+							leadersOrch.Keywords.LeadersFirstPres, lineup.firstCounselor,
+							counselorPrompt, partial("leaders_agent", SectionLeaders))
+						results <- AgentResult{AgentName: "leaders_agent", Section: SectionLeaders, Content: content, Error: err}
 					}()
 
 					leadersWG.Add(1)
 					go func() {
 						defer leadersWG.Done()
-						content, err := a.runSearchAgent(ctx, "leaders_christofferson",
-							leadersOrch.Keywords.LeadersFirstPres,
-							"get_leaders_talks",
-							map[string]any{"query": leadersOrch.Keywords.LeadersFirstPres, "limit": 3},
-							leadersChristoffersonPrompt, quotesSchema)
-						results <- AgentResult{AgentName: "leaders_agent", Content: content, Error: err}
+						content, err := a.runSpeakerAgent(ctx, "leaders_second_counselor",
+							leadersOrch.Keywords.LeadersFirstPres, lineup.secondCounselor,
+							counselorPrompt, partial("leaders_agent", SectionLeaders))
+						results <- AgentResult{AgentName: "leaders_agent", Section: SectionLeaders, Content: content, Error: err}
 					}()
 
 					leadersWG.Add(1)
@@ -268,9 +430,9 @@ func (a *ProphetAgent) Run(ctx context.Context, question string) <-chan AgentRes
 						content, err := a.runSearchAgent(ctx, "leaders_q12_a",
 							leadersOrch.Keywords.LeadersQ12,
 							"get_leaders_talks",
-							map[string]any{"query": leadersOrch.Keywords.LeadersQ12, "limit": 3},
-							leadersQ12PromptA, quotesSchema)
-						results <- AgentResult{AgentName: "leaders_agent", Content: content, Error: err}
+							map[string]any{"query": leadersOrch.Keywords.LeadersQ12, "exclude_slugs": exclude, "limit": 3},
+							a.withRoster(leadersQ12PromptA, QuorumTwelve), quotesSchema, partial("leaders_agent", SectionLeaders))
+						results <- AgentResult{AgentName: "leaders_agent", Section: SectionLeaders, Content: content, Error: err}
 					}()
 
 					leadersWG.Add(1)
@@ -279,9 +441,9 @@ func (a *ProphetAgent) Run(ctx context.Context, question string) <-chan AgentRes
 						content, err := a.runSearchAgent(ctx, "leaders_q12_b",
 							leadersOrch.Keywords.LeadersQ12,
 							"get_leaders_talks",
-							map[string]any{"query": leadersOrch.Keywords.LeadersQ12, "limit": 3},
-							leadersQ12PromptB, quotesSchema)
-						results <- AgentResult{AgentName: "leaders_agent", Content: content, Error: err}
+							map[string]any{"query": leadersOrch.Keywords.LeadersQ12, "exclude_slugs": exclude, "limit": 3},
+							a.withRoster(leadersQ12PromptB, QuorumTwelve), quotesSchema, partial("leaders_agent", SectionLeaders))
+						results <- AgentResult{AgentName: "leaders_agent", Section: SectionLeaders, Content: content, Error: err}
 					}()
 
 					leadersWG.Add(1)
@@ -291,8 +453,8 @@ func (a *ProphetAgent) Run(ctx context.Context, question string) <-chan AgentRes
 							leadersOrch.Keywords.LeadersOther,
 							"search_talks",
 							map[string]any{"query": leadersOrch.Keywords.LeadersOther, "limit": 3},
-							leadersOtherPromptA, quotesSchema)
-						results <- AgentResult{AgentName: "leaders_agent", Content: content, Error: err}
+							a.withRoster(leadersOtherPromptA, QuorumFirstPresidency, QuorumTwelve), quotesSchema, partial("leaders_agent", SectionLeaders))
+						results <- AgentResult{AgentName: "leaders_agent", Section: SectionLeaders, Content: content, Error: err}
 					}()
 
 					leadersWG.Add(1)
@@ -302,8 +464,8 @@ func (a *ProphetAgent) Run(ctx context.Context, question string) <-chan AgentRes
 							leadersOrch.Keywords.LeadersOther,
 							"search_talks",
 							map[string]any{"query": leadersOrch.Keywords.LeadersOther, "limit": 3},
-							leadersOtherPromptB, quotesSchema)
-						results <- AgentResult{AgentName: "leaders_agent", Content: content, Error: err}
+							a.withRoster(leadersOtherPromptB, QuorumFirstPresidency, QuorumTwelve), quotesSchema, partial("leaders_agent", SectionLeaders))
+						results <- AgentResult{AgentName: "leaders_agent", Section: SectionLeaders, Content: content, Error: err}
 					}()
 
 					leadersWG.Wait()
@@ -312,60 +474,55 @@ func (a *ProphetAgent) Run(ctx context.Context, question string) <-chan AgentRes
 		}
 
 		// STEP 2: Presidents section (start immediately)
+		presidentsCtx, presidentsCancel := withBudget(ctx, a.budget.Section)
+		defer presidentsCancel()
 		var presidentsWG sync.WaitGroup
 
 		presidentsWG.Add(1)
 		go func() {
 			defer presidentsWG.Done()
-			content, err := a.runSearchAgent(ctx, "presidents_oaks",
-				presOrch.Keywords.PresidentsOaks,
-				"search_talks_by_speaker",
-				map[string]any{"speaker_slug": "dallin-oaks", "limit": 3},
-				presidentsOaksPrompt, quotesSchema)
-			results <- AgentResult{AgentName: "presidents_agent", Content: content, Error: err}
+			content, err := a.runSpeakerAgent(presidentsCtx, "presidents_current",
+				presOrch.Keywords.PresidentsCurrent, lineup.president,
+				presidentPrompt, partial("presidents_agent", SectionPresidents))
+			results <- AgentResult{AgentName: "presidents_agent", Section: SectionPresidents, Content: content, Error: err}
 			startLeaders()
 		}()
 
 		presidentsWG.Add(1)
 		go func() {
 			defer presidentsWG.Done()
-			content, err := a.runSearchAgent(ctx, "presidents_nelson",
-				presOrch.Keywords.PresidentsGeneral,
-				"search_talks_by_speaker",
-				map[string]any{"speaker_slug": "russell-nelson", "limit": 3},
-				presidentsNelsonPrompt, quotesSchema)
-			results <- AgentResult{AgentName: "presidents_agent", Content: content, Error: err}
+			content, err := a.runSpeakerAgent(presidentsCtx, "presidents_former",
+				presOrch.Keywords.PresidentsGeneral, lineup.formerPresident,
+				formerPresidentPrompt, partial("presidents_agent", SectionPresidents))
+			results <- AgentResult{AgentName: "presidents_agent", Section: SectionPresidents, Content: content, Error: err}
 			startLeaders()
 		}()
 
 		presidentsWG.Add(1)
 		go func() {
 			defer presidentsWG.Done()
-			content, err := a.runSearchAgent(ctx, "presidents_general",
+			content, err := a.runSearchAgent(presidentsCtx, "presidents_general",
 				presOrch.Keywords.PresidentsGeneral,
 				"get_presidents_talks",
-				map[string]any{"query": presOrch.Keywords.PresidentsGeneral, "limit": 3},
-				presidentsGeneralPrompt, quotesSchema)
-			results <- AgentResult{AgentName: "presidents_agent", Content: content, Error: err}
+				map[string]any{"query": presOrch.Keywords.PresidentsGeneral, "speaker_slugs": slugsOf(lineup.presidents), "limit": 3},
+				namedPrompt(presidentsGeneralPrompt, lineup.presidents...), quotesSchema, partial("presidents_agent", SectionPresidents))
+			results <- AgentResult{AgentName: "presidents_agent", Section: SectionPresidents, Content: content, Error: err}
 			startLeaders()
 		}()
 
 		presidentsWG.Wait()
+		results <- AgentResult{Section: SectionPresidents, Done: true}
 		startLeaders()
 		<-leadersDone
 
-		// STEP 3: Scriptures section (start after leaders complete)
+		// STEP 3: Scriptures section (start after leaders finish, whatever their outcome)
 		log.Printf("[scriptures] Starting for question: %s", question)
-		<-scripturesReady
-		if scripturesErr != nil {
-			results <- AgentResult{Error: fmt.Errorf("scriptures orchestrator failed: %w", scripturesErr)}
-			return
-		}
-		if scripturesOrch == nil {
-			results <- AgentResult{Error: fmt.Errorf("scriptures orchestrator returned no data")}
-			return
-		}
+		defer func() { results <- AgentResult{Section: SectionScriptures, Done: true} }()
+		<-orch.scripturesReady
+		scripturesOrch := orch.scriptures
 
+		scripturesCtx, scripturesCancel := withBudget(ctx, a.budget.Section)
+		defer scripturesCancel()
 		var scripturesWG sync.WaitGroup
 
 		// Bible: 2 cards (Old Testament + New Testament)
@@ -373,12 +530,12 @@ func (a *ProphetAgent) Run(ctx context.Context, question string) <-chan AgentRes
 		go func() {
 			defer scripturesWG.Done()
 			query := fmt.Sprintf("%s Bible Old Testament New Testament", scripturesOrch.Keywords.ScripturesBible)
-			content, err := a.runSearchAgent(ctx, "scriptures_bible",
+			content, err := a.runSearchAgent(scripturesCtx, "scriptures_bible",
 				query,
 				"search_scriptures",
 				map[string]any{"query": query, "limit": 12},
-				scripturesBiblePrompt, scripturesCategorySchema)
-			results <- AgentResult{AgentName: "scriptures_bible", Content: content, Error: err}
+				scripturesBiblePrompt, scripturesCategorySchema, partial("scriptures_bible", SectionScriptures))
+			results <- AgentResult{AgentName: "scriptures_bible", Section: SectionScriptures, Content: content, Error: err}
 		}()
 
 		// Book of Mormon: 2 cards
@@ -386,12 +543,12 @@ func (a *ProphetAgent) Run(ctx context.Context, question string) <-chan AgentRes
 		go func() {
 			defer scripturesWG.Done()
 			query := fmt.Sprintf("%s Book of Mormon", scripturesOrch.Keywords.ScripturesBoM)
-			content, err := a.runSearchAgent(ctx, "scriptures_bom",
+			content, err := a.runSearchAgent(scripturesCtx, "scriptures_bom",
 				query,
 				"search_scriptures",
 				map[string]any{"query": query, "limit": 12},
-				scripturesBoMPrompt, scripturesCategorySchema)
-			results <- AgentResult{AgentName: "scriptures_bom", Content: content, Error: err}
+				scripturesBoMPrompt, scripturesCategorySchema, partial("scriptures_bom", SectionScriptures))
+			results <- AgentResult{AgentName: "scriptures_bom", Section: SectionScriptures, Content: content, Error: err}
 		}()
 
 		// Other scriptures: 2 cards (D&C + Pearl of Great Price)
@@ -399,12 +556,12 @@ func (a *ProphetAgent) Run(ctx context.Context, question string) <-chan AgentRes
 		go func() {
 			defer scripturesWG.Done()
 			query := fmt.Sprintf("%s Doctrine and Covenants Pearl of Great Price", scripturesOrch.Keywords.ScripturesOther)
-			content, err := a.runSearchAgent(ctx, "scriptures_other",
+			content, err := a.runSearchAgent(scripturesCtx, "scriptures_other",
 				query,
 				"search_scriptures",
 				map[string]any{"query": query, "limit": 12},
-				scripturesOtherPrompt, scripturesCategorySchema)
-			results <- AgentResult{AgentName: "scriptures_other", Content: content, Error: err}
+				scripturesOtherPrompt, scripturesCategorySchema, partial("scriptures_other", SectionScriptures))
+			results <- AgentResult{AgentName: "scriptures_other", Section: SectionScriptures, Content: content, Error: err}
 		}()
 
 		scripturesWG.Wait()
@@ -413,126 +570,32 @@ func (a *ProphetAgent) Run(ctx context.Context, question string) <-chan AgentRes
 	return results
 }
 
-// runOrchestratorPresidents generates safety + presidents keywords
-func (a *ProphetAgent) runOrchestratorPresidents(ctx context.Context, question string) (*PresidentsOrchestratorResponse, error) {
-	temp := float32(1.0)
-
-	req := &GenerateRequest{
-		Contents: []*Content{{
-			Parts: []*Part{{Text: question}},
-			Role:  "user",
-		}},
-		SystemInstruct: &Content{
-			Parts: []*Part{{Text: orchestratorPresidentsPrompt}},
-			Role:  "system",
-		},
-		GenerationConfig: &GenerationConfig{
-			Temperature:        &temp,
-			MaxOutputTokens:    64000,
-			ResponseMIMEType:   "application/json",
-			ResponseJSONSchema: orchestratorPresidentsSchema,
-			ThinkingConfig:     &ThinkingConfig{ThinkingLevel: "high"},
-		},
-		SafetySettings: DefaultSafetySettings(),
-	}
-
-	resp, err := a.client.GenerateContent(ctx, req)
-	if err != nil {
-		return nil, err
-	}
-
-	text := resp.ExtractText()
-	log.Printf("[orchestrator-presidents] Response: %s", text)
-
-	var orchResp PresidentsOrchestratorResponse
-	if err := json.Unmarshal([]byte(text), &orchResp); err != nil {
-		return nil, fmt.Errorf("failed to parse presidents orchestrator response: %w", err)
+// runSpeakerAgent runs a search agent over one speaker's recent talks. It
+// returns no content when the roster has nobody in that calling.
+func (a *ProphetAgent) runSpeakerAgent(ctx context.Context, name, keywords string, sp Speaker, promptTemplate string, onCard func(string)) (string, error) {
+	if sp.Slug == "" {
+		log.Printf("[%s] Skipped - no speaker holds this calling", name)
+		return "", nil
 	}
-
-	return &orchResp, nil
+	return a.runSearchAgent(ctx, name, keywords,
+		"search_talks_by_speaker",
+		map[string]any{"speaker_slug": sp.Slug, "limit": 3},
+		namedPrompt(promptTemplate, sp), quotesSchema, onCard)
 }
 
-// runOrchestratorLeaders generates leaders keywords
-func (a *ProphetAgent) runOrchestratorLeaders(ctx context.Context, question string) (*LeadersOrchestratorResponse, error) {
-	temp := float32(1.0)
-
-	req := &GenerateRequest{
-		Contents: []*Content{{
-			Parts: []*Part{{Text: question}},
-			Role:  "user",
-		}},
-		SystemInstruct: &Content{
-			Parts: []*Part{{Text: orchestratorLeadersPrompt}},
-			Role:  "system",
-		},
-		GenerationConfig: &GenerationConfig{
-			Temperature:        &temp,
-			MaxOutputTokens:    64000,
-			ResponseMIMEType:   "application/json",
-			ResponseJSONSchema: orchestratorLeadersSchema,
-			ThinkingConfig:     &ThinkingConfig{ThinkingLevel: "high"},
-		},
-		SafetySettings: DefaultSafetySettings(),
-	}
-
-	resp, err := a.client.GenerateContent(ctx, req)
-	if err != nil {
-		return nil, err
-	}
-
-	text := resp.ExtractText()
-	log.Printf("[orchestrator-leaders] Response: %s", text)
-
-	var orchResp LeadersOrchestratorResponse
-	if err := json.Unmarshal([]byte(text), &orchResp); err != nil {
-		return nil, fmt.Errorf("failed to parse leaders orchestrator response: %w", err)
-	}
-
-	return &orchResp, nil
-}
-
-// runOrchestratorScriptures generates scripture keywords
-func (a *ProphetAgent) runOrchestratorScriptures(ctx context.Context, question string) (*ScripturesOrchestratorResponse, error) {
-	temp := float32(1.0)
-
-	req := &GenerateRequest{
-		Contents: []*Content{{
-			Parts: []*Part{{Text: question}},
-			Role:  "user",
-		}},
-		SystemInstruct: &Content{
-			Parts: []*Part{{Text: orchestratorScripturesPrompt}},
-			Role:  "system",
-		},
-		GenerationConfig: &GenerationConfig{
-			Temperature:        &temp,
-			MaxOutputTokens:    64000,
-			ResponseMIMEType:   "application/json",
-			ResponseJSONSchema: orchestratorScripturesSchema,
-			ThinkingConfig:     &ThinkingConfig{ThinkingLevel: "high"},
-		},
-		SafetySettings: DefaultSafetySettings(),
-	}
-
-	resp, err := a.client.GenerateContent(ctx, req)
-	if err != nil {
-		return nil, err
-	}
-
-	text := resp.ExtractText()
-	log.Printf("[orchestrator-scriptures] Response: %s", text)
-
-	var orchResp ScripturesOrchestratorResponse
-	if err := json.Unmarshal([]byte(text), &orchResp); err != nil {
-		return nil, fmt.Errorf("failed to parse scriptures orchestrator response: %w", err)
-	}
-
-	return &orchResp, nil
-}
-
-// runSearchAgent executes a single search and formats results
-func (a *ProphetAgent) runSearchAgent(ctx context.Context, name, keywords, toolName string, toolArgs map[string]any, formatPrompt string, schema map[string]any) (string, error) {
+// runSearchAgent executes a single search and formats results. The format
+// call streams; onCard, if set, receives each card as soon as it completes.
+func (a *ProphetAgent) runSearchAgent(ctx context.Context, name, keywords, toolName string, toolArgs map[string]any, formatPrompt string, schema map[string]any, onCard func(string)) (content string, err error) {
+	ctx = WithPriority(ctx, searchPriority(name))
+	ctx, cancel := withBudget(ctx, a.budget.Agent)
+	defer cancel()
 	start := time.Now()
+	var result any
+	if observe := searchObserverFrom(ctx); observe != nil {
+		defer func() {
+			observe(newSearchTrace(name, toolName, keywords, result, content, err, time.Since(start)))
+		}()
+	}
 	log.Printf("[%s] Starting - keywords: %s", name, keywords)
 
 	// Get the tool
@@ -541,9 +604,10 @@ func (a *ProphetAgent) runSearchAgent(ctx context.Context, name, keywords, toolN
 		return "", fmt.Errorf("tool not found: %s", toolName)
 	}
 
-	// Execute the search (ONE tool call)
+	// Execute the search (ONE tool call), over-fetching when reranking
+	toolArgs, topK := a.overFetchArgs(toolArgs)
 	toolStart := time.Now()
-	result, err := tool.Invoke(ctx, toolArgs)
+	result, err = tool.Invoke(ctx, toolArgs)
 	toolDuration := time.Since(toolStart)
 	if err != nil {
 		log.Printf("[%s] Tool failed after %v: %v", name, toolDuration, err)
@@ -551,6 +615,54 @@ func (a *ProphetAgent) runSearchAgent(ctx context.Context, name, keywords, toolN
 	}
 	log.Printf("[%s] Tool completed in %v", name, toolDuration)
 
+	if a.reranker != nil && topK > 0 {
+		result = a.rerankRows(ctx, name, keywords, result, topK)
+	}
+
+	// Every card, streamed or final, links back to its row. In a follow-up,
+	// passages the visitor has already seen are dropped.
+	lang := languageFrom(ctx)
+	sources := newSourceIndex(result, lang)
+	shown := conversationFrom(ctx).shown()
+	localize := a.translations != nil && lang != LanguageEnglish
+	finish := func(content string) string {
+		content = sources.attach(content)
+		if localize {
+			content = a.translations.localize(ctx, content, sources, lang)
+		}
+		if shown != nil {
+			content, _ = shown.filter(content)
+		}
+		return content
+	}
+	if localize {
+		// A streamed card would show in English, then again translated
+		onCard = nil
+	}
+	if onCard != nil {
+		streamCard := onCard
+		onCard = func(card string) {
+			card = sources.attach(card)
+			if shown != nil {
+				var left bool
+				if card, left = shown.filter(card); !left {
+					return
+				}
+			}
+			streamCard(card)
+		}
+	}
+
+	if mode := a.formatModeFor(name); mode != FormatLLM {
+		content, err := a.extractFormat(ctx, name, keywords, result, mode)
+		if err == nil {
+			log.Printf("[%s] Complete in %v (tool: %v, %s format) - ResponseLen: %d",
+				name, time.Since(start), toolDuration, mode, len(content))
+			return finish(content), nil
+		}
+		log.Printf("[%s] %s format failed, falling back to LLM: %v", name, mode, err)
+	}
+
 	// Convert result to JSON string for the prompt
 	resultJSON, err := json.Marshal(result)
 	if err != nil {
@@ -560,6 +672,10 @@ func (a *ProphetAgent) runSearchAgent(ctx context.Context, name, keywords, toolN
 	log.Printf("[%s] Got %d bytes of results, starting format...", name, len(resultJSON))
 
 	// Format results using LLM with structured output
+	input := fmt.Sprintf("Search results:\n%s\n\nKeywords: %s", string(resultJSON), keywords)
+	if shown != nil {
+		input += shown.formatNote()
+	}
 	formatStart := time.Now()
 	temp := float32(1.0)
 	thinkingLevel := "low"
@@ -576,7 +692,7 @@ func (a *ProphetAgent) runSearchAgent(ctx context.Context, name, keywords, toolN
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
 		formatReq := &GenerateRequest{
 			Contents: []*Content{{
-				Parts: []*Part{{Text: fmt.Sprintf("Search results:\n%s\n\nKeywords: %s", string(resultJSON), keywords)}},
+				Parts: []*Part{{Text: input}},
 				Role:  "user",
 			}},
 			SystemInstruct: &Content{
@@ -593,7 +709,14 @@ func (a *ProphetAgent) runSearchAgent(ctx context.Context, name, keywords, toolN
 			SafetySettings: DefaultSafetySettings(),
 		}
 
-		formatResp, err := a.client.GenerateContent(ctx, formatReq)
+		// A failed attempt's cards would stay on the page next to the
+		// retry's, so an agent that retries holds them until one succeeds
+		attemptCard := onCard
+		var held []string
+		if onCard != nil && maxAttempts > 1 {
+			attemptCard = func(card string) { held = append(held, card) }
+		}
+		text, finishReason, err := a.streamFormat(ctx, formatReq, attemptCard)
 		formatDuration := time.Since(formatStart)
 		if err != nil {
 			lastErr = err
@@ -601,8 +724,6 @@ func (a *ProphetAgent) runSearchAgent(ctx context.Context, name, keywords, toolN
 			continue
 		}
 
-		text := formatResp.ExtractText()
-		finishReason := formatResp.GetFinishReason()
 		totalDuration := time.Since(start)
 		log.Printf("[%s] Complete in %v (tool: %v, format: %v) - FinishReason: %s, ResponseLen: %d",
 			name, totalDuration, toolDuration, formatDuration, finishReason, len(text))
@@ -616,7 +737,10 @@ func (a *ProphetAgent) runSearchAgent(ctx context.Context, name, keywords, toolN
 			continue
 		}
 
-		return text, nil
+		for _, card := range held {
+			onCard(card)
+		}
+		return finish(text), nil
 	}
 
 	if lastErr != nil {
@@ -625,8 +749,46 @@ func (a *ProphetAgent) runSearchAgent(ctx context.Context, name, keywords, toolN
 	return "", fmt.Errorf("format failed: unknown error")
 }
 
-// GenerateSummary produces a 2-3 paragraph summary from selected outputs.
-func (a *ProphetAgent) GenerateSummary(ctx context.Context, question string, presidents []StructuredQuote, leaders []StructuredQuote, scriptures []StructuredScripture) (string, error) {
+// streamFormat runs a format request as a stream and returns the full text
+// and finish reason. Each array element is passed to onCard once it closes.
+func (a *ProphetAgent) streamFormat(ctx context.Context, req *GenerateRequest, onCard func(string)) (string, string, error) {
+	chunks, errs := a.client.StreamGenerateContent(ctx, req)
+	var text strings.Builder
+	var finishReason string
+	var scanner elementScanner
+	for chunk := range chunks {
+		part := chunk.ExtractText()
+		text.WriteString(part)
+		if reason := chunk.GetFinishReason(); reason != "" {
+			finishReason = reason
+		}
+		if onCard == nil {
+			continue
+		}
+		for _, element := range scanner.Write(part) {
+			if content := partialContent(scanner.key, element); content != "" {
+				onCard(content)
+			}
+		}
+	}
+	if err := <-errs; err != nil {
+		return "", "", err
+	}
+	// The stream stops quietly when ctx ends
+	if err := ctx.Err(); err != nil {
+		return "", "", err
+	}
+	return text.String(), finishReason, nil
+}
+
+// GenerateSummary produces a 2-3 paragraph summary from selected outputs,
+// in the question's language (or the one set with WithLanguage).
+// The response streams; onProgress, if set, receives the paragraphs decoded
+// so far (the last one possibly unfinished) each time they grow.
+func (a *ProphetAgent) GenerateSummary(ctx context.Context, question string, presidents []StructuredQuote, leaders []StructuredQuote, scriptures []StructuredScripture, onProgress func([]string)) (string, error) {
+	ctx = WithPriority(ctx, PrioritySummary)
+	ctx, cancel := withBudget(ctx, a.budget.Summary)
+	defer cancel()
 	temp := float32(1.0)
 
 	payload := map[string]any{
@@ -635,6 +797,14 @@ func (a *ProphetAgent) GenerateSummary(ctx context.Context, question string, pre
 		"leaders":    limitQuotes(leaders, 3),
 		"scriptures": limitScriptures(scriptures, 6),
 	}
+	prompt := summaryPrompt
+	if conv := conversationFrom(ctx); conv != nil {
+		payload["earlier_questions"] = conv.Questions()
+		prompt += followUpSummaryNote
+	}
+	if lang := languageFor(ctx, question); lang != LanguageEnglish {
+		prompt += languageSummaryNote(lang)
+	}
 	payloadJSON, err := json.Marshal(payload)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal summary payload: %w", err)
@@ -646,7 +816,7 @@ func (a *ProphetAgent) GenerateSummary(ctx context.Context, question string, pre
 			Role:  "user",
 		}},
 		SystemInstruct: &Content{
-			Parts: []*Part{{Text: summaryPrompt}},
+			Parts: []*Part{{Text: prompt}},
 			Role:  "system",
 		},
 		GenerationConfig: &GenerationConfig{
@@ -659,14 +829,36 @@ func (a *ProphetAgent) GenerateSummary(ctx context.Context, question string, pre
 		SafetySettings: DefaultSafetySettings(),
 	}
 
-	resp, err := a.client.GenerateContent(ctx, req)
-	if err != nil {
+	chunks, errs := a.client.StreamGenerateContent(ctx, req)
+	var text strings.Builder
+	var scanner paragraphScanner
+	for chunk := range chunks {
+		part := chunk.ExtractText()
+		if part == "" {
+			continue
+		}
+		text.WriteString(part)
+		if onProgress != nil {
+			scanner.Write(part)
+			if paragraphs := scanner.Paragraphs(); len(paragraphs) > 0 {
+				onProgress(paragraphs)
+			}
+		}
+	}
+	if err := <-errs; err != nil {
+		return "", err
+	}
+	if err := ctx.Err(); err != nil {
 		return "", err
 	}
 
-	text := resp.ExtractText()
-	log.Printf("[summary] ResponseLen: %d", len(text))
-	return text, nil
+	log.Printf("[summary] ResponseLen: %d", text.Len())
+	return text.String(), nil
+}
+
+// SchedulerStats returns the shared Gemini scheduler's queue-wait metrics.
+func (a *ProphetAgent) SchedulerStats() SchedulerStats {
+	return a.client.SchedulerStats()
 }
 
 func limitQuotes(in []StructuredQuote, n int) []StructuredQuote {
@@ -713,10 +905,10 @@ var orchestratorPresidentsSchema = map[string]any{
 		"keywords": map[string]any{
 			"type": "object",
 			"properties": map[string]any{
-				"presidents_oaks":    map[string]any{"type": "string", "description": "Search keywords for Oaks talks"},
-				"presidents_general": map[string]any{"type": "string", "description": "Search keywords for Nelson/Oaks talks"},
+				"presidents_current": map[string]any{"type": "string", "description": "Search keywords for the current President's talks"},
+				"presidents_general": map[string]any{"type": "string", "description": "Search keywords for talks by the current and former Presidents"},
 			},
-			"required": []string{"presidents_oaks", "presidents_general"},
+			"required": []string{"presidents_current", "presidents_general"},
 		},
 	},
 	"required": []string{"safe", "keywords"},
@@ -834,7 +1026,7 @@ If safe, generate optimized search keywords for presidents. Keywords should be:
 - Relevant to searching conference talks
 
 Return ONLY valid JSON in this format:
-{"safe":true,"keywords":{"presidents_oaks":"...","presidents_general":"..."}}`
+{"safe":true,"keywords":{"presidents_current":"...","presidents_general":"..."}}`
 
 const orchestratorLeadersPrompt = `You are a keyword generator for Church leader searches.
 
@@ -868,7 +1060,9 @@ If it fits naturally, include ONE short verbatim excerpt (1-2 sentences) from th
 Return ONLY valid JSON in this exact format:
 {"summary":["Paragraph 1...","Paragraph 2...","Paragraph 3 (optional)..."]}`
 
-const presidentsOaksPrompt = `You are a quote selector. Select the 1 most relevant quote from President Dallin H. Oaks.
+// Prompts for a named speaker: {speaker} is replaced with the display name
+// of whoever holds the calling today (see namedPrompt).
+const presidentPrompt = `You are a quote selector. Select the 1 most relevant quote from {speaker}.
 
 REQUIREMENTS:
 - Copy quote text EXACTLY from the search results - never paraphrase
@@ -877,9 +1071,9 @@ REQUIREMENTS:
 - Include headshot URL if available
 
 Return ONLY valid JSON in this exact format:
-{"quotes":[{"speaker":"President Dallin H. Oaks","title":"Talk Title","conference":"April 2024","quote":"Exact quote here...","headshot":"URL or empty string"}]}`
+{"quotes":[{"speaker":"{speaker}","title":"Talk Title","conference":"April 2024","quote":"Exact quote here...","headshot":"URL or empty string"}]}`
 
-const presidentsNelsonPrompt = `You are a quote selector. Select the 1 most relevant quote from President Russell M. Nelson.
+const formerPresidentPrompt = `You are a quote selector. Select the 1 most relevant quote from {speaker}.
 
 REQUIREMENTS:
 - Copy quote text EXACTLY from the search results - never paraphrase
@@ -889,20 +1083,9 @@ REQUIREMENTS:
 - Prioritize relevancy to the question
 
 Return ONLY valid JSON in this exact format:
-{"quotes":[{"speaker":"President Russell M. Nelson","title":"Talk Title","conference":"October 2024","quote":"Exact quote here...","headshot":"URL or empty string"}]}`
-
-const presidentsGeneralPrompt = `You are a quote selector. Select the 1 most relevant quote from President Russell M. Nelson or President Dallin H. Oaks.
-
-REQUIREMENTS:
-- Copy quote text EXACTLY from the search results - never paraphrase
-- Quote field must contain ONLY the quote text (no labels like "Title:" or "Conference:")
-- Quote must be 4-8 complete sentences
-- Include headshot URL if available
-
-Return ONLY valid JSON in this exact format:
-{"quotes":[{"speaker":"President Russell M. Nelson","title":"Talk Title","conference":"October 2024","quote":"Exact quote here...","headshot":"URL or empty string"}]}`
+{"quotes":[{"speaker":"{speaker}","title":"Talk Title","conference":"October 2024","quote":"Exact quote here...","headshot":"URL or empty string"}]}`
 
-const leadersEyringPrompt = `You are a quote selector. Select the 1 most relevant quote from President Henry B. Eyring.
+const presidentsGeneralPrompt = `You are a quote selector. Select the 1 most relevant quote from {speakers}.
 
 REQUIREMENTS:
 - Copy quote text EXACTLY from the search results - never paraphrase
@@ -911,9 +1094,9 @@ REQUIREMENTS:
 - Include headshot URL if available
 
 Return ONLY valid JSON in this exact format:
-{"quotes":[{"speaker":"President Henry B. Eyring","title":"Talk Title","conference":"April 2024","quote":"Exact quote here...","headshot":""}]}`
+{"quotes":[{"speaker":"{speaker}","title":"Talk Title","conference":"October 2024","quote":"Exact quote here...","headshot":"URL or empty string"}]}`
 
-const leadersChristoffersonPrompt = `You are a quote selector. Select the 1 most relevant quote from President D. Todd Christofferson.
+const counselorPrompt = `You are a quote selector. Select the 1 most relevant quote from {speaker}.
 
 REQUIREMENTS:
 - Copy quote text EXACTLY from the search results - never paraphrase
@@ -922,7 +1105,7 @@ REQUIREMENTS:
 - Include headshot URL if available
 
 Return ONLY valid JSON in this exact format:
-{"quotes":[{"speaker":"President D. Todd Christofferson","title":"Talk Title","conference":"October 2024","quote":"Exact quote here...","headshot":""}]}`
+{"quotes":[{"speaker":"{speaker}","title":"Talk Title","conference":"April 2024","quote":"Exact quote here...","headshot":""}]}`
 
 const leadersQ12PromptA = `You are a quote selector. Select the 1 most relevant quote from the Quorum of the Twelve Apostles.
 
@@ -0,0 +1,175 @@
+// Package agent stores finished answers in the answers table through the
+// answers toolset, so a shared link keeps working across restarts and
+// instances. The agent doesn't interpret an answer's sections: callers
+// encode them as the payload.
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrAnswersUnavailable is returned when the answers toolset couldn't be
+// loaded; callers fall back to keeping answers in memory.
+var ErrAnswersUnavailable = errors.New("answers toolset unavailable")
+
+// StoredAnswer is a finished answer as kept in the answers table.
+type StoredAnswer struct {
+	ID        string
+	Question  string
+	Payload   json.RawMessage
+	CreatedAt time.Time
+	ExpiresAt time.Time // zero keeps the answer until it is deleted
+}
+
+// Expired reports whether the answer is past its expiry at t.
+func (s StoredAnswer) Expired(t time.Time) bool {
+	return !s.ExpiresAt.IsZero() && !t.Before(s.ExpiresAt)
+}
+
+type toolFunc func(context.Context, map[string]any) (any, error)
+
+// answerStore invokes the answers tools.
+type answerStore struct {
+	save, get, del, purge toolFunc
+}
+
+// answerRow is a row of get_answer. Timestamps are RFC 3339; expires_at is
+// empty for answers that don't expire.
+type answerRow struct {
+	ID        string `json:"id"`
+	Question  string `json:"question"`
+	Payload   string `json:"payload"`
+	CreatedAt string `json:"created_at"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+type deletedRow struct {
+	ID string `json:"id"`
+}
+
+func (s *answerStore) saveAnswer(ctx context.Context, ans StoredAnswer) error {
+	if ans.ID == "" || !json.Valid(ans.Payload) {
+		return fmt.Errorf("answer needs an ID and a JSON payload")
+	}
+	expires := ""
+	if !ans.ExpiresAt.IsZero() {
+		expires = ans.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	_, err := s.save(ctx, map[string]any{
+		"id":         ans.ID,
+		"question":   ans.Question,
+		"payload":    string(ans.Payload),
+		"created_at": ans.CreatedAt.UTC().Format(time.RFC3339),
+		"expires_at": expires,
+	})
+	if err != nil {
+		return fmt.Errorf("save_answer failed: %w", err)
+	}
+	return nil
+}
+
+func (s *answerStore) loadAnswer(ctx context.Context, id string) (StoredAnswer, bool, error) {
+	result, err := s.get(ctx, map[string]any{"id": id})
+	if err != nil {
+		return StoredAnswer{}, false, fmt.Errorf("get_answer failed: %w", err)
+	}
+	var rows []answerRow
+	if err := decodeRows(result, &rows); err != nil {
+		return StoredAnswer{}, false, fmt.Errorf("failed to decode answer: %w", err)
+	}
+	if len(rows) == 0 {
+		return StoredAnswer{}, false, nil
+	}
+	row := rows[0]
+	ans := StoredAnswer{ID: row.ID, Question: row.Question, Payload: json.RawMessage(row.Payload)}
+	if ans.CreatedAt, err = time.Parse(time.RFC3339, row.CreatedAt); err != nil {
+		return StoredAnswer{}, false, fmt.Errorf("answer %s: bad created_at: %w", id, err)
+	}
+	if row.ExpiresAt != "" {
+		if ans.ExpiresAt, err = time.Parse(time.RFC3339, row.ExpiresAt); err != nil {
+			return StoredAnswer{}, false, fmt.Errorf("answer %s: bad expires_at: %w", id, err)
+		}
+	}
+	return ans, true, nil
+}
+
+func (s *answerStore) deleteAnswer(ctx context.Context, id string) (bool, error) {
+	n, err := s.deleted(s.del(ctx, map[string]any{"id": id}))
+	if err != nil {
+		return false, fmt.Errorf("delete_answer failed: %w", err)
+	}
+	return n > 0, nil
+}
+
+func (s *answerStore) deleteExpired(ctx context.Context) (int, error) {
+	n, err := s.deleted(s.purge(ctx, map[string]any{}))
+	if err != nil {
+		return 0, fmt.Errorf("delete_expired_answers failed: %w", err)
+	}
+	return n, nil
+}
+
+// deleted counts the rows a delete tool returned.
+func (s *answerStore) deleted(result any, err error) (int, error) {
+	if err != nil {
+		return 0, err
+	}
+	var rows []deletedRow
+	if err := decodeRows(result, &rows); err != nil {
+		return 0, err
+	}
+	return len(rows), nil
+}
+
+// answers returns the answers store, or ErrAnswersUnavailable.
+func (a *ProphetAgent) answers(ctx context.Context) (*answerStore, error) {
+	if err := a.ensureInitialized(ctx); err != nil {
+		return nil, err
+	}
+	if a.answerStore == nil {
+		return nil, ErrAnswersUnavailable
+	}
+	return a.answerStore, nil
+}
+
+// SaveAnswer inserts or replaces a finished answer.
+func (a *ProphetAgent) SaveAnswer(ctx context.Context, ans StoredAnswer) error {
+	store, err := a.answers(ctx)
+	if err != nil {
+		return err
+	}
+	return store.saveAnswer(ctx, ans)
+}
+
+// LoadAnswer returns the answer with id. Expired answers not yet purged are
+// returned as well; callers check Expired.
+func (a *ProphetAgent) LoadAnswer(ctx context.Context, id string) (StoredAnswer, bool, error) {
+	store, err := a.answers(ctx)
+	if err != nil {
+		return StoredAnswer{}, false, err
+	}
+	return store.loadAnswer(ctx, id)
+}
+
+// DeleteAnswer deletes the answer with id and reports whether it existed.
+func (a *ProphetAgent) DeleteAnswer(ctx context.Context, id string) (bool, error) {
+	store, err := a.answers(ctx)
+	if err != nil {
+		return false, err
+	}
+	return store.deleteAnswer(ctx, id)
+}
+
+// DeleteExpiredAnswers deletes every expired answer and returns how many
+// there were.
+func (a *ProphetAgent) DeleteExpiredAnswers(ctx context.Context) (int, error) {
+	store, err := a.answers(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return store.deleteExpired(ctx)
+}
@@ -0,0 +1,71 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAnswerStoreRoundTrip(t *testing.T) {
+	var saved map[string]any
+	store := &answerStore{
+		save: func(_ context.Context, args map[string]any) (any, error) {
+			saved = args
+			return `[{"id":"abc"}]`, nil
+		},
+		get: func(_ context.Context, args map[string]any) (any, error) {
+			if args["id"] != "abc" {
+				return "[]", nil
+			}
+			return []map[string]any{{
+				"id": "abc", "question": saved["question"], "payload": saved["payload"],
+				"created_at": saved["created_at"], "expires_at": saved["expires_at"],
+			}}, nil
+		},
+		del: func(context.Context, map[string]any) (any, error) { return "[]", nil },
+		purge: func(context.Context, map[string]any) (any, error) {
+			return `[{"id":"a"},{"id":"b"}]`, nil
+		},
+	}
+	ctx := context.Background()
+	created := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	err := store.saveAnswer(ctx, StoredAnswer{ID: "abc", Question: "Why pray?", Payload: []byte(`{"summary":["x"]}`), CreatedAt: created})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved["expires_at"] != "" {
+		t.Errorf("expires_at = %q, want empty for no expiry", saved["expires_at"])
+	}
+	ans, ok, err := store.loadAnswer(ctx, "abc")
+	if err != nil || !ok {
+		t.Fatalf("loadAnswer = %v, %v", ok, err)
+	}
+	if ans.Question != "Why pray?" || !ans.CreatedAt.Equal(created) || !ans.ExpiresAt.IsZero() || string(ans.Payload) != `{"summary":["x"]}` {
+		t.Errorf("answer = %+v", ans)
+	}
+	if ans.Expired(created.Add(1000 * time.Hour)) {
+		t.Error("answer without expiry should never expire")
+	}
+	if _, ok, _ := store.loadAnswer(ctx, "missing"); ok {
+		t.Error("missing answer reported as found")
+	}
+	if deleted, _ := store.deleteAnswer(ctx, "abc"); deleted {
+		t.Error("delete of no rows reported as deleted")
+	}
+	if n, _ := store.deleteExpired(ctx); n != 2 {
+		t.Errorf("deleteExpired = %d, want 2", n)
+	}
+	if err := store.saveAnswer(ctx, StoredAnswer{ID: "x", Payload: []byte("{")}); err == nil {
+		t.Error("invalid payload should be rejected")
+	}
+}
+
+func TestAnswersUnavailable(t *testing.T) {
+	a := &ProphetAgent{}
+	a.initOnce.Do(func() {}) // initialized without the answers toolset
+	if _, _, err := a.LoadAnswer(context.Background(), "abc"); !errors.Is(err, ErrAnswersUnavailable) {
+		t.Errorf("err = %v, want ErrAnswersUnavailable", err)
+	}
+}
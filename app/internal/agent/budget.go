@@ -0,0 +1,79 @@
+// Package agent defines the deadline budgets for an agent run.
+// A session gets one overall deadline; each phase (orchestrator, section,
+// summary) and each search agent derive tighter deadlines from it via context,
+// so nothing waits on the HTTP clients' much longer transport timeouts.
+package agent
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+)
+
+// Budget holds the deadlines for one question.
+type Budget struct {
+	Session      time.Duration // whole run, orchestrators through summary
+	Orchestrator time.Duration // each keyword orchestrator call
+	Section      time.Duration // presidents, leaders or scriptures fan-out
+	Agent        time.Duration // one search agent (tool call + format)
+	Summary      time.Duration // summary generation, reserved out of Session
+}
+
+// DefaultBudget returns the budgets used when none are configured.
+func DefaultBudget() Budget {
+	return Budget{
+		Session:      150 * time.Second,
+		Orchestrator: 30 * time.Second,
+		Section:      60 * time.Second,
+		Agent:        45 * time.Second,
+		Summary:      30 * time.Second,
+	}
+}
+
+// BudgetFromEnv overrides the defaults with AGENT_BUDGET_* durations.
+func BudgetFromEnv() Budget {
+	b := DefaultBudget()
+	b.Session = envDuration("AGENT_BUDGET_SESSION", b.Session)
+	b.Orchestrator = envDuration("AGENT_BUDGET_ORCHESTRATOR", b.Orchestrator)
+	b.Section = envDuration("AGENT_BUDGET_SECTION", b.Section)
+	b.Agent = envDuration("AGENT_BUDGET_AGENT", b.Agent)
+	b.Summary = envDuration("AGENT_BUDGET_SUMMARY", b.Summary)
+	return b
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Printf("Invalid %s=%q, using %v", key, v, fallback)
+		return fallback
+	}
+	return d
+}
+
+// withBudget bounds ctx by d. A zero budget only adds cancellation.
+func withBudget(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// withReserve ends ctx early enough to leave reserve before its deadline,
+// so the sections can't consume the time set aside for the summary.
+func withReserve(ctx context.Context, reserve time.Duration) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok || reserve <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, deadline.Add(-reserve))
+}
+
+// WithSessionBudget bounds a whole question, including its summary.
+func (a *ProphetAgent) WithSessionBudget(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withBudget(ctx, a.budget.Session)
+}
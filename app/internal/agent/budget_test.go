@@ -0,0 +1,113 @@
+// Package agent tests the deadline budgets.
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestBudgetFromEnv verifies valid overrides apply and invalid, zero or
+// negative values fall back to the defaults
+func TestBudgetFromEnv(t *testing.T) {
+	def := DefaultBudget()
+	t.Setenv("AGENT_BUDGET_SESSION", "2m")
+	t.Setenv("AGENT_BUDGET_ORCHESTRATOR", "0")
+	t.Setenv("AGENT_BUDGET_SECTION", "soon")
+	t.Setenv("AGENT_BUDGET_AGENT", "-5s")
+	t.Setenv("AGENT_BUDGET_SUMMARY", "")
+
+	got := BudgetFromEnv()
+	want := Budget{
+		Session:      2 * time.Minute,
+		Orchestrator: def.Orchestrator,
+		Section:      def.Section,
+		Agent:        def.Agent,
+		Summary:      def.Summary,
+	}
+	if got != want {
+		t.Errorf("BudgetFromEnv = %+v, want %+v", got, want)
+	}
+}
+
+// TestWithReserveSubtractsFromDeadline verifies the summary reserve ends the
+// sections' context that much before the session deadline
+func TestWithReserveSubtractsFromDeadline(t *testing.T) {
+	session, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	sessionDeadline, _ := session.Deadline()
+
+	sections, cancelSections := withReserve(session, 20*time.Second)
+	defer cancelSections()
+	deadline, ok := sections.Deadline()
+	if !ok || !deadline.Equal(sessionDeadline.Add(-20*time.Second)) {
+		t.Errorf("Sections deadline = %v, want %v", deadline, sessionDeadline.Add(-20*time.Second))
+	}
+
+	// A reserve longer than the time left ends the sections right away
+	short, cancelShort := withReserve(session, 2*time.Minute)
+	defer cancelShort()
+	select {
+	case <-short.Done():
+	case <-time.After(time.Second):
+		t.Error("Expected a reserve past the deadline to end the context")
+	}
+
+	// No session deadline or no reserve only adds cancellation
+	for _, tc := range []struct {
+		ctx     context.Context
+		reserve time.Duration
+	}{
+		{context.Background(), 20 * time.Second},
+		{session, 0},
+	} {
+		ctx, cancel := withReserve(tc.ctx, tc.reserve)
+		parent, parentOK := tc.ctx.Deadline()
+		got, gotOK := ctx.Deadline()
+		if gotOK != parentOK || !got.Equal(parent) {
+			t.Errorf("withReserve(%v) deadline = %v, %v; want the parent's", tc.reserve, got, gotOK)
+		}
+		cancel()
+		if ctx.Err() == nil {
+			t.Error("Expected the returned cancel to end the context")
+		}
+	}
+}
+
+// TestWithBudgetNeverExtendsParent verifies nested budgets only tighten the
+// deadline they inherit
+func TestWithBudgetNeverExtendsParent(t *testing.T) {
+	parent, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	parentDeadline, _ := parent.Deadline()
+
+	longer, cancelLonger := withBudget(parent, time.Hour)
+	defer cancelLonger()
+	if got, _ := longer.Deadline(); !got.Equal(parentDeadline) {
+		t.Errorf("Longer budget deadline = %v, want the parent's %v", got, parentDeadline)
+	}
+
+	shorter, cancelShorter := withBudget(parent, time.Second)
+	defer cancelShorter()
+	if got, _ := shorter.Deadline(); !got.Before(parentDeadline) {
+		t.Errorf("Shorter budget deadline = %v, want before %v", got, parentDeadline)
+	}
+
+	nested, cancelNested := withBudget(shorter, time.Minute)
+	defer cancelNested()
+	shorterDeadline, _ := shorter.Deadline()
+	if got, _ := nested.Deadline(); !got.Equal(shorterDeadline) {
+		t.Errorf("Nested budget deadline = %v, want %v", got, shorterDeadline)
+	}
+
+	zero, cancelZero := withBudget(parent, 0)
+	defer cancelZero()
+	if got, _ := zero.Deadline(); !got.Equal(parentDeadline) {
+		t.Errorf("Zero budget deadline = %v, want the parent's %v", got, parentDeadline)
+	}
+
+	cancel()
+	if nested.Err() == nil || zero.Err() == nil {
+		t.Error("Expected cancelling the parent to end nested budgets")
+	}
+}
@@ -0,0 +1,228 @@
+// Package agent answers follow-up questions in the context of a
+// conversation: the orchestrators and the summary see what the visitor
+// asked before, and the search agents don't show a quote or verse again.
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// maxConversationTurns bounds how much of a conversation a follow-up carries.
+const maxConversationTurns = 3
+
+// Turn is one answered question and what it showed the visitor.
+type Turn struct {
+	Question   string
+	Quotes     []StructuredQuote
+	Scriptures []StructuredScripture
+}
+
+// Conversation is the earlier turns of a visitor's session, oldest first.
+// It is immutable; With returns a new one.
+type Conversation struct {
+	turns []Turn
+}
+
+// With returns the conversation followed by turn, keeping the latest
+// maxConversationTurns turns. c may be nil.
+func (c *Conversation) With(turn Turn) *Conversation {
+	var turns []Turn
+	if c != nil {
+		turns = append(turns, c.turns...)
+	}
+	turns = append(turns, turn)
+	if len(turns) > maxConversationTurns {
+		turns = turns[len(turns)-maxConversationTurns:]
+	}
+	return &Conversation{turns: turns}
+}
+
+// Turns returns the conversation's turns, oldest first.
+func (c *Conversation) Turns() []Turn {
+	if c == nil {
+		return nil
+	}
+	return c.turns
+}
+
+// Questions returns the questions asked so far, oldest first.
+func (c *Conversation) Questions() []string {
+	var questions []string
+	for _, t := range c.Turns() {
+		questions = append(questions, t.Question)
+	}
+	return questions
+}
+
+type conversationKey struct{}
+
+// WithConversation makes Run and GenerateSummary with ctx answer a
+// follow-up to c. A nil or empty c leaves ctx as it is.
+func WithConversation(ctx context.Context, c *Conversation) context.Context {
+	if len(c.Turns()) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, conversationKey{}, c)
+}
+
+func conversationFrom(ctx context.Context) *Conversation {
+	c, _ := ctx.Value(conversationKey{}).(*Conversation)
+	return c
+}
+
+// orchestratorInput is the orchestrators' user message for a follow-up:
+// the earlier questions and what they showed, then the question itself.
+func (c *Conversation) orchestratorInput(question string) string {
+	var b strings.Builder
+	b.WriteString("Earlier in this conversation:\n")
+	for _, t := range c.turns {
+		fmt.Fprintf(&b, "\nVisitor asked: %s\n", t.Question)
+		for _, q := range t.Quotes {
+			fmt.Fprintf(&b, "- Shown: %s, %q: %s\n", q.Speaker, q.Title, excerpt(q.Quote))
+		}
+		for _, s := range t.Scriptures {
+			fmt.Fprintf(&b, "- Shown: %s\n", s.Reference)
+		}
+	}
+	fmt.Fprintf(&b, "\nFollow-up question: %s", question)
+	return b.String()
+}
+
+// fallbackInput is what fallback keywords are extracted from for a
+// follow-up, whose own words ("how do I develop that?") may carry no topic.
+func (c *Conversation) fallbackInput(question string) string {
+	return c.turns[len(c.turns)-1].Question + " " + question
+}
+
+const followUpOrchestratorNote = `
+
+## FOLLOW-UP QUESTIONS
+The visitor may be following up on earlier questions, listed before the follow-up. Read the follow-up in light of them (resolve words like "that" or "it"), judge its safety in that light, and generate keywords for what the follow-up asks rather than for ground the shown quotes already cover.`
+
+const followUpSummaryNote = `
+
+This is a follow-up: earlier_questions lists what the visitor asked before. Answer the follow-up question itself, building on the earlier ones without repeating them.`
+
+// excerpt shortens text for the orchestrators' context.
+func excerpt(text string) string {
+	const maxRunes = 160
+	runes := []rune(strings.TrimSpace(text))
+	if len(runes) <= maxRunes {
+		return string(runes)
+	}
+	return string(runes[:maxRunes]) + "..."
+}
+
+// shownSet holds the quotes and verses a conversation already showed. A
+// passage matches by its card key or, when known, by its source, since a
+// formatter may cut the same paragraph a little differently.
+type shownSet struct {
+	keys  map[string]bool
+	lines []string // for the formatters, one per shown passage
+}
+
+// shown returns the passages shown in the conversation, or nil if none.
+func (c *Conversation) shown() *shownSet {
+	s := &shownSet{keys: map[string]bool{}}
+	for _, t := range c.Turns() {
+		for _, q := range t.Quotes {
+			for _, key := range quoteKeys(q) {
+				s.keys[key] = true
+			}
+			s.lines = append(s.lines, fmt.Sprintf("%s, %q: %s", q.Speaker, q.Title, excerpt(q.Quote)))
+		}
+		for _, sc := range t.Scriptures {
+			for _, key := range scriptureKeys(sc) {
+				s.keys[key] = true
+			}
+			s.lines = append(s.lines, sc.Reference)
+		}
+	}
+	if len(s.keys) == 0 {
+		return nil
+	}
+	return s
+}
+
+// QuoteKey identifies a quote card by its speaker, talk and text.
+func QuoteKey(q StructuredQuote) string {
+	return fmt.Sprintf("%s|%s|%s|%s", q.Speaker, q.Title, q.Conference, q.Quote)
+}
+
+// ScriptureKey identifies a scripture card by its volume, reference and text.
+func ScriptureKey(s StructuredScripture) string {
+	return fmt.Sprintf("%s|%s|%s", s.Volume, s.Reference, s.Text)
+}
+
+func quoteKeys(q StructuredQuote) []string {
+	keys := []string{QuoteKey(q)}
+	if q.TalkID != "" && q.Paragraph != "" {
+		keys = append(keys, "talk:"+q.TalkID+"#"+q.Paragraph)
+	}
+	return keys
+}
+
+func scriptureKeys(s StructuredScripture) []string {
+	keys := []string{ScriptureKey(s)}
+	if s.VerseID != "" {
+		keys = append(keys, "verse:"+s.VerseID)
+	}
+	return keys
+}
+
+func (s *shownSet) hasAny(keys []string) bool {
+	for _, key := range keys {
+		if s.keys[key] {
+			return true
+		}
+	}
+	return false
+}
+
+// formatNote asks a formatter to pick passages other than the shown ones.
+func (s *shownSet) formatNote() string {
+	return "\n\nAlready shown to the visitor; select different passages:\n- " + strings.Join(s.lines, "\n- ")
+}
+
+// filter drops shown passages from a search agent's content or streamed
+// card, and reports whether any passage is left. Content that isn't a
+// quotes or scriptures object is returned as is.
+func (s *shownSet) filter(content string) (string, bool) {
+	var resp struct {
+		Quotes     []StructuredQuote     `json:"quotes"`
+		Scriptures []StructuredScripture `json:"scriptures"`
+	}
+	if err := json.Unmarshal([]byte(content), &resp); err != nil {
+		return content, true
+	}
+	before := len(resp.Quotes) + len(resp.Scriptures)
+	if before == 0 {
+		return content, true
+	}
+	quotes := []StructuredQuote{}
+	for _, q := range resp.Quotes {
+		if !s.hasAny(quoteKeys(q)) {
+			quotes = append(quotes, q)
+		}
+	}
+	scriptures := []StructuredScripture{}
+	for _, sc := range resp.Scriptures {
+		if !s.hasAny(scriptureKeys(sc)) {
+			scriptures = append(scriptures, sc)
+		}
+	}
+	left := len(quotes) + len(scriptures)
+	if left == before {
+		return content, true
+	}
+	var out []byte
+	if resp.Scriptures != nil {
+		out, _ = json.Marshal(ScripturesResponse{Scriptures: scriptures})
+	} else {
+		out, _ = json.Marshal(LeadersResponse{Quotes: quotes})
+	}
+	return string(out), left > 0
+}
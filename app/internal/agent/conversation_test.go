@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestConversationKeepsLatestTurns(t *testing.T) {
+	var c *Conversation
+	for _, q := range []string{"one", "two", "three", "four"} {
+		c = c.With(Turn{Question: q})
+	}
+	if got := strings.Join(c.Questions(), ","); got != "two,three,four" {
+		t.Errorf("questions = %s", got)
+	}
+
+	// With doesn't change the conversation it extends
+	longer := c.With(Turn{Question: "five"})
+	if len(c.Turns()) != 3 || c.Turns()[2].Question != "four" || longer.Turns()[2].Question != "five" {
+		t.Errorf("turns = %v, %v", c.Questions(), longer.Questions())
+	}
+}
+
+func TestWithConversation(t *testing.T) {
+	ctx := context.Background()
+	if conversationFrom(WithConversation(ctx, nil)) != nil || conversationFrom(WithConversation(ctx, &Conversation{})) != nil {
+		t.Error("an empty conversation should leave ctx as it is")
+	}
+	c := (*Conversation)(nil).With(Turn{Question: "What is faith?"})
+	if conversationFrom(WithConversation(ctx, c)) != c {
+		t.Error("conversation not carried by ctx")
+	}
+	if conversationFrom(ctx).shown() != nil {
+		t.Error("no conversation should show nothing")
+	}
+}
+
+func TestOrchestratorInput(t *testing.T) {
+	c := (*Conversation)(nil).With(Turn{
+		Question:   "What is faith?",
+		Quotes:     []StructuredQuote{{Speaker: "Dallin H. Oaks", Title: "Faith", Quote: strings.Repeat("word ", 100)}},
+		Scriptures: []StructuredScripture{{Reference: "Hebrews 11:1"}},
+	})
+	input := c.orchestratorInput("How do I develop that?")
+	for _, want := range []string{"Visitor asked: What is faith?", `Dallin H. Oaks, "Faith"`, "Hebrews 11:1", "Follow-up question: How do I develop that?"} {
+		if !strings.Contains(input, want) {
+			t.Errorf("input missing %q:\n%s", want, input)
+		}
+	}
+	if strings.Count(input, "word") > 40 {
+		t.Error("shown quotes should be excerpted")
+	}
+	if got := c.fallbackInput("How do I develop that?"); got != "What is faith? How do I develop that?" {
+		t.Errorf("fallback input = %q", got)
+	}
+}
+
+func TestShownSetFilter(t *testing.T) {
+	shownQuote := StructuredQuote{Speaker: "A", Title: "T", Conference: "C", Quote: "Q", TalkID: "t1", Paragraph: "p4"}
+	c := (*Conversation)(nil).With(Turn{
+		Quotes:     []StructuredQuote{shownQuote},
+		Scriptures: []StructuredScripture{{Volume: "Bible", Reference: "John 3:16", Text: "For God", VerseID: "v1"}},
+	})
+	shown := c.shown()
+
+	// The same paragraph cut differently still matches by its source
+	recut := shownQuote
+	recut.Quote = "Q, a little longer"
+	content, _ := json.Marshal(LeadersResponse{Quotes: []StructuredQuote{recut, {Speaker: "B", Quote: "new"}}})
+	got, left := shown.filter(string(content))
+	var quotes LeadersResponse
+	json.Unmarshal([]byte(got), &quotes)
+	if !left || len(quotes.Quotes) != 1 || quotes.Quotes[0].Speaker != "B" {
+		t.Errorf("filter(quotes) = %s, %v", got, left)
+	}
+
+	got, left = shown.filter(`{"scriptures":[{"volume":"Bible","reference":"John 3:16","text":"For God"}]}`)
+	if left || got != `{"scriptures":[]}` {
+		t.Errorf("filter(shown card) = %s, %v", got, left)
+	}
+
+	for _, content := range []string{`{"quotes":[{"speaker":"B","quote":"new"}]}`, `not json`, `{"quotes":[]}`} {
+		if got, left := shown.filter(content); got != content || !left {
+			t.Errorf("filter(%s) = %s, %v; want it unchanged", content, got, left)
+		}
+	}
+	if note := shown.formatNote(); !strings.Contains(note, `A, "T": Q`) || !strings.Contains(note, "John 3:16") {
+		t.Errorf("format note = %q", note)
+	}
+}
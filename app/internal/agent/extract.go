@@ -0,0 +1,501 @@
+// Package agent implements deterministic formatting for the search agents.
+// Instead of asking Gemini to pick a quote from the tool rows, the extractor
+// splits each talk into sentence windows, scores them against the keywords
+// with BM25 and emits the best one directly. Rerank mode keeps the cheap
+// candidate generation but lets a small Gemini call choose among the top few.
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// FormatMode selects how a search agent turns tool rows into cards.
+type FormatMode string
+
+const (
+	FormatLLM           FormatMode = "llm"           // Gemini formats the rows (default)
+	FormatDeterministic FormatMode = "deterministic" // BM25 picks passages, no LLM call
+	FormatRerank        FormatMode = "rerank"        // BM25 candidates, Gemini picks
+)
+
+// ParseFormatMode parses a FORMAT_MODE value. Empty means llm.
+func ParseFormatMode(s string) (FormatMode, error) {
+	switch FormatMode(strings.ToLower(strings.TrimSpace(s))) {
+	case "", FormatLLM:
+		return FormatLLM, nil
+	case FormatDeterministic:
+		return FormatDeterministic, nil
+	case FormatRerank:
+		return FormatRerank, nil
+	}
+	return "", fmt.Errorf("unknown format mode %q (want llm, deterministic or rerank)", s)
+}
+
+// formatModesFromEnv reads FORMAT_MODE (the default for every search agent)
+// and FORMAT_MODE_<AGENT> overrides, e.g. FORMAT_MODE_PRESIDENTS_CURRENT.
+func formatModesFromEnv() (FormatMode, map[string]FormatMode) {
+	def, err := ParseFormatMode(os.Getenv("FORMAT_MODE"))
+	if err != nil {
+		log.Printf("%v, using %s", err, FormatLLM)
+		def = FormatLLM
+	}
+	overrides := map[string]FormatMode{}
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		name, ok := strings.CutPrefix(key, "FORMAT_MODE_")
+		if !ok {
+			continue
+		}
+		mode, err := ParseFormatMode(value)
+		if err != nil {
+			log.Printf("%v for %s, using %s", err, key, def)
+			continue
+		}
+		overrides[strings.ToLower(name)] = mode
+	}
+	return def, overrides
+}
+
+// extractFormat formats a tool result without the LLM format pass (or, in
+// rerank mode, with a much smaller call). No usable passage yields empty
+// content, which the section treats as no results.
+func (a *ProphetAgent) extractFormat(ctx context.Context, name, keywords string, result any, mode FormatMode) (string, error) {
+	ranked, err := extractCards(name, result, keywords)
+	if err != nil {
+		return "", err
+	}
+	if len(ranked) == 0 {
+		return "", nil
+	}
+	n := cardCount(name)
+	var picked []passage
+	if mode == FormatRerank {
+		picked = a.rerank(ctx, name, keywords, ranked, n)
+	} else {
+		picked = pickCards(ranked, n)
+	}
+	return cardsContent(picked)
+}
+
+// formatModeFor returns the format mode of the named search agent.
+func (a *ProphetAgent) formatModeFor(name string) FormatMode {
+	if mode, ok := a.formatModes[name]; ok {
+		return mode
+	}
+	return a.formatMode
+}
+
+// Passage limits, matching quotesSchema and the prompts' 4-8 sentences.
+const (
+	minQuoteChars     = 120
+	maxQuoteChars     = 2000
+	minQuoteSentences = 4
+	maxQuoteSentences = 8
+
+	minScriptureChars = 60
+
+	// rerankCandidates is how many passages rerank mode shows Gemini.
+	rerankCandidates = 5
+)
+
+// BM25 parameters.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// talkRow is one row of the talk search tools.
+type talkRow struct {
+	Speaker    string `json:"speaker"`
+	Title      string `json:"title"`
+	Conference string `json:"conference"`
+	Content    string `json:"content"`
+	Headshot   string `json:"headshot"`
+}
+
+// verseRow is one row of search_scriptures.
+type verseRow struct {
+	Volume  string `json:"volume"`
+	Book    string `json:"book_name"`
+	Chapter any    `json:"chapter_number"`
+	Verse   any    `json:"verse_number"`
+	Text    string `json:"verse_text"`
+}
+
+// decodeRows decodes a tool result, which Toolbox returns either as a JSON
+// string or as already-decoded rows.
+func decodeRows(result any, out any) error {
+	data, ok := result.(string)
+	if !ok {
+		raw, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		data = string(raw)
+	}
+	data = strings.TrimSpace(data)
+	if data == "" || data == "null" {
+		return nil
+	}
+	return json.Unmarshal([]byte(data), out)
+}
+
+// passage is a candidate card: a quote from a talk or a single verse.
+type passage struct {
+	quote     *StructuredQuote
+	scripture *StructuredScripture
+	terms     []string
+	score     float64
+}
+
+func (p passage) text() string {
+	if p.quote != nil {
+		return p.quote.Quote
+	}
+	return p.scripture.Text
+}
+
+// extractCards splits a tool result into candidate cards ranked by BM25
+// against the keywords.
+func extractCards(name string, result any, keywords string) ([]passage, error) {
+	var candidates []passage
+	if strings.HasPrefix(name, "scriptures_") {
+		var rows []verseRow
+		if err := decodeRows(result, &rows); err != nil {
+			return nil, fmt.Errorf("failed to decode verses: %w", err)
+		}
+		candidates = versePassages(rows)
+	} else {
+		var rows []talkRow
+		if err := decodeRows(result, &rows); err != nil {
+			return nil, fmt.Errorf("failed to decode talks: %w", err)
+		}
+		candidates = talkPassages(rows)
+	}
+	scoreBM25(candidates, queryTerms(keywords))
+	// Stable, so equal scores keep the tool's order (most relevant or recent first)
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+	return candidates, nil
+}
+
+// cardCount is how many cards an agent's prompt asks for.
+func cardCount(name string) int {
+	if strings.HasPrefix(name, "scriptures_") {
+		return 2
+	}
+	return 1
+}
+
+// pickCards takes the first n ranked candidates, one quote per talk and, for
+// scriptures, different volumes first (the prompts ask for OT + NT, D&C + PGP).
+func pickCards(ranked []passage, n int) []passage {
+	var picked []passage
+	used := map[string]bool{}
+	for pass := 0; pass < 2 && len(picked) < n; pass++ {
+		for _, p := range ranked {
+			if len(picked) == n {
+				break
+			}
+			key := groupKey(p)
+			if used[key] || (pass == 0 && usedVolume(picked, p)) {
+				continue
+			}
+			used[key] = true
+			picked = append(picked, p)
+		}
+	}
+	return picked
+}
+
+func groupKey(p passage) string {
+	if p.quote != nil {
+		return p.quote.Speaker + "|" + p.quote.Title
+	}
+	return p.scripture.Reference
+}
+
+func usedVolume(picked []passage, p passage) bool {
+	if p.scripture == nil {
+		return false
+	}
+	for _, q := range picked {
+		if q.scripture != nil && q.scripture.Volume == p.scripture.Volume {
+			return true
+		}
+	}
+	return false
+}
+
+// cardsContent renders picked passages in the formatter's JSON shape.
+func cardsContent(picked []passage) (string, error) {
+	var data []byte
+	var err error
+	if len(picked) > 0 && picked[0].scripture != nil {
+		resp := ScripturesResponse{Scriptures: []StructuredScripture{}}
+		for _, p := range picked {
+			resp.Scriptures = append(resp.Scriptures, *p.scripture)
+		}
+		data, err = json.Marshal(resp)
+	} else {
+		resp := PresidentsResponse{Quotes: []StructuredQuote{}}
+		for _, p := range picked {
+			resp.Quotes = append(resp.Quotes, *p.quote)
+		}
+		data, err = json.Marshal(resp)
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// talkPassages builds every window of 4-8 consecutive sentences that fits
+// the quote length limits.
+func talkPassages(rows []talkRow) []passage {
+	var out []passage
+	for _, row := range rows {
+		sentences := splitSentences(row.Content)
+		for start := range sentences {
+			var b strings.Builder
+			for end := start; end < len(sentences) && end-start < maxQuoteSentences; end++ {
+				if b.Len() > 0 {
+					b.WriteByte(' ')
+				}
+				b.WriteString(sentences[end])
+				if b.Len() > maxQuoteChars {
+					break
+				}
+				if end-start+1 < minQuoteSentences || b.Len() < minQuoteChars {
+					continue
+				}
+				text := b.String()
+				out = append(out, passage{
+					quote: &StructuredQuote{
+						Speaker:    row.Speaker,
+						Title:      row.Title,
+						Conference: row.Conference,
+						Quote:      text,
+						Headshot:   row.Headshot,
+					},
+					terms: passageTerms(text),
+				})
+				break // shortest qualifying window per start sentence
+			}
+		}
+	}
+	return out
+}
+
+// versePassages makes one candidate per verse, preferring verses long
+// enough for a card.
+func versePassages(rows []verseRow) []passage {
+	var out []passage
+	for _, row := range rows {
+		text := strings.TrimSpace(row.Text)
+		if len(text) < minScriptureChars {
+			continue
+		}
+		out = append(out, passage{
+			scripture: &StructuredScripture{
+				Volume:    row.Volume,
+				Reference: fmt.Sprintf("%s %v:%v", row.Book, row.Chapter, row.Verse),
+				Text:      text,
+			},
+			terms: passageTerms(text),
+		})
+	}
+	return out
+}
+
+// splitSentences splits text at sentence-ending punctuation followed by
+// whitespace. A trailing fragment (the tools truncate content at 2000
+// characters) is dropped.
+func splitSentences(text string) []string {
+	var sentences []string
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	start := 0
+	for i, r := range runes {
+		if r != '.' && r != '!' && r != '?' {
+			continue
+		}
+		end := i + 1
+		// Keep closing quotes and brackets with their sentence
+		for end < len(runes) && strings.ContainsRune("\"'”’)]", runes[end]) {
+			end++
+		}
+		if end < len(runes) && !unicode.IsSpace(runes[end]) {
+			continue
+		}
+		if s := strings.TrimSpace(string(runes[start:end])); s != "" {
+			sentences = append(sentences, s)
+		}
+		start = end
+	}
+	return sentences
+}
+
+// queryTerms are the stemmed, de-duplicated keywords.
+func queryTerms(keywords string) []string {
+	var terms []string
+	seen := map[string]bool{}
+	for _, t := range passageTerms(keywords) {
+		if !seen[t] {
+			seen[t] = true
+			terms = append(terms, t)
+		}
+	}
+	return terms
+}
+
+// passageTerms tokenizes text the same way as the keyword extractor.
+func passageTerms(text string) []string {
+	var terms []string
+	for _, word := range tokenize(text) {
+		if stopwords[word] || len(word) < 3 {
+			continue
+		}
+		terms = append(terms, stemWord(word))
+	}
+	return terms
+}
+
+// scoreBM25 scores each passage against the query, treating the candidate
+// passages as the corpus.
+func scoreBM25(passages []passage, query []string) {
+	if len(passages) == 0 || len(query) == 0 {
+		return
+	}
+	df := map[string]int{}
+	totalLen := 0
+	for _, p := range passages {
+		totalLen += len(p.terms)
+		seen := map[string]bool{}
+		for _, t := range p.terms {
+			if !seen[t] {
+				seen[t] = true
+				df[t]++
+			}
+		}
+	}
+	n := float64(len(passages))
+	avgLen := float64(totalLen) / n
+	if avgLen == 0 {
+		return
+	}
+	for i := range passages {
+		tf := map[string]int{}
+		for _, t := range passages[i].terms {
+			tf[t]++
+		}
+		length := float64(len(passages[i].terms))
+		var score float64
+		for _, q := range query {
+			f := float64(tf[q])
+			if f == 0 {
+				continue
+			}
+			idf := math.Log(1 + (n-float64(df[q])+0.5)/(float64(df[q])+0.5))
+			score += idf * f * (bm25K1 + 1) / (f + bm25K1*(1-bm25B+bm25B*length/avgLen))
+		}
+		passages[i].score = score
+	}
+}
+
+// RerankResponse is the structured output of the rerank call.
+type RerankResponse struct {
+	Choices []int `json:"choices"`
+}
+
+var rerankSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"choices": map[string]any{
+			"type":  "array",
+			"items": map[string]any{"type": "integer"},
+		},
+	},
+	"required": []string{"choices"},
+}
+
+const rerankPrompt = `You are a passage selector. Given search keywords and numbered candidate passages, choose the %d passage(s) that best address the keywords and read well on their own.
+Return ONLY valid JSON: {"choices":[passage numbers, best first]}`
+
+// rerank asks Gemini to choose n of the top candidates. It falls back to
+// the BM25 order if the call fails or returns nothing usable.
+func (a *ProphetAgent) rerank(ctx context.Context, name, keywords string, ranked []passage, n int) []passage {
+	candidates := ranked
+	if len(candidates) > rerankCandidates {
+		candidates = candidates[:rerankCandidates]
+	}
+	if len(candidates) <= n {
+		return pickCards(ranked, n)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Keywords: %s\n", keywords)
+	for i, p := range candidates {
+		if p.quote != nil {
+			fmt.Fprintf(&b, "\n[%d] %s, %q (%s):\n%s\n", i+1, p.quote.Speaker, p.quote.Title, p.quote.Conference, p.text())
+		} else {
+			fmt.Fprintf(&b, "\n[%d] %s (%s):\n%s\n", i+1, p.scripture.Reference, p.scripture.Volume, p.text())
+		}
+	}
+
+	temp := float32(0.2)
+	req := &GenerateRequest{
+		Contents: []*Content{{
+			Parts: []*Part{{Text: b.String()}},
+			Role:  "user",
+		}},
+		SystemInstruct: &Content{
+			Parts: []*Part{{Text: fmt.Sprintf(rerankPrompt, n)}},
+			Role:  "system",
+		},
+		GenerationConfig: &GenerationConfig{
+			Temperature:        &temp,
+			MaxOutputTokens:    256,
+			ResponseMIMEType:   "application/json",
+			ResponseJSONSchema: rerankSchema,
+			ThinkingConfig:     &ThinkingConfig{ThinkingLevel: "minimal"},
+		},
+		SafetySettings: DefaultSafetySettings(),
+	}
+	resp, err := a.client.GenerateContent(ctx, req)
+	if err != nil {
+		log.Printf("[%s] Rerank failed, keeping BM25 order: %v", name, err)
+		return pickCards(ranked, n)
+	}
+	var out RerankResponse
+	if err := json.Unmarshal([]byte(resp.ExtractText()), &out); err != nil {
+		log.Printf("[%s] Rerank returned invalid JSON, keeping BM25 order: %v", name, err)
+		return pickCards(ranked, n)
+	}
+
+	// Chosen candidates first, then the rest in BM25 order as backfill
+	var reordered []passage
+	chosen := map[int]bool{}
+	for _, c := range out.Choices {
+		if c >= 1 && c <= len(candidates) && !chosen[c-1] {
+			chosen[c-1] = true
+			reordered = append(reordered, candidates[c-1])
+		}
+	}
+	if len(reordered) == 0 {
+		log.Printf("[%s] Rerank chose nothing usable, keeping BM25 order", name)
+		return pickCards(ranked, n)
+	}
+	for i, p := range ranked {
+		if !chosen[i] {
+			reordered = append(reordered, p)
+		}
+	}
+	return pickCards(reordered, n)
+}
@@ -0,0 +1,76 @@
+// Package agent tests the deterministic quote extractor.
+package agent
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestSplitSentences verifies sentence splitting keeps closing quotes and drops truncated tails
+func TestSplitSentences(t *testing.T) {
+	got := splitSentences(`He said, "Pray always." Then he left. Dr. Smith stayed? The talk was cut off mid`)
+	want := []string{`He said, "Pray always."`, "Then he left.", "Dr.", "Smith stayed?"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("splitSentences = %q, want %q", got, want)
+	}
+}
+
+// TestExtractCardsRanksRelevantPassage verifies BM25 picks the passage about the keywords
+func TestExtractCardsRanksRelevantPassage(t *testing.T) {
+	filler := "We met in the chapel that morning. The weather was cold and clear. Many members came early. The choir sang two hymns. "
+	relevant := "Prayer opens the windows of heaven. When we pray with real intent, answers come. Sincere prayer invites the Spirit. Pray always, and you will find peace. "
+	rows, _ := json.Marshal([]talkRow{
+		{Speaker: "Dallin H. Oaks", Title: "Gathering", Conference: "April 2024", Content: filler + filler},
+		{Speaker: "Russell M. Nelson", Title: "Prayer", Conference: "October 2023", Content: filler + relevant},
+	})
+
+	ranked, err := extractCards("presidents_general", string(rows), "prayer answers")
+	if err != nil {
+		t.Fatalf("extractCards failed: %v", err)
+	}
+	picked := pickCards(ranked, cardCount("presidents_general"))
+	if len(picked) != 1 {
+		t.Fatalf("expected 1 card, got %d", len(picked))
+	}
+	q := picked[0].quote
+	if q.Title != "Prayer" || !strings.Contains(q.Quote, "answers come") {
+		t.Errorf("expected the prayer passage, got %q from %q", q.Quote, q.Title)
+	}
+	if n := len(splitSentences(q.Quote)); n < minQuoteSentences || n > maxQuoteSentences || len(q.Quote) < minQuoteChars {
+		t.Errorf("quote outside card limits: %d sentences, %d chars", n, len(q.Quote))
+	}
+
+	content, err := cardsContent(picked)
+	if err != nil {
+		t.Fatalf("cardsContent failed: %v", err)
+	}
+	var resp PresidentsResponse
+	if err := json.Unmarshal([]byte(content), &resp); err != nil || len(resp.Quotes) != 1 {
+		t.Errorf("content does not parse as a formatter response: %s", content)
+	}
+}
+
+// TestPickCardsPrefersDistinctVolumes verifies scripture cards cover different volumes first
+func TestPickCardsPrefersDistinctVolumes(t *testing.T) {
+	verse := strings.Repeat("Trust in the Lord with all thine heart and lean not. ", 2)
+	rows := []any{
+		map[string]any{"volume": "Old Testament", "book_name": "Proverbs", "chapter_number": 3, "verse_number": 5, "verse_text": verse + "trust"},
+		map[string]any{"volume": "Old Testament", "book_name": "Psalms", "chapter_number": 37, "verse_number": 5, "verse_text": verse},
+		map[string]any{"volume": "New Testament", "book_name": "Hebrews", "chapter_number": 11, "verse_number": 1, "verse_text": verse},
+	}
+	ranked, err := extractCards("scriptures_bible", rows, "trust")
+	if err != nil {
+		t.Fatalf("extractCards failed: %v", err)
+	}
+	picked := pickCards(ranked, cardCount("scriptures_bible"))
+	if len(picked) != 2 {
+		t.Fatalf("expected 2 cards, got %d", len(picked))
+	}
+	if picked[0].scripture.Volume == picked[1].scripture.Volume {
+		t.Errorf("expected distinct volumes, got %q and %q", picked[0].scripture.Reference, picked[1].scripture.Reference)
+	}
+	if picked[0].scripture.Reference != "Proverbs 3:5" {
+		t.Errorf("expected Proverbs 3:5 first, got %q", picked[0].scripture.Reference)
+	}
+}
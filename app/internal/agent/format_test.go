@@ -0,0 +1,101 @@
+// Package agent tests the streamed formatter against stub Toolbox and Gemini services.
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// stubServices serves the toolsets' manifests, answers every tool with rows,
+// and streams each Gemini format call the next of attempts as one chunk
+// with its finish reason.
+type stubServices struct {
+	rows     string
+	attempts []stubAttempt
+
+	mu    sync.Mutex
+	calls int
+}
+
+// stubAttempt is one streamed format response.
+type stubAttempt struct {
+	text, finishReason string
+}
+
+func (s *stubServices) RoundTrip(r *http.Request) (*http.Response, error) {
+	w := httptest.NewRecorder()
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/api/toolset/"):
+		tool := map[string]any{"description": "stub", "parameters": []map[string]any{
+			{"name": "query", "type": "string"},
+			{"name": "speaker_slugs", "type": "array", "items": map[string]any{"name": "slug", "type": "string"}},
+			{"name": "limit", "type": "integer"},
+		}}
+		json.NewEncoder(w).Encode(map[string]any{"serverVersion": "stub", "tools": map[string]any{
+			"get_presidents_talks": tool,
+		}})
+	case strings.HasPrefix(r.URL.Path, "/api/tool/"):
+		json.NewEncoder(w).Encode(map[string]any{"result": s.rows})
+	case strings.Contains(r.URL.Path, ":streamGenerateContent"):
+		s.mu.Lock()
+		attempt := s.attempts[min(s.calls, len(s.attempts)-1)]
+		s.calls++
+		s.mu.Unlock()
+		chunk, _ := json.Marshal(GenerateResponse{Candidates: []*Candidate{{
+			Content:      &Content{Role: "model", Parts: []*Part{{Text: attempt.text}}},
+			FinishReason: attempt.finishReason,
+		}}})
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "data: %s\n\n", chunk)
+	default:
+		http.Error(w, "not stubbed", http.StatusNotFound)
+	}
+	resp := w.Result()
+	resp.Request = r
+	return resp, nil
+}
+
+// TestRetriedFormatDropsFailedCards verifies a presidents_general attempt
+// that ends in RECITATION doesn't stream its cards before the retry's
+func TestRetriedFormatDropsFailedCards(t *testing.T) {
+	quotes := func(quote string) string {
+		return `{"quotes": [{"speaker": "Dallin H. Oaks", "title": "T", "conference": "October 2024", "quote": "` + quote + `"}]}`
+	}
+	stub := &stubServices{
+		rows: `[{"talk_id": "t1", "speaker": "Dallin H. Oaks", "title": "T", "content": "Recited. Kept."}]`,
+		attempts: []stubAttempt{
+			{quotes("Recited."), "RECITATION"},
+			{quotes("Kept."), "STOP"},
+		},
+	}
+	a, err := New(context.Background(), Config{APIKey: "test-key", ToolboxURL: "http://toolbox.test", Transport: stub, SpeakersRefresh: -1, Budget: DefaultBudget()})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := a.ensureInitialized(context.Background()); err != nil {
+		t.Fatalf("ensureInitialized: %v", err)
+	}
+
+	var cards []string
+	content, err := a.runSearchAgent(context.Background(), "presidents_general", "faith",
+		"get_presidents_talks", map[string]any{"query": "faith", "limit": 3},
+		presidentsGeneralPrompt, quotesSchema, func(card string) { cards = append(cards, card) })
+	if err != nil {
+		t.Fatalf("runSearchAgent: %v", err)
+	}
+	if stub.calls != 2 {
+		t.Fatalf("Expected 2 format attempts, got %d", stub.calls)
+	}
+	if len(cards) != 1 || !strings.Contains(cards[0], "Kept.") {
+		t.Errorf("Cards = %v, want only the retry's", cards)
+	}
+	if strings.Contains(content, "Recited.") || !strings.Contains(content, "Kept.") {
+		t.Errorf("Content = %s", content)
+	}
+}
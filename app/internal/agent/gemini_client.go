@@ -16,6 +16,7 @@ import (
 	"net/http"
 	"net/http/httptrace"
 	"os"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -40,6 +41,7 @@ type GeminiClient struct {
 	dumped     uint32
 	dumpLog    bool
 	dumpAll    bool
+	scheduler  *llmScheduler
 }
 
 // NewGeminiClient creates a new Gemini REST client
@@ -54,6 +56,15 @@ func NewGeminiClient(apiKey string) (*GeminiClient, error) {
 	dumpPath := strings.TrimSpace(os.Getenv("GEMINI_DUMP_PATH"))
 	dumpLog := envBool("GEMINI_DUMP_LOG")
 	dumpAll := envBool("GEMINI_DUMP_ALL")
+	maxInFlight := DefaultMaxInFlight
+	if v := strings.TrimSpace(os.Getenv("GEMINI_MAX_IN_FLIGHT")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			log.Printf("[gemini] Invalid GEMINI_MAX_IN_FLIGHT=%q, using %d", v, DefaultMaxInFlight)
+		} else {
+			maxInFlight = n
+		}
+	}
 	transport := &http.Transport{
 		MaxIdleConns:        200,
 		MaxIdleConnsPerHost: 100,
@@ -67,12 +78,13 @@ func NewGeminiClient(apiKey string) (*GeminiClient, error) {
 			Timeout:   240 * time.Second, // 4 min to allow long format passes
 			Transport: transport,
 		},
-		apiKey:   apiKey,
-		model:    DefaultModel,
-		trace:    trace,
-		dumpPath: dumpPath,
-		dumpLog:  dumpLog,
-		dumpAll:  dumpAll,
+		apiKey:    apiKey,
+		model:     DefaultModel,
+		trace:     trace,
+		dumpPath:  dumpPath,
+		dumpLog:   dumpLog,
+		dumpAll:   dumpAll,
+		scheduler: newLLMScheduler(maxInFlight),
 	}, nil
 }
 
@@ -199,12 +211,38 @@ type requestTrace struct {
 	wroteReqErr  error
 }
 
-// GenerateContent makes a non-streaming request to the Gemini API
+// GenerateContent makes a non-streaming request to the Gemini API.
+// The call waits for a scheduler slot at the priority carried by ctx.
 func (c *GeminiClient) GenerateContent(ctx context.Context, req *GenerateRequest) (*GenerateResponse, error) {
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	url := fmt.Sprintf("%s/models/%s:generateContent", GeminiAPIEndpoint, c.model)
 	return c.doRequest(ctx, url, req)
 }
 
+// acquire waits for an in-flight slot, logging noticeable queue waits.
+func (c *GeminiClient) acquire(ctx context.Context) (func(), error) {
+	priority := priorityFrom(ctx)
+	start := time.Now()
+	release, err := c.scheduler.acquire(ctx, priority)
+	if err != nil {
+		return nil, fmt.Errorf("waiting for %s slot: %w", priority, err)
+	}
+	if wait := time.Since(start); wait > 100*time.Millisecond {
+		log.Printf("[gemini] Queued %v for %s slot", wait, priority)
+	}
+	return release, nil
+}
+
+// SchedulerStats returns in-flight counts and per-priority queue-wait metrics.
+func (c *GeminiClient) SchedulerStats() SchedulerStats {
+	return c.scheduler.Stats()
+}
+
 // StreamGenerateContent makes a streaming request to the Gemini API
 // Returns a channel that receives response chunks
 func (c *GeminiClient) StreamGenerateContent(ctx context.Context, req *GenerateRequest) (<-chan *GenerateResponse, <-chan error) {
@@ -215,6 +253,13 @@ func (c *GeminiClient) StreamGenerateContent(ctx context.Context, req *GenerateR
 		defer close(respChan)
 		defer close(errChan)
 
+		release, err := c.acquire(ctx)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		defer release()
+
 		url := fmt.Sprintf("%s/models/%s:streamGenerateContent?alt=sse", GeminiAPIEndpoint, c.model)
 
 		body, err := json.Marshal(req)
@@ -0,0 +1,176 @@
+// Package agent provides incremental JSON scanners for streamed Gemini
+// output. Gemini streams the structured response in arbitrary text chunks;
+// the formatter scanner hands back each element of the response's array
+// (quotes[] or scriptures[]) as soon as its closing brace arrives, and the
+// summary scanner decodes paragraphs while they are still being written.
+package agent
+
+import (
+	"encoding/json"
+	"unicode/utf8"
+)
+
+// elementScanner finds the complete objects inside the array held by the
+// top-level object, e.g. each quote of {"quotes": [{...}, {...}]}.
+type elementScanner struct {
+	buf   []byte
+	pos   int
+	stack []byte // open containers: '{' or '['
+
+	inString    bool
+	escaped     bool
+	stringStart int
+	lastString  string // most recent string at depth 1, i.e. the array's key
+
+	key       string // key of the array being scanned
+	elemStart int
+}
+
+// Write appends chunk and returns the array elements it completed.
+func (s *elementScanner) Write(chunk string) []string {
+	s.buf = append(s.buf, chunk...)
+	var elements []string
+	for ; s.pos < len(s.buf); s.pos++ {
+		c := s.buf[s.pos]
+		if s.inString {
+			switch {
+			case s.escaped:
+				s.escaped = false
+			case c == '\\':
+				s.escaped = true
+			case c == '"':
+				s.inString = false
+				if len(s.stack) == 1 {
+					s.lastString = string(s.buf[s.stringStart:s.pos])
+				}
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			s.inString = true
+			s.stringStart = s.pos + 1
+		case '{':
+			if s.inTopArray() {
+				s.elemStart = s.pos
+			}
+			s.stack = append(s.stack, c)
+		case '[':
+			if len(s.stack) == 1 && s.stack[0] == '{' {
+				s.key = s.lastString
+			}
+			s.stack = append(s.stack, c)
+		case '}', ']':
+			if len(s.stack) == 0 {
+				continue
+			}
+			s.stack = s.stack[:len(s.stack)-1]
+			if c == '}' && s.inTopArray() {
+				elements = append(elements, string(s.buf[s.elemStart:s.pos+1]))
+			}
+		}
+	}
+	return elements
+}
+
+// inTopArray reports whether the scanner sits directly inside the top-level
+// object's array, where each '{' starts an element.
+func (s *elementScanner) inTopArray() bool {
+	return len(s.stack) == 2 && s.stack[0] == '{' && s.stack[1] == '['
+}
+
+// partialContent wraps a single streamed element in its response shape, so
+// it parses exactly like a complete formatter response.
+func partialContent(key, element string) string {
+	data, err := json.Marshal(map[string][]json.RawMessage{key: {json.RawMessage(element)}})
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// paragraphScanner decodes the string array of a streamed summary response,
+// {"summary": ["...", "..."]}, including the paragraph still being written.
+type paragraphScanner struct {
+	buf   []byte
+	pos   int
+	stack []byte
+
+	inString  bool
+	paragraph bool // the open string is an element of the top-level array
+	escape    int  // bytes of an escape sequence still to come
+	start     int  // first byte of the open string's contents
+	safe      int  // end of the open string's decodable prefix
+
+	done []string
+}
+
+// Write appends chunk to the scanned response.
+func (s *paragraphScanner) Write(chunk string) {
+	s.buf = append(s.buf, chunk...)
+	for ; s.pos < len(s.buf); s.pos++ {
+		c := s.buf[s.pos]
+		if s.inString {
+			switch {
+			case s.escape > 0:
+				s.escape--
+				if s.escape == 0 && c == 'u' && s.buf[s.pos-1] == '\\' {
+					s.escape = 4
+				}
+				if s.escape == 0 {
+					s.safe = s.pos + 1
+				}
+			case c == '\\':
+				s.escape = 1
+			case c == '"':
+				s.inString = false
+				if s.paragraph {
+					s.done = append(s.done, decodeJSONString(s.buf[s.start:s.pos]))
+				}
+			default:
+				s.safe = s.pos + 1
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			s.inString = true
+			s.paragraph = len(s.stack) == 2 && s.stack[0] == '{' && s.stack[1] == '['
+			s.start = s.pos + 1
+			s.safe = s.start
+		case '{', '[':
+			s.stack = append(s.stack, c)
+		case '}', ']':
+			if len(s.stack) > 0 {
+				s.stack = s.stack[:len(s.stack)-1]
+			}
+		}
+	}
+}
+
+// Paragraphs returns the completed paragraphs followed by the one in progress.
+func (s *paragraphScanner) Paragraphs() []string {
+	paragraphs := append([]string(nil), s.done...)
+	if s.inString && s.paragraph {
+		raw := s.buf[s.start:s.safe]
+		// Hold back a multi-byte character split across chunks
+		for i := 0; i < utf8.UTFMax-1 && len(raw) > 0 && !utf8.Valid(raw); i++ {
+			raw = raw[:len(raw)-1]
+		}
+		if text := decodeJSONString(raw); text != "" {
+			paragraphs = append(paragraphs, text)
+		}
+	}
+	return paragraphs
+}
+
+// decodeJSONString decodes the contents of a JSON string literal.
+func decodeJSONString(raw []byte) string {
+	var text string
+	quoted := make([]byte, 0, len(raw)+2)
+	quoted = append(append(append(quoted, '"'), raw...), '"')
+	if err := json.Unmarshal(quoted, &text); err != nil {
+		return string(raw)
+	}
+	return text
+}
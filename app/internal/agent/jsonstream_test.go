@@ -0,0 +1,78 @@
+// Package agent tests the incremental formatter output scanner.
+package agent
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+const streamedQuotes = `{"quotes": [
+  {"speaker": "Dallin H. Oaks", "quote": "A \"quoted\" {brace} and ] bracket \\", "tags": ["a", "b"]},
+  {"speaker": "Russell M. Nelson", "quote": "Second"}
+]}`
+
+// TestElementScannerChunked verifies elements are emitted as they close, whatever the chunking
+func TestElementScannerChunked(t *testing.T) {
+	for _, size := range []int{1, 3, 7, len(streamedQuotes)} {
+		var s elementScanner
+		var got []string
+		for i := 0; i < len(streamedQuotes); i += size {
+			end := min(i+size, len(streamedQuotes))
+			got = append(got, s.Write(streamedQuotes[i:end])...)
+		}
+		if len(got) != 2 {
+			t.Fatalf("chunk size %d: expected 2 elements, got %d: %q", size, len(got), got)
+		}
+		if s.key != "quotes" {
+			t.Errorf("chunk size %d: expected key %q, got %q", size, "quotes", s.key)
+		}
+		var first StructuredQuote
+		if err := json.Unmarshal([]byte(got[0]), &first); err != nil {
+			t.Fatalf("chunk size %d: first element is not valid JSON: %v", size, err)
+		}
+		if first.Quote != `A "quoted" {brace} and ] bracket \` {
+			t.Errorf("chunk size %d: unexpected quote %q", size, first.Quote)
+		}
+	}
+}
+
+// TestElementScannerEmitsBeforeEnd verifies a card is available before the array closes
+func TestElementScannerEmitsBeforeEnd(t *testing.T) {
+	var s elementScanner
+	got := s.Write(`{"scriptures": [{"reference": "Moroni 10:4"}, {"refer`)
+	if len(got) != 1 {
+		t.Fatalf("expected the first element before the stream ends, got %q", got)
+	}
+	var resp ScripturesResponse
+	if err := json.Unmarshal([]byte(partialContent(s.key, got[0])), &resp); err != nil {
+		t.Fatalf("partial content does not parse as a response: %v", err)
+	}
+	if len(resp.Scriptures) != 1 || resp.Scriptures[0].Reference != "Moroni 10:4" {
+		t.Errorf("unexpected partial response: %+v", resp)
+	}
+}
+
+// TestParagraphScannerProgress verifies partial paragraphs decode as they stream
+func TestParagraphScannerProgress(t *testing.T) {
+	const summary = `{"summary": ["Faith \"grows\" — line\nbreak é.", "Second paragraph."]}`
+	var s paragraphScanner
+	var snapshots [][]string
+	for i := 0; i < len(summary); i++ {
+		s.Write(summary[i : i+1])
+		snapshots = append(snapshots, s.Paragraphs())
+	}
+	final := s.Paragraphs()
+	want := []string{"Faith \"grows\" — line\nbreak é.", "Second paragraph."}
+	if len(final) != len(want) || final[0] != want[0] || final[1] != want[1] {
+		t.Fatalf("final paragraphs = %q, want %q", final, want)
+	}
+	for _, snap := range snapshots {
+		for i, p := range snap {
+			if !utf8.ValidString(p) || !strings.HasPrefix(want[i], p) {
+				t.Fatalf("snapshot paragraph %d = %q is not a prefix of %q", i, p, want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,288 @@
+// Package agent provides deterministic keyword extraction for when the
+// orchestrator LLM calls fail or run out of budget. The searches use
+// plainto_tsquery, which ANDs every term, so the extractor returns a few
+// salient words rather than an expanded list. Spanish and Portuguese
+// questions are reduced to English topic terms.
+package agent
+
+import (
+	"strings"
+	"unicode"
+)
+
+// maxFallbackKeywords bounds the fallback query; more AND-ed terms rarely match.
+const maxFallbackKeywords = 3
+
+// stopwords are dropped before picking keywords. Besides common English words
+// it includes words that appear in nearly every question or talk.
+var stopwords = toSet(`a about above after again against all am an and any are as at be because
+been before being below between both but by can could did do does doing down during each few
+for from further had has have having he her here hers herself him himself his how i if in into
+is it its itself just me more most my myself no nor not now of off on once only or other our ours
+ourselves out over own same she should so some such than that the their theirs them themselves
+then there these they this those through to too under until up very was we were what when where
+which while who whom why will with would you your yours yourself yourselves
+im ive dont doesnt cant wont isnt arent whats hows
+tell say says said think thought know knew mean means feel feels want wants need needs get gets
+give help really way ways thing things something someone anyone everyone also
+church lds mormon latter day saints saint prophet prophets apostle apostles teach teaches
+teaching teachings taught believe believes belief beliefs`)
+
+// topicVocabulary maps gospel topic terms, as used in conference talks and
+// scripture, to the everyday words visitors use for them.
+var topicVocabulary = map[string][]string{
+	"comfort":      {"sad", "sadness", "grief", "grieving", "mourn", "mourning", "lonely", "loneliness", "depressed", "depression", "sorrow"},
+	"fear":         {"afraid", "scared", "anxiety", "anxious", "worry", "worried", "worries"},
+	"prayer":       {"pray", "prays", "praying", "prayed", "prayers"},
+	"marriage":     {"married", "marry", "wife", "husband", "spouse", "wedding"},
+	"children":     {"kids", "child", "son", "daughter", "sons", "daughters", "raising"},
+	"family":       {"families", "parent", "parents", "parenting", "mom", "dad"},
+	"joy":          {"happy", "happiness", "joyful", "rejoice"},
+	"forgive":      {"forgiveness", "forgiving", "forgave", "forgiven", "grudge"},
+	"repentance":   {"repent", "repenting", "sin", "sins", "sinned", "mistakes", "guilt"},
+	"resurrection": {"afterlife", "heaven", "death", "dead", "die", "dying", "died", "resurrected"},
+	"atonement":    {"atone", "atoning", "redeemer", "redemption"},
+	"Jesus Christ": {"jesus", "christ", "savior", "saviour"},
+	"baptism":      {"baptized", "baptize", "baptised", "baptise"},
+	"temple":       {"temples", "endowment", "sealing", "sealed"},
+	"revelation":   {"guidance", "answers", "inspiration", "promptings", "prompting"},
+	"faith":        {"trust", "doubt", "doubts", "doubting"},
+	"service":      {"serve", "serving", "volunteer", "kindness"},
+	"addiction":    {"addicted", "addictions", "pornography"},
+	"trials":       {"suffering", "hardship", "hardships", "struggle", "struggles", "adversity", "pain"},
+}
+
+// topicSynonyms indexes topicVocabulary by stem.
+var topicSynonyms = buildSynonyms(topicVocabulary)
+
+// translatedVocabulary maps English topic terms to the words Spanish and
+// Portuguese speakers use for them, written without accents. The searches
+// are English, so a question in these languages only searches its topics.
+var translatedVocabulary = map[Language]map[string][]string{
+	LanguageSpanish: {
+		"faith":        {"fe", "creer", "confianza", "duda", "dudas"},
+		"prayer":       {"oracion", "oraciones", "orar", "rezar"},
+		"comfort":      {"consuelo", "tristeza", "triste", "duelo", "soledad", "depresion"},
+		"fear":         {"miedo", "temor", "ansiedad", "preocupacion"},
+		"marriage":     {"matrimonio", "casarse", "esposo", "esposa", "boda"},
+		"children":     {"hijos", "hijo", "hija", "ninos"},
+		"family":       {"familia", "familias", "padres"},
+		"joy":          {"gozo", "alegria", "felicidad", "feliz"},
+		"peace":        {"paz"},
+		"love":         {"amor"},
+		"forgive":      {"perdon", "perdonar"},
+		"repentance":   {"arrepentimiento", "arrepentirse", "pecado", "pecados", "culpa"},
+		"resurrection": {"resurreccion", "muerte", "morir", "cielo"},
+		"atonement":    {"expiacion"},
+		"Jesus Christ": {"jesus", "cristo", "jesucristo", "salvador"},
+		"God":          {"dios"},
+		"Holy Ghost":   {"espiritu"},
+		"baptism":      {"bautismo", "bautizar"},
+		"temple":       {"templo", "templos"},
+		"revelation":   {"revelacion", "inspiracion"},
+		"service":      {"servicio", "servir"},
+		"trials":       {"pruebas", "sufrimiento", "dificultades", "adversidad", "dolor"},
+		"grace":        {"gracia"},
+		"covenant":     {"convenio", "convenios"},
+		"hope":         {"esperanza"},
+		"purpose":      {"proposito"},
+	},
+	LanguagePortuguese: {
+		"faith":        {"fe", "crer", "confianca", "duvida", "duvidas"},
+		"prayer":       {"oracao", "oracoes", "orar", "rezar"},
+		"comfort":      {"consolo", "conforto", "tristeza", "triste", "luto", "solidao", "depressao"},
+		"fear":         {"medo", "temor", "ansiedade", "preocupacao"},
+		"marriage":     {"casamento", "casar", "marido", "esposa", "esposo"},
+		"children":     {"filhos", "filho", "filha", "criancas"},
+		"family":       {"familia", "familias", "pais"},
+		"joy":          {"alegria", "felicidade", "feliz", "gozo"},
+		"peace":        {"paz"},
+		"love":         {"amor"},
+		"forgive":      {"perdao", "perdoar"},
+		"repentance":   {"arrependimento", "arrepender", "pecado", "pecados", "culpa"},
+		"resurrection": {"ressurreicao", "morte", "morrer", "ceu"},
+		"atonement":    {"expiacao"},
+		"Jesus Christ": {"jesus", "cristo", "salvador"},
+		"God":          {"deus"},
+		"Holy Ghost":   {"espirito"},
+		"baptism":      {"batismo", "batizar"},
+		"temple":       {"templo", "templos"},
+		"revelation":   {"revelacao", "inspiracao"},
+		"service":      {"servico", "servir"},
+		"trials":       {"provacoes", "sofrimento", "dificuldades", "adversidade", "dor"},
+		"grace":        {"graca"},
+		"covenant":     {"convenio", "convenios"},
+		"hope":         {"esperanca"},
+		"purpose":      {"proposito"},
+	},
+}
+
+// translatedSynonyms indexes translatedVocabulary by word, per language.
+var translatedSynonyms = func() map[Language]map[string]string {
+	out := map[Language]map[string]string{}
+	for lang, topics := range translatedVocabulary {
+		index := map[string]string{}
+		for topic, words := range topics {
+			for _, w := range words {
+				index[w] = topic
+			}
+		}
+		out[lang] = index
+	}
+	return out
+}()
+
+// accentFolder removes the accents translatedVocabulary is written without.
+var accentFolder = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "é", "e", "ê", "e", "í", "i",
+	"ó", "o", "ô", "o", "õ", "o", "ú", "u", "ü", "u", "ç", "c", "ñ", "n",
+)
+
+// ExtractKeywords returns up to limit search keywords for question. Words that
+// map to a gospel topic come first (as the topic term), then the remaining
+// content words in question order.
+func ExtractKeywords(question string, limit int) []string {
+	var topics, others []string
+	seen := map[string]bool{}
+	for _, word := range tokenize(question) {
+		if stopwords[word] || len(word) < 3 {
+			continue
+		}
+		stem := stemWord(word)
+		if topic, ok := topicSynonyms[stem]; ok {
+			if !seen[topic] {
+				seen[topic] = true
+				topics = append(topics, topic)
+			}
+			continue
+		}
+		if !seen[stem] {
+			seen[stem] = true
+			others = append(others, word)
+		}
+	}
+	keywords := append(topics, others...)
+	if len(keywords) > limit {
+		keywords = keywords[:limit]
+	}
+	return keywords
+}
+
+// translateKeywords returns up to limit English topic terms for a question
+// in lang, in question order. Words with no known topic are dropped, since
+// they can't match the English search index.
+func translateKeywords(question string, lang Language, limit int) []string {
+	index := translatedSynonyms[lang]
+	var keywords []string
+	seen := map[string]bool{}
+	for _, word := range tokenize(question) {
+		word = accentFolder.Replace(word)
+		topic, ok := index[word]
+		if !ok {
+			topic, ok = index[strings.TrimSuffix(word, "s")]
+		}
+		if !ok || seen[topic] {
+			continue
+		}
+		seen[topic] = true
+		keywords = append(keywords, topic)
+		if len(keywords) == limit {
+			break
+		}
+	}
+	return keywords
+}
+
+// fallbackKeywords is the keyword string used in place of an orchestrator's.
+// A question in Spanish or Portuguese searches its topics in English, or
+// the Savior if none is known; the question itself wouldn't match anything.
+func fallbackKeywords(question string) string {
+	if lang := DetectLanguage(question); lang != LanguageEnglish {
+		keywords := translateKeywords(question, lang, maxFallbackKeywords)
+		if len(keywords) == 0 {
+			return "Jesus Christ"
+		}
+		return strings.Join(keywords, " ")
+	}
+	keywords := ExtractKeywords(question, maxFallbackKeywords)
+	if len(keywords) == 0 {
+		return strings.TrimSpace(question)
+	}
+	return strings.Join(keywords, " ")
+}
+
+// fallbackPresidentsKeywords stands in for the presidents orchestrator. The
+// question has already passed the local safety classifier.
+func fallbackPresidentsKeywords(question string) *PresidentsOrchestratorResponse {
+	kw := fallbackKeywords(question)
+	resp := &PresidentsOrchestratorResponse{Safe: true}
+	resp.Keywords.PresidentsCurrent = kw
+	resp.Keywords.PresidentsGeneral = kw
+	return resp
+}
+
+// fallbackLeadersKeywords stands in for the leaders orchestrator.
+func fallbackLeadersKeywords(question string) *LeadersOrchestratorResponse {
+	kw := fallbackKeywords(question)
+	resp := &LeadersOrchestratorResponse{}
+	resp.Keywords.LeadersFirstPres = kw
+	resp.Keywords.LeadersQ12 = kw
+	resp.Keywords.LeadersOther = kw
+	return resp
+}
+
+// fallbackScripturesKeywords stands in for the scriptures orchestrator.
+func fallbackScripturesKeywords(question string) *ScripturesOrchestratorResponse {
+	kw := fallbackKeywords(question)
+	resp := &ScripturesOrchestratorResponse{}
+	resp.Keywords.ScripturesBible = kw
+	resp.Keywords.ScripturesBoM = kw
+	resp.Keywords.ScripturesOther = kw
+	return resp
+}
+
+// tokenize lowercases question and splits it into words, dropping apostrophes
+// so contractions match the stopword list.
+func tokenize(question string) []string {
+	question = strings.NewReplacer("'", "", "’", "").Replace(strings.ToLower(question))
+	return strings.FieldsFunc(question, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// stemWord strips common English suffixes. It only needs to be consistent
+// for synonym lookup and de-duplication; Postgres does the real stemming.
+func stemWord(word string) string {
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word) > 4:
+		return word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "ing") && len(word) > 5:
+		return word[:len(word)-3]
+	case strings.HasSuffix(word, "ed") && len(word) > 4:
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "es") && len(word) > 4:
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && len(word) > 3:
+		return word[:len(word)-1]
+	}
+	return word
+}
+
+func toSet(words string) map[string]bool {
+	set := map[string]bool{}
+	for _, w := range strings.Fields(words) {
+		set[w] = true
+	}
+	return set
+}
+
+func buildSynonyms(topics map[string][]string) map[string]string {
+	index := map[string]string{}
+	for topic, words := range topics {
+		index[stemWord(strings.ToLower(topic))] = topic
+		for _, w := range words {
+			index[stemWord(w)] = topic
+		}
+	}
+	return index
+}
@@ -0,0 +1,69 @@
+// Package agent tests the fallback keyword extractor.
+package agent
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestExtractKeywords verifies stopwords are dropped and topic synonyms come first
+func TestExtractKeywords(t *testing.T) {
+	cases := []struct {
+		question string
+		want     []string
+	}{
+		{"How can I find peace when I'm feeling sad?", []string{"comfort", "find", "peace"}},
+		{"What happens after we die?", []string{"resurrection", "happens"}},
+		{"Why should I pray every day?", []string{"prayer", "every"}},
+		{"What does the Church teach about the Savior's atonement?", []string{"Jesus Christ", "atonement"}},
+		{"What is the church?", nil},
+	}
+	for _, tc := range cases {
+		got := ExtractKeywords(tc.question, maxFallbackKeywords)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ExtractKeywords(%q) = %q, want %q", tc.question, got, tc.want)
+		}
+	}
+}
+
+// TestFallbackKeywordsNeverEmpty verifies a question made of stopwords still searches
+func TestFallbackKeywordsNeverEmpty(t *testing.T) {
+	if got := fallbackKeywords("What is the church?"); got == "" {
+		t.Error("Expected fallback keywords to fall back to the question")
+	}
+	if got := fallbackLeadersKeywords("Why do we have temples?"); got.Keywords.LeadersQ12 != "temple" {
+		t.Errorf("Expected leaders fallback keyword %q, got %q", "temple", got.Keywords.LeadersQ12)
+	}
+}
+
+// TestTopicSynonymsUnambiguous verifies no two topics claim the same stem
+func TestTopicSynonymsUnambiguous(t *testing.T) {
+	owners := map[string]string{}
+	for topic, words := range topicVocabulary {
+		for _, w := range append([]string{strings.ToLower(topic)}, words...) {
+			stem := stemWord(w)
+			if prev, ok := owners[stem]; ok && prev != topic {
+				t.Errorf("Stem %q maps to both %q and %q", stem, prev, topic)
+			}
+			owners[stem] = topic
+		}
+	}
+}
+
+// TestFallbackKeywordsTranslated verifies Spanish and Portuguese questions
+// search their topics in English
+func TestFallbackKeywordsTranslated(t *testing.T) {
+	cases := map[string]string{
+		"¿Cómo puedo fortalecer mi fe?":             "faith",
+		"¿Por qué debo orar y leer las Escrituras?": "prayer",
+		"Como encontrar paz quando estou triste?":   "peace comfort",
+		"¿Qué es la expiación de Jesucristo?":       "atonement Jesus Christ",
+		"¿Dónde está mi abuela?":                    "Jesus Christ",
+	}
+	for question, want := range cases {
+		if got := fallbackKeywords(question); got != want {
+			t.Errorf("fallbackKeywords(%q) = %q, want %q", question, got, want)
+		}
+	}
+}
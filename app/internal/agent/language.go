@@ -0,0 +1,149 @@
+// Package agent answers questions asked in Spanish and Portuguese. The talks
+// and scriptures are searched in English, so the language of a question
+// only changes the orchestrators' instructions (English keywords), the
+// summary and related questions (written in the visitor's language), and
+// the cards, which show translated text where the corpus has it.
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Language is a language the agent answers in, as an ISO 639-1 code.
+type Language string
+
+const (
+	LanguageEnglish    Language = "en"
+	LanguageSpanish    Language = "es"
+	LanguagePortuguese Language = "pt"
+)
+
+// ParseLanguage parses a locale ("es", "pt-BR", "spa") into a supported
+// language. Unsupported locales give English and false.
+func ParseLanguage(locale string) (Language, bool) {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(locale, "-_"); i >= 0 {
+		locale = locale[:i]
+	}
+	switch locale {
+	case "en", "eng":
+		return LanguageEnglish, true
+	case "es", "spa":
+		return LanguageSpanish, true
+	case "pt", "por":
+		return LanguagePortuguese, true
+	}
+	return LanguageEnglish, false
+}
+
+// name is the language's English name, for prompts.
+func (l Language) name() string {
+	switch l {
+	case LanguageSpanish:
+		return "Spanish"
+	case LanguagePortuguese:
+		return "Portuguese"
+	}
+	return "English"
+}
+
+// siteCode is the lang parameter churchofjesuschrist.org uses for it.
+func (l Language) siteCode() string {
+	switch l {
+	case LanguageSpanish:
+		return "spa"
+	case LanguagePortuguese:
+		return "por"
+	}
+	return "eng"
+}
+
+// Words common in questions in one language and rare in the others. Words
+// the two share ("que", "como", "para") don't tell them apart and are left out.
+var (
+	englishMarkers    = toSet(`the is are what how why who when where does do did can should my i you your about of and to`)
+	spanishMarkers    = toSet(`el los las del es una qué cómo cuál cuándo dónde quién puedo puede mi mis hay y lo le al muy yo tengo dios iglesia nuestro nuestra también más`)
+	portugueseMarkers = toSet(`o os da dos das é não nao um uma com qual quando onde quem posso pode meu minha são há deus em seu sua ao eu tenho você voce vocês igreja muito nós nosso isso também mais`)
+)
+
+// DetectLanguage guesses the language of a question from its common words
+// and letters (¿ ñ for Spanish, ã õ ç for Portuguese). Questions with no
+// clear sign of Spanish or Portuguese are English.
+func DetectLanguage(question string) Language {
+	var en, es, pt int
+	for _, r := range strings.ToLower(question) {
+		switch r {
+		case '¿', '¡', 'ñ':
+			es += 2
+		case 'ã', 'õ', 'ç', 'ê', 'ô':
+			pt += 2
+		}
+	}
+	for _, word := range tokenize(question) {
+		switch {
+		case englishMarkers[word]:
+			en++
+		case spanishMarkers[word]:
+			es++
+		case portugueseMarkers[word]:
+			pt++
+		}
+	}
+	switch {
+	case es > en && es > pt:
+		return LanguageSpanish
+	case pt > en && pt > es:
+		return LanguagePortuguese
+	}
+	return LanguageEnglish
+}
+
+type languageKey struct{}
+
+// WithLanguage makes Run, GenerateSummary and RelatedQuestions with ctx
+// answer in lang instead of the language detected from the question.
+func WithLanguage(ctx context.Context, lang Language) context.Context {
+	return context.WithValue(ctx, languageKey{}, lang)
+}
+
+// languageFor is the language to answer question in: the one on ctx, or
+// else the question's own.
+func languageFor(ctx context.Context, question string) Language {
+	if lang, ok := ctx.Value(languageKey{}).(Language); ok {
+		return lang
+	}
+	return DetectLanguage(question)
+}
+
+// languageFrom is the language on ctx, English if none.
+func languageFrom(ctx context.Context) Language {
+	if lang, ok := ctx.Value(languageKey{}).(Language); ok {
+		return lang
+	}
+	return LanguageEnglish
+}
+
+// languageOrchestratorNote keeps keywords in English for a question in
+// another language, since the search index is English.
+func languageOrchestratorNote(lang Language) string {
+	return fmt.Sprintf(`
+
+## LANGUAGE
+The question is in %s. Understand it in %s, but write every keyword in English: the talks and scriptures are searched in English.`, lang.name(), lang.name())
+}
+
+// languageSummaryNote has the summary written in the visitor's language.
+func languageSummaryNote(lang Language) string {
+	return fmt.Sprintf(`
+
+Write the summary in %s, the language of the question. Quotes that were not given in %s must not be excerpted: leave out the verbatim excerpt rather than translating it.`, lang.name(), lang.name())
+}
+
+// languageRelatedNote has related questions written in the visitor's language.
+func languageRelatedNote(lang Language) string {
+	return fmt.Sprintf(`
+
+Write the questions in %s, the language of the visitor's question.`, lang.name())
+}
@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	cases := []struct {
+		question string
+		want     Language
+	}{
+		{"What is faith?", LanguageEnglish},
+		{"How can I feel God's love?", LanguageEnglish},
+		{"¿Qué es la fe?", LanguageSpanish},
+		{"Como puedo sentir el amor de Dios", LanguageSpanish},
+		{"O que é a fé?", LanguagePortuguese},
+		{"Como posso sentir o amor de Deus?", LanguagePortuguese},
+		{"Por que coisas ruins acontecem com pessoas boas?", LanguagePortuguese},
+		{"Jesus", LanguageEnglish},
+	}
+	for _, tc := range cases {
+		if got := DetectLanguage(tc.question); got != tc.want {
+			t.Errorf("DetectLanguage(%q) = %s, want %s", tc.question, got, tc.want)
+		}
+	}
+}
+
+func TestParseLanguage(t *testing.T) {
+	cases := map[string]Language{"es": LanguageSpanish, "es-MX": LanguageSpanish, "pt_BR": LanguagePortuguese, "por": LanguagePortuguese, "EN": LanguageEnglish}
+	for locale, want := range cases {
+		if got, ok := ParseLanguage(locale); !ok || got != want {
+			t.Errorf("ParseLanguage(%q) = %s, %v, want %s", locale, got, ok, want)
+		}
+	}
+	if got, ok := ParseLanguage("fr"); ok || got != LanguageEnglish {
+		t.Errorf("ParseLanguage(fr) = %s, %v, want English and false", got, ok)
+	}
+}
+
+func TestLanguageFor(t *testing.T) {
+	ctx := context.Background()
+	if got := languageFor(ctx, "¿Qué es la fe?"); got != LanguageSpanish {
+		t.Errorf("detected %s", got)
+	}
+	// A language set on ctx wins over the question's
+	if got := languageFor(WithLanguage(ctx, LanguagePortuguese), "What is faith?"); got != LanguagePortuguese {
+		t.Errorf("languageFor = %s", got)
+	}
+}
+
+func TestClassifySpanishAndPortuguese(t *testing.T) {
+	cases := []struct {
+		question string
+		want     ContentClassification
+	}{
+		{"¿Qué enseña la Iglesia sobre la poligamia?", ContentControversial},
+		{"O que a igreja pensa sobre o aborto?", ContentControversial},
+		{"¿Es la Iglesia una secta?", ContentControversial},
+		{"¿Cómo puedo encontrar paz?", ContentSafe},
+		{"¿Qué pasa en el culto dominical?", ContentSafe},
+		{"Why is life so difficult?", ContentSafe},
+		{"Is the Church a cult?", ContentControversial},
+		// Spanish and Portuguese words containing short English ones
+		{"Por que Deus permite que seja assim?", ContentSafe},
+		{"Como posso passar por provações com fé?", ContentSafe},
+		{"¿Por qué ayunamos en la sexta semana?", ContentSafe},
+		{"O que fazer na sexta-feira?", ContentSafe},
+		{"¿Por qué oramos de madrugada?", ContentSafe},
+		{"What method helps me study?", ContentSafe},
+		{"Where can I buy meth?", ContentInappropriate},
+		{"Tell me about sex", ContentInappropriate},
+		{"Are drugs a sin?", ContentInappropriate},
+		{"Eres un asshole", ContentInappropriate},
+		{"¿Cómo conseguir drogas?", ContentInappropriate},
+	}
+	for _, tc := range cases {
+		if got := ClassifyContent(tc.question); got != tc.want {
+			t.Errorf("ClassifyContent(%q) = %s, want %s", tc.question, got, tc.want)
+		}
+	}
+}
+
+func TestRedirectMessageLocalized(t *testing.T) {
+	es := DefaultSuggestionCatalog().Redirect(ContentControversial, "church_history", "es-MX")
+	if !strings.Contains(es.Message, "misioneros") {
+		t.Errorf("Spanish redirect message = %q", es.Message)
+	}
+	if en := redirectMessage(ContentInappropriate, "fr"); en != redirectMessages[LanguageEnglish][1] {
+		t.Errorf("unsupported locale message = %q", en)
+	}
+}
@@ -0,0 +1,131 @@
+// Package agent also answers narrower lookups than Run: one quote on a
+// topic, or a few verses. They reuse the section search agents (tools,
+// reranking, formatting and source links) without the orchestrator, so
+// the topic is used as the search keywords directly and callers screen it.
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ScriptureVolume narrows FindScriptures to part of the canon.
+type ScriptureVolume string
+
+const (
+	VolumeAny          ScriptureVolume = ""
+	VolumeBible        ScriptureVolume = "bible"
+	VolumeBookOfMormon ScriptureVolume = "book_of_mormon"
+	VolumeOther        ScriptureVolume = "other" // D&C and Pearl of Great Price
+)
+
+// lookupSearchLimit is how many rows a lookup's search returns.
+const lookupSearchLimit = 5
+
+// FindLeaderQuote selects the quote most relevant to topic from a recent
+// talk of speaker, whom the speaker registry must know, or, without a
+// speaker, from any General Conference talk.
+func (a *ProphetAgent) FindLeaderQuote(ctx context.Context, topic, speaker string) ([]StructuredQuote, error) {
+	if err := a.ensureInitialized(ctx); err != nil {
+		return nil, err
+	}
+	ctx, cancel := withBudget(ctx, a.budget.Section)
+	defer cancel()
+
+	var content string
+	var err error
+	if speaker != "" {
+		sp, ok := a.speakers.Lookup(speaker)
+		if !ok {
+			return nil, fmt.Errorf("unknown speaker %q", speaker)
+		}
+		content, err = a.runSpeakerAgent(ctx, "leaders_lookup_speaker", topic, sp, speakerQuotePrompt, nil)
+	} else {
+		content, err = a.runSearchAgent(ctx, "leaders_lookup", topic,
+			"search_talks",
+			map[string]any{"query": topic, "limit": lookupSearchLimit},
+			a.withRoster(leaderQuotePrompt, QuorumFirstPresidency, QuorumTwelve), quotesSchema, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var resp LeadersResponse
+	if err := decodeContent(content, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Quotes, nil
+}
+
+// FindScriptures selects verses relevant to topic from any volume, or from
+// the given one like the scriptures section does.
+func (a *ProphetAgent) FindScriptures(ctx context.Context, topic string, volume ScriptureVolume) ([]StructuredScripture, error) {
+	var name, query, prompt string
+	schema := scripturesCategorySchema
+	switch volume {
+	case VolumeAny:
+		name, query, prompt, schema = "scriptures_lookup", topic, scripturesSinglePrompt, scripturesSchema
+	case VolumeBible:
+		name, query, prompt = "scriptures_lookup_bible", topic+" Bible Old Testament New Testament", scripturesBiblePrompt
+	case VolumeBookOfMormon:
+		name, query, prompt = "scriptures_lookup_bom", topic+" Book of Mormon", scripturesBoMPrompt
+	case VolumeOther:
+		name, query, prompt = "scriptures_lookup_other", topic+" Doctrine and Covenants Pearl of Great Price", scripturesOtherPrompt
+	default:
+		return nil, fmt.Errorf("unknown volume %q", volume)
+	}
+	if err := a.ensureInitialized(ctx); err != nil {
+		return nil, err
+	}
+	ctx, cancel := withBudget(ctx, a.budget.Section)
+	defer cancel()
+
+	content, err := a.runSearchAgent(ctx, name, query,
+		"search_scriptures",
+		map[string]any{"query": query, "limit": 12},
+		prompt, schema, nil)
+	if err != nil {
+		return nil, err
+	}
+	var resp ScripturesResponse
+	if err := decodeContent(content, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Scriptures, nil
+}
+
+// decodeContent decodes a search agent's content. Empty content means the
+// search found nothing usable.
+func decodeContent(content string, out any) error {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(content), out); err != nil {
+		return fmt.Errorf("failed to decode agent content: %w", err)
+	}
+	return nil
+}
+
+const speakerQuotePrompt = `You are a quote selector. Select the 1 quote from {speaker} most relevant to the keywords.
+
+REQUIREMENTS:
+- Copy quote text EXACTLY from the search results - never paraphrase
+- Quote field must contain ONLY the quote text (no labels like "Title:" or "Conference:")
+- Quote must be 4-8 complete sentences
+- Include headshot URL if available
+
+Return ONLY valid JSON in this exact format:
+{"quotes":[{"speaker":"{speaker}","title":"Talk Title","conference":"April 2024","quote":"Exact quote here...","headshot":""}]}`
+
+const leaderQuotePrompt = `You are a quote selector. Select the 1 quote most relevant to the keywords from any Church leader in the search results.
+
+REQUIREMENTS:
+- Copy quote text EXACTLY from the search results - never paraphrase
+- Quote field must contain ONLY the quote text (no labels like "Title:" or "Conference:")
+- Quote must be 4-8 complete sentences
+- Include headshot URL if available
+
+Return ONLY valid JSON in this exact format:
+{"quotes":[{"speaker":"Elder Name Here","title":"Talk Title","conference":"October 2024","quote":"Exact quote here...","headshot":""}]}`
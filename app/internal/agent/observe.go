@@ -0,0 +1,55 @@
+// Package agent lets callers observe each search agent's retrieval and
+// output, e.g. the offline evaluation harness, without changing what Run
+// streams to the UI.
+package agent
+
+import (
+	"context"
+	"time"
+)
+
+// TraceRow is one row a search handed to its formatter.
+type TraceRow struct {
+	ID    string `json:"id,omitempty"`
+	Label string `json:"label"` // "Speaker: Title" or a scripture reference
+	Text  string `json:"text"`
+}
+
+// SearchTrace describes one search agent run.
+type SearchTrace struct {
+	Agent    string        `json:"agent"`
+	Tool     string        `json:"tool"`
+	Keywords string        `json:"keywords"`
+	Rows     []TraceRow    `json:"rows"`
+	Content  string        `json:"content"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+type searchObserverKey struct{}
+
+// WithSearchObserver makes search agents run with ctx report to observe.
+// It is called from the agents' goroutines, concurrently.
+func WithSearchObserver(ctx context.Context, observe func(SearchTrace)) context.Context {
+	return context.WithValue(ctx, searchObserverKey{}, observe)
+}
+
+func searchObserverFrom(ctx context.Context) func(SearchTrace) {
+	observe, _ := ctx.Value(searchObserverKey{}).(func(SearchTrace))
+	return observe
+}
+
+// newSearchTrace builds the trace of a finished search from its tool result.
+func newSearchTrace(name, toolName, keywords string, result any, content string, err error, d time.Duration) SearchTrace {
+	trace := SearchTrace{Agent: name, Tool: toolName, Keywords: keywords, Content: content, Duration: d}
+	if err != nil {
+		trace.Error = err.Error()
+	}
+	var rows []map[string]any
+	if result != nil && decodeRows(result, &rows) == nil {
+		for _, row := range rows {
+			trace.Rows = append(trace.Rows, TraceRow{ID: rowID(row), Label: rowLabel(row), Text: rowText(row)})
+		}
+	}
+	return trace
+}
@@ -0,0 +1,155 @@
+// Package agent tests the combined orchestrator mode against a stub Gemini.
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// stubGemini answers every generateContent call with text and records the
+// requests it was sent.
+type stubGemini struct {
+	text string
+
+	mu       sync.Mutex
+	requests []*GenerateRequest
+}
+
+func (s *stubGemini) RoundTrip(r *http.Request) (*http.Response, error) {
+	var req GenerateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.requests = append(s.requests, &req)
+	s.mu.Unlock()
+
+	body, _ := json.Marshal(GenerateResponse{Candidates: []*Candidate{{
+		Content: &Content{Role: "model", Parts: []*Part{{Text: s.text}}},
+	}}})
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(body)),
+		Request:    r,
+	}, nil
+}
+
+// newStubAgent creates an agent in mode whose Gemini calls go to stub.
+func newStubAgent(t *testing.T, mode OrchestratorMode, stub *stubGemini) *ProphetAgent {
+	t.Helper()
+	a, err := New(context.Background(), Config{APIKey: "test-key", OrchestratorMode: mode, Budget: DefaultBudget()})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	a.client.httpClient = &http.Client{Transport: stub}
+	return a
+}
+
+const combinedResponse = `{"safe": true, "keywords": {
+	"presidents_current": "president peace", "presidents_general": "peace christ",
+	"leaders_first_presidency": "fp peace", "leaders_q12": "q12 peace", "leaders_other": "seventy peace",
+	"scriptures_bible": "bible peace", "scriptures_bom": "bom peace", "scriptures_other": "dc peace"}}`
+
+// TestOrchestrateCombined verifies combined mode makes one structured call
+// and maps its fields onto each section's keywords
+func TestOrchestrateCombined(t *testing.T) {
+	stub := &stubGemini{text: combinedResponse}
+	a := newStubAgent(t, OrchestratorCombined, stub)
+
+	o := a.orchestrate(context.Background(), "How can I find peace?")
+	<-o.leadersReady
+	<-o.scripturesReady
+
+	if len(stub.requests) != 1 {
+		t.Fatalf("Expected 1 Gemini call, got %d", len(stub.requests))
+	}
+	schema, _ := stub.requests[0].GenerationConfig.ResponseJSONSchema.(map[string]any)
+	props, _ := schema["properties"].(map[string]any)
+	if _, ok := props["keywords"]; !ok {
+		t.Errorf("Expected the combined schema, got %v", schema)
+	}
+
+	if !o.presidents.Safe {
+		t.Error("Expected the question to be safe")
+	}
+	got := combine(o.presidents, o.leaders, o.scriptures).Fields()
+	want := map[string]string{
+		"presidents_current":       "president peace",
+		"presidents_general":       "peace christ",
+		"leaders_first_presidency": "fp peace",
+		"leaders_q12":              "q12 peace",
+		"leaders_other":            "seventy peace",
+		"scriptures_bible":         "bible peace",
+		"scriptures_bom":           "bom peace",
+		"scriptures_other":         "dc peace",
+	}
+	for field, kw := range want {
+		if got[field] != kw {
+			t.Errorf("%s = %q, want %q", field, got[field], kw)
+		}
+	}
+}
+
+// TestOrchestrateCombinedBlocked verifies an unsafe verdict and its reason
+// reach the presidents response the run checks
+func TestOrchestrateCombinedBlocked(t *testing.T) {
+	stub := &stubGemini{text: `{"safe": false, "reason": "off topic", "keywords": {}}`}
+	a := newStubAgent(t, OrchestratorCombined, stub)
+
+	o := a.orchestrate(context.Background(), "Write me a poem about pizza")
+	if o.presidents.Safe || o.presidents.Reason != "off topic" {
+		t.Errorf("Presidents = %+v, want unsafe with the reason", o.presidents)
+	}
+}
+
+// TestOrchestrateCombinedFallback verifies an unparseable response falls
+// back to keywords extracted from the question for every section
+func TestOrchestrateCombinedFallback(t *testing.T) {
+	stub := &stubGemini{text: "not json"}
+	a := newStubAgent(t, OrchestratorCombined, stub)
+
+	question := "How can I find peace when I feel overwhelmed?"
+	o := a.orchestrate(context.Background(), question)
+	<-o.leadersReady
+	<-o.scripturesReady
+
+	if len(stub.requests) != 1 {
+		t.Errorf("Expected 1 Gemini call, got %d", len(stub.requests))
+	}
+	if !o.presidents.Safe {
+		t.Error("Expected fallback keywords to be safe")
+	}
+	want := fallbackKeywords(question)
+	for field, kw := range combine(o.presidents, o.leaders, o.scriptures).Fields() {
+		if kw != want {
+			t.Errorf("%s = %q, want the fallback %q", field, kw, want)
+		}
+	}
+}
+
+// TestGenerateKeywordsCombined verifies the benchmark entry point reports one
+// call in combined mode and doesn't fall back on failure
+func TestGenerateKeywordsCombined(t *testing.T) {
+	a := newStubAgent(t, OrchestratorSplit, &stubGemini{text: combinedResponse})
+	resp, timings, err := a.GenerateKeywords(context.Background(), "How can I find peace?", OrchestratorCombined)
+	if err != nil {
+		t.Fatalf("GenerateKeywords: %v", err)
+	}
+	if timings.Calls != 1 || timings.FirstKeywords != timings.AllKeywords {
+		t.Errorf("Timings = %+v, want one call", timings)
+	}
+	if resp.Keywords.ScripturesBoM != "bom peace" {
+		t.Errorf("ScripturesBoM = %q", resp.Keywords.ScripturesBoM)
+	}
+
+	b := newStubAgent(t, OrchestratorSplit, &stubGemini{text: "not json"})
+	if _, _, err := b.GenerateKeywords(context.Background(), "How can I find peace?", OrchestratorCombined); err == nil {
+		t.Error("Expected an error for an unparseable response")
+	}
+}
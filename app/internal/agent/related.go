@@ -0,0 +1,161 @@
+// Package agent ends an answer with related questions the visitor might ask
+// next, grounded in the talks and scriptures the answer showed.
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// maxRelatedQuestions is how many related questions are offered.
+	maxRelatedQuestions = 4
+	// maxRelatedQuestionRunes drops rambling questions that won't fit a chip.
+	maxRelatedQuestionRunes = 120
+)
+
+// RelatedQuestions proposes up to four questions the visitor might ask
+// next, each grounded in a talk title or scripture the answer showed. Only
+// questions the local classifier finds safe are returned, and none repeats
+// the question or an earlier one in the conversation on ctx. They are in the
+// question's language, like the summary.
+func (a *ProphetAgent) RelatedQuestions(ctx context.Context, question string, quotes []StructuredQuote, scriptures []StructuredScripture) ([]string, error) {
+	if len(quotes) == 0 && len(scriptures) == 0 {
+		// Nothing to ground them in
+		return nil, nil
+	}
+	ctx = WithPriority(ctx, PrioritySummary)
+	ctx, cancel := withBudget(ctx, a.budget.Summary)
+	defer cancel()
+	temp := float32(1.0)
+
+	type talk struct {
+		Speaker string `json:"speaker"`
+		Title   string `json:"title"`
+	}
+	var talks []talk
+	for _, q := range quotes {
+		talks = append(talks, talk{Speaker: q.Speaker, Title: q.Title})
+	}
+	var references []string
+	for _, s := range scriptures {
+		references = append(references, s.Reference)
+	}
+	asked := append(conversationFrom(ctx).Questions(), question)
+	payload := map[string]any{
+		"question":          question,
+		"earlier_questions": asked[:len(asked)-1],
+		"talks":             talks,
+		"scriptures":        references,
+	}
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal related questions payload: %w", err)
+	}
+
+	prompt := relatedQuestionsPrompt
+	if lang := languageFor(ctx, question); lang != LanguageEnglish {
+		prompt += languageRelatedNote(lang)
+	}
+
+	req := &GenerateRequest{
+		Contents: []*Content{{
+			Parts: []*Part{{Text: string(payloadJSON)}},
+			Role:  "user",
+		}},
+		SystemInstruct: &Content{
+			Parts: []*Part{{Text: prompt}},
+			Role:  "system",
+		},
+		GenerationConfig: &GenerationConfig{
+			Temperature:        &temp,
+			MaxOutputTokens:    64000,
+			ResponseMIMEType:   "application/json",
+			ResponseJSONSchema: relatedQuestionsSchema,
+			ThinkingConfig:     &ThinkingConfig{ThinkingLevel: "low"},
+		},
+		SafetySettings: DefaultSafetySettings(),
+	}
+
+	resp, err := a.client.GenerateContent(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	var out struct {
+		Questions []string `json:"questions"`
+	}
+	if err := json.Unmarshal([]byte(resp.ExtractText()), &out); err != nil {
+		return nil, fmt.Errorf("failed to parse related questions: %w", err)
+	}
+	related := cleanRelatedQuestions(out.Questions, asked)
+	log.Printf("[related] %d of %d questions kept", len(related), len(out.Questions))
+	return related, nil
+}
+
+// cleanRelatedQuestions keeps the safe, distinct questions that fit a chip
+// and weren't already asked, up to maxRelatedQuestions.
+func cleanRelatedQuestions(questions, asked []string) []string {
+	seen := map[string]bool{}
+	for _, q := range asked {
+		seen[normalizeQuestion(q)] = true
+	}
+	var out []string
+	for _, q := range questions {
+		q = strings.Join(strings.Fields(q), " ")
+		key := normalizeQuestion(q)
+		if key == "" || seen[key] || utf8.RuneCountInString(q) > maxRelatedQuestionRunes {
+			continue
+		}
+		if ClassifyContent(q) != ContentSafe {
+			log.Printf("[related] Dropped unsafe question: %q", q)
+			continue
+		}
+		seen[key] = true
+		out = append(out, q)
+		if len(out) == maxRelatedQuestions {
+			break
+		}
+	}
+	return out
+}
+
+// normalizeQuestion is the form in which two questions count as the same.
+func normalizeQuestion(q string) string {
+	return strings.ToLower(strings.TrimRight(strings.Join(strings.Fields(q), " "), "?.! "))
+}
+
+// Schema for related questions response
+var relatedQuestionsSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"questions": map[string]any{
+			"type":     "array",
+			"minItems": 3,
+			"maxItems": 4,
+			"items": map[string]any{
+				"type":      "string",
+				"minLength": 10,
+				"maxLength": 100,
+			},
+		},
+	},
+	"required": []string{"questions"},
+}
+
+const relatedQuestionsPrompt = `You suggest what a visitor at Temple Square might ask next, after reading an answer to their question with quotes from Church leaders and scriptures.
+
+Write 4 short questions (at most 12 words each) in the visitor's own voice, e.g. "How can I feel God's love when I'm struggling?".
+
+REQUIREMENTS:
+- Ground each question in one of the talks (its title and topic) or scriptures listed, and cover different ones
+- Each question must be sincere, faith-centered, and answerable from General Conference talks and scriptures
+- Don't repeat the visitor's question or any earlier question, even reworded
+- No questions about controversial, political, or historical disputes
+- Don't mention speakers, talk titles, or references in the questions
+
+Return ONLY valid JSON in this exact format:
+{"questions":["...","...","...","..."]}`
@@ -0,0 +1,41 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCleanRelatedQuestions(t *testing.T) {
+	got := cleanRelatedQuestions([]string{
+		"How can I strengthen my faith?",
+		"  how can I   strengthen my faith ",        // duplicate
+		"What is faith",                             // already asked
+		"What did the Church teach about polygamy?", // redirected topic
+		"",
+		strings.Repeat("Why ", 40) + "?",
+		"How does repentance bring peace?",
+		"What does it mean to endure to the end?",
+		"How can I hear the Spirit more clearly?",
+		"Why do we make covenants with God?",
+	}, []string{"What is faith?"})
+
+	want := []string{
+		"How can I strengthen my faith?",
+		"How does repentance bring peace?",
+		"What does it mean to endure to the end?",
+		"How can I hear the Spirit more clearly?",
+	}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("cleanRelatedQuestions =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestRelatedQuestionsNeedsSources(t *testing.T) {
+	// Nothing retrieved means nothing to ground questions in, and no call
+	a := &ProphetAgent{}
+	got, err := a.RelatedQuestions(context.Background(), "What is faith?", nil, nil)
+	if err != nil || got != nil {
+		t.Errorf("RelatedQuestions = %v, %v", got, err)
+	}
+}
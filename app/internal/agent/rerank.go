@@ -0,0 +1,396 @@
+// Package agent provides the reranking stage between retrieval and
+// selection. With a reranker configured, each search over-fetches candidates
+// (ts_rank alone decides little), the reranker scores them against the
+// keywords, and only the top rows reach the formatter. Candidate and rerank
+// scores can be logged as JSON lines for offline evaluation.
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Reranker scores candidate texts against a query; higher is more relevant.
+// Scores are only compared within one call.
+type Reranker interface {
+	Name() string
+	Score(ctx context.Context, query string, texts []string) ([]float64, error)
+}
+
+// DefaultRerankFetch is how many candidates a search fetches for reranking.
+const DefaultRerankFetch = 30
+
+// rerankerFromEnv builds the reranker named by RERANKER (lexical,
+// cross-encoder or llm). Empty disables reranking.
+func rerankerFromEnv(client *GeminiClient) Reranker {
+	switch name := strings.ToLower(strings.TrimSpace(os.Getenv("RERANKER"))); name {
+	case "", "none", "off":
+		return nil
+	case "lexical":
+		return LexicalReranker{}
+	case "cross-encoder":
+		url := os.Getenv("RERANKER_URL")
+		if url == "" {
+			log.Printf("RERANKER=cross-encoder needs RERANKER_URL, reranking disabled")
+			return nil
+		}
+		return NewCrossEncoderReranker(url)
+	case "llm":
+		return &LLMReranker{client: client, batchSize: defaultLLMRerankBatch}
+	default:
+		log.Printf("Unknown RERANKER %q (want lexical, cross-encoder or llm), reranking disabled", name)
+		return nil
+	}
+}
+
+// rerankFetchFromEnv reads RERANK_FETCH, defaulting to DefaultRerankFetch.
+func rerankFetchFromEnv() int {
+	if v := os.Getenv("RERANK_FETCH"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+		log.Printf("Invalid RERANK_FETCH=%q, using %d", v, DefaultRerankFetch)
+	}
+	return DefaultRerankFetch
+}
+
+// LexicalReranker scores candidates with BM25 over the candidate set.
+type LexicalReranker struct{}
+
+func (LexicalReranker) Name() string { return "lexical" }
+
+func (LexicalReranker) Score(_ context.Context, query string, texts []string) ([]float64, error) {
+	passages := make([]passage, len(texts))
+	for i, text := range texts {
+		passages[i].terms = passageTerms(text)
+	}
+	scoreBM25(passages, queryTerms(query))
+	scores := make([]float64, len(texts))
+	for i, p := range passages {
+		scores[i] = p.score
+	}
+	return scores, nil
+}
+
+// CrossEncoderReranker calls a cross-encoder served over HTTP with the
+// text-embeddings-inference /rerank API.
+type CrossEncoderReranker struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewCrossEncoderReranker returns a reranker for the /rerank endpoint at url.
+func NewCrossEncoderReranker(url string) *CrossEncoderReranker {
+	return &CrossEncoderReranker{url: url, httpClient: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (r *CrossEncoderReranker) Name() string { return "cross-encoder" }
+
+func (r *CrossEncoderReranker) Score(ctx context.Context, query string, texts []string) ([]float64, error) {
+	body, err := json.Marshal(map[string]any{"query": query, "texts": texts, "truncate": true})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cross-encoder request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("cross-encoder error (status %d): %s", resp.StatusCode, data)
+	}
+	var ranked []struct {
+		Index int     `json:"index"`
+		Score float64 `json:"score"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&ranked); err != nil {
+		return nil, fmt.Errorf("failed to decode cross-encoder response: %w", err)
+	}
+	scores := make([]float64, len(texts))
+	for _, r := range ranked {
+		if r.Index >= 0 && r.Index < len(scores) {
+			scores[r.Index] = r.Score
+		}
+	}
+	return scores, nil
+}
+
+// defaultLLMRerankBatch is how many candidates go into one scoring call.
+const defaultLLMRerankBatch = 10
+
+// llmRerankSnippet bounds each candidate's text in the scoring prompt, in
+// characters.
+const llmRerankSnippet = 600
+
+// LLMReranker scores candidates with Gemini, in parallel batches.
+type LLMReranker struct {
+	client    *GeminiClient
+	batchSize int
+}
+
+func (r *LLMReranker) Name() string { return "llm" }
+
+var llmRerankSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"scores": map[string]any{
+			"type":  "array",
+			"items": map[string]any{"type": "number", "minimum": 0, "maximum": 10},
+		},
+	},
+	"required": []string{"scores"},
+}
+
+const llmRerankPrompt = `You are a relevance judge. Rate how well each numbered passage addresses the search keywords, from 0 (unrelated) to 10 (directly on topic).
+Return ONLY valid JSON with one score per passage, in passage order: {"scores":[...]}`
+
+func (r *LLMReranker) Score(ctx context.Context, query string, texts []string) ([]float64, error) {
+	scores := make([]float64, len(texts))
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+	for start := 0; start < len(texts); start += r.batchSize {
+		end := min(start+r.batchSize, len(texts))
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			batch, err := r.scoreBatch(ctx, query, texts[start:end])
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			copy(scores[start:end], batch)
+		}(start, end)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return scores, nil
+}
+
+func (r *LLMReranker) scoreBatch(ctx context.Context, query string, texts []string) ([]float64, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Keywords: %s\n", query)
+	for i, text := range texts {
+		if runes := []rune(text); len(runes) > llmRerankSnippet {
+			text = string(runes[:llmRerankSnippet]) + "..."
+		}
+		fmt.Fprintf(&b, "\n[%d] %s\n", i+1, text)
+	}
+
+	temp := float32(0.0)
+	req := &GenerateRequest{
+		Contents: []*Content{{
+			Parts: []*Part{{Text: b.String()}},
+			Role:  "user",
+		}},
+		SystemInstruct: &Content{
+			Parts: []*Part{{Text: llmRerankPrompt}},
+			Role:  "system",
+		},
+		GenerationConfig: &GenerationConfig{
+			Temperature:        &temp,
+			MaxOutputTokens:    512,
+			ResponseMIMEType:   "application/json",
+			ResponseJSONSchema: llmRerankSchema,
+			ThinkingConfig:     &ThinkingConfig{ThinkingLevel: "minimal"},
+		},
+		SafetySettings: DefaultSafetySettings(),
+	}
+	resp, err := r.client.GenerateContent(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	var out struct {
+		Scores []float64 `json:"scores"`
+	}
+	if err := json.Unmarshal([]byte(resp.ExtractText()), &out); err != nil {
+		return nil, fmt.Errorf("invalid rerank scores: %w", err)
+	}
+	if len(out.Scores) != len(texts) {
+		return nil, fmt.Errorf("got %d rerank scores for %d passages", len(out.Scores), len(texts))
+	}
+	return out.Scores, nil
+}
+
+// rerankCandidate is one logged candidate.
+type rerankCandidate struct {
+	RetrievalRank int     `json:"retrieval_rank"`
+	ID            string  `json:"id,omitempty"`
+	Label         string  `json:"label"`
+	Score         float64 `json:"rerank_score"`
+	Kept          bool    `json:"kept"`
+}
+
+// rerankRecord is one line of the rerank log.
+type rerankRecord struct {
+	Time       time.Time         `json:"time"`
+	Agent      string            `json:"agent"`
+	Reranker   string            `json:"reranker"`
+	Query      string            `json:"query"`
+	TopK       int               `json:"top_k"`
+	DurationMS int64             `json:"duration_ms"`
+	Candidates []rerankCandidate `json:"candidates"`
+}
+
+// rerankLog appends rerank records as JSON lines to RERANK_LOG, if set.
+var rerankLog = struct {
+	once sync.Once
+	mu   sync.Mutex
+	file *os.File
+}{}
+
+func writeRerankRecord(rec rerankRecord) {
+	rerankLog.once.Do(func() {
+		path := os.Getenv("RERANK_LOG")
+		if path == "" {
+			return
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			log.Printf("Failed to open RERANK_LOG %s: %v", path, err)
+			return
+		}
+		rerankLog.file = f
+	})
+	if rerankLog.file == nil {
+		return
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+	rerankLog.mu.Lock()
+	defer rerankLog.mu.Unlock()
+	rerankLog.file.Write(append(data, '\n'))
+}
+
+// overFetchArgs raises a search's limit to the rerank fetch size and returns
+// the limit the formatter expects. Without a reranker args are unchanged.
+func (a *ProphetAgent) overFetchArgs(args map[string]any) (map[string]any, int) {
+	topK, _ := args["limit"].(int)
+	if a.reranker == nil || topK <= 0 || topK >= a.rerankFetch {
+		return args, topK
+	}
+	fetched := make(map[string]any, len(args))
+	for k, v := range args {
+		fetched[k] = v
+	}
+	fetched["limit"] = a.rerankFetch
+	return fetched, topK
+}
+
+// rerankRows reorders an over-fetched tool result by rerank score and keeps
+// the top k rows, in the same JSON form Toolbox returns. If reranking
+// fails the rows keep their retrieval order.
+func (a *ProphetAgent) rerankRows(ctx context.Context, name, keywords string, result any, topK int) any {
+	var rows []map[string]any
+	if err := decodeRows(result, &rows); err != nil {
+		log.Printf("[%s] Rerank skipped, can't decode rows: %v", name, err)
+		return result
+	}
+	if len(rows) <= topK {
+		return result
+	}
+
+	texts := make([]string, len(rows))
+	for i, row := range rows {
+		texts[i] = rowText(row)
+	}
+	start := time.Now()
+	scores, err := a.reranker.Score(ctx, keywords, texts)
+	duration := time.Since(start)
+	order := make([]int, len(rows))
+	for i := range order {
+		order[i] = i
+	}
+	if err != nil {
+		log.Printf("[%s] %s rerank failed after %v, keeping retrieval order: %v", name, a.reranker.Name(), duration, err)
+		scores = make([]float64, len(rows))
+	} else {
+		sort.SliceStable(order, func(i, j int) bool { return scores[order[i]] > scores[order[j]] })
+	}
+
+	kept := make([]map[string]any, 0, topK)
+	rec := rerankRecord{
+		Time:       time.Now().UTC(),
+		Agent:      name,
+		Reranker:   a.reranker.Name(),
+		Query:      keywords,
+		TopK:       topK,
+		DurationMS: duration.Milliseconds(),
+	}
+	keptSet := map[int]bool{}
+	for _, i := range order[:topK] {
+		keptSet[i] = true
+		kept = append(kept, rows[i])
+	}
+	for i, row := range rows {
+		rec.Candidates = append(rec.Candidates, rerankCandidate{
+			RetrievalRank: i + 1,
+			ID:            rowID(row),
+			Label:         rowLabel(row),
+			Score:         scores[i],
+			Kept:          keptSet[i],
+		})
+	}
+	writeRerankRecord(rec)
+	log.Printf("[%s] %s rerank kept %d of %d candidates in %v", name, a.reranker.Name(), topK, len(rows), duration)
+
+	data, err := json.Marshal(kept)
+	if err != nil {
+		return result
+	}
+	return string(data)
+}
+
+// rowText is the text a reranker scores: a talk's title and content or a
+// verse's text.
+func rowText(row map[string]any) string {
+	if text, ok := row["verse_text"].(string); ok {
+		return text
+	}
+	title, _ := row["title"].(string)
+	content, _ := row["content"].(string)
+	return strings.TrimSpace(title + "\n" + content)
+}
+
+func rowID(row map[string]any) string {
+	for _, key := range []string{"talk_id", "verse_id", "id"} {
+		if v, ok := row[key]; ok && v != nil {
+			return fmt.Sprint(v)
+		}
+	}
+	return ""
+}
+
+func rowLabel(row map[string]any) string {
+	if book, ok := row["book_name"].(string); ok {
+		return fmt.Sprintf("%s %v:%v", book, row["chapter_number"], row["verse_number"])
+	}
+	speaker, _ := row["speaker"].(string)
+	title, _ := row["title"].(string)
+	return speaker + ": " + title
+}
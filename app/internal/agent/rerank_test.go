@@ -0,0 +1,88 @@
+// Package agent tests the reranking stage.
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// TestRerankRowsKeepsTopK verifies over-fetched rows are reordered by score and cut to the limit
+func TestRerankRowsKeepsTopK(t *testing.T) {
+	a := &ProphetAgent{reranker: LexicalReranker{}, rerankFetch: DefaultRerankFetch}
+
+	args, topK := a.overFetchArgs(map[string]any{"query": "prayer", "limit": 2})
+	if topK != 2 || args["limit"] != DefaultRerankFetch {
+		t.Fatalf("overFetchArgs = %v, %d; want limit %d and topK 2", args, topK, DefaultRerankFetch)
+	}
+
+	rows := `[
+		{"talk_id": "a", "speaker": "A", "title": "Ministering", "content": "Serve your neighbors."},
+		{"talk_id": "b", "speaker": "B", "title": "Prayer", "content": "Pray always; prayer brings answers."},
+		{"talk_id": "c", "speaker": "C", "title": "Temples", "content": "The temple is a house of prayer."}
+	]`
+	kept := a.rerankRows(context.Background(), "leaders_q12_a", "prayer answers", rows, topK)
+
+	var got []map[string]any
+	if err := json.Unmarshal([]byte(kept.(string)), &got); err != nil {
+		t.Fatalf("reranked result is not a JSON row list: %v", err)
+	}
+	if len(got) != 2 || got[0]["talk_id"] != "b" || got[1]["talk_id"] != "c" {
+		t.Errorf("expected rows b, c; got %v", got)
+	}
+}
+
+// TestCrossEncoderReranker verifies scores are mapped back to input order
+func TestCrossEncoderReranker(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query string   `json:"query"`
+			Texts []string `json:"texts"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Texts) != 3 {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`[{"index": 2, "score": 0.9}, {"index": 0, "score": 0.5}, {"index": 1, "score": 0.1}]`))
+	}))
+	defer srv.Close()
+
+	scores, err := NewCrossEncoderReranker(srv.URL).Score(context.Background(), "faith", []string{"x", "y", "z"})
+	if err != nil {
+		t.Fatalf("Score failed: %v", err)
+	}
+	if scores[0] != 0.5 || scores[1] != 0.1 || scores[2] != 0.9 {
+		t.Errorf("unexpected scores %v", scores)
+	}
+}
+
+// TestLLMRerankerTruncatesOnCharacters verifies long candidates are cut on a
+// character boundary, so the prompt stays valid UTF-8
+func TestLLMRerankerTruncatesOnCharacters(t *testing.T) {
+	stub := &stubGemini{text: `{"scores": [7]}`}
+	client, err := NewGeminiClient("test-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.httpClient = &http.Client{Transport: stub}
+
+	// "—" is three bytes, so a byte cut at llmRerankSnippet splits one
+	text := "a" + strings.Repeat("—", llmRerankSnippet)
+	scores, err := (&LLMReranker{client: client, batchSize: defaultLLMRerankBatch}).Score(context.Background(), "faith", []string{text})
+	if err != nil || len(scores) != 1 || scores[0] != 7 {
+		t.Fatalf("Score = %v, %v", scores, err)
+	}
+
+	prompt := stub.requests[0].Contents[0].Parts[0].Text
+	if !utf8.ValidString(prompt) {
+		t.Error("Expected the prompt to be valid UTF-8")
+	}
+	want := string([]rune(text)[:llmRerankSnippet]) + "..."
+	if !strings.Contains(prompt, "[1] "+want+"\n") {
+		t.Errorf("Expected the candidate cut to %d characters, got %q", llmRerankSnippet, prompt)
+	}
+}
@@ -0,0 +1,288 @@
+// Package agent models the leadership roster: who held which calling from
+// when to when. Section membership (who is President, who are the
+// counselors) and the callings shown on cards are read from the roster for
+// a date, so a change in leadership is a new row in the callings table
+// rather than a code change.
+package agent
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const rosterDateLayout = "2006-01-02"
+
+// RosterEntry is one calling held by a speaker. End is empty while the
+// calling is current; a calling ends on End (exclusive).
+type RosterEntry struct {
+	Slug    string `json:"name_slug"`
+	Calling string `json:"calling"`
+	Start   string `json:"starts_on"`
+	End     string `json:"ends_on,omitempty"`
+
+	start, end time.Time
+}
+
+func (e RosterEntry) activeAt(t time.Time) bool {
+	return !t.Before(e.start) && (e.end.IsZero() || t.Before(e.end))
+}
+
+func (e RosterEntry) isPresident() bool {
+	return presidencyRank(e.Calling) == 0 && quorumForCalling(e.Calling) == QuorumFirstPresidency
+}
+
+//go:embed roster.json
+var defaultRosterJSON []byte
+
+// defaultRoster returns the roster embedded in the binary, used until the
+// callings table loads.
+func defaultRoster() []RosterEntry {
+	var file struct {
+		Callings []RosterEntry `json:"callings"`
+	}
+	if err := json.Unmarshal(defaultRosterJSON, &file); err != nil {
+		panic(fmt.Sprintf("invalid embedded roster: %v", err))
+	}
+	entries, err := parseRoster(file.Callings)
+	if err != nil {
+		panic(fmt.Sprintf("invalid embedded roster: %v", err))
+	}
+	return entries
+}
+
+// parseRoster validates entries and parses their dates.
+func parseRoster(entries []RosterEntry) ([]RosterEntry, error) {
+	out := make([]RosterEntry, 0, len(entries))
+	for _, e := range entries {
+		if e.Slug == "" || e.Calling == "" {
+			return nil, fmt.Errorf("roster entry %+v needs a slug and a calling", e)
+		}
+		start, err := time.Parse(rosterDateLayout, e.Start)
+		if err != nil {
+			return nil, fmt.Errorf("roster entry for %s: bad starts_on: %w", e.Slug, err)
+		}
+		e.start = start
+		if e.End != "" {
+			end, err := time.Parse(rosterDateLayout, e.End)
+			if err != nil {
+				return nil, fmt.Errorf("roster entry for %s: bad ends_on: %w", e.Slug, err)
+			}
+			if !end.After(start) {
+				return nil, fmt.Errorf("roster entry for %s ends before it starts", e.Slug)
+			}
+			e.end = end
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+// setRoster indexes entries by slug, each speaker's sorted by start date.
+func (r *SpeakerRegistry) setRoster(entries []RosterEntry) {
+	roster := map[string][]RosterEntry{}
+	for _, e := range entries {
+		roster[e.Slug] = append(roster[e.Slug], e)
+	}
+	for _, list := range roster {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].start.Before(list[j].start) })
+	}
+	r.mu.Lock()
+	r.roster = roster
+	r.mu.Unlock()
+}
+
+// loadRoster replaces the roster from the callings table. An empty table
+// keeps the current roster.
+func (r *SpeakerRegistry) loadRoster(ctx context.Context, listCallings func(context.Context, map[string]any) (any, error)) error {
+	result, err := listCallings(ctx, map[string]any{})
+	if err != nil {
+		return fmt.Errorf("list_callings failed: %w", err)
+	}
+	var rows []RosterEntry
+	if err := decodeRows(result, &rows); err != nil {
+		return fmt.Errorf("failed to decode callings: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	entries, err := parseRoster(rows)
+	if err != nil {
+		return err
+	}
+	r.setRoster(entries)
+	log.Printf("Roster loaded %d callings", len(entries))
+	return nil
+}
+
+// resolveLocked returns sp with the calling, quorum and title it had at t.
+// Where the roster covers t for the speaker it wins over the speakers
+// table, including when it says the speaker held no calling (e.g. a former
+// President); the title then comes from their latest calling. Before the
+// speaker's first roster entry their calling isn't known, so there is none.
+func (r *SpeakerRegistry) resolveLocked(sp *Speaker, t time.Time) Speaker {
+	out := *sp
+	titleCalling := sp.Calling
+	var current, latest *RosterEntry
+	entries := r.roster[sp.Slug]
+	for i := range entries {
+		if entries[i].start.After(t) {
+			continue
+		}
+		latest = &entries[i]
+		if latest.activeAt(t) {
+			current = latest
+		}
+	}
+	switch {
+	case current != nil:
+		out.Calling, titleCalling = current.Calling, current.Calling
+	case latest != nil:
+		out.Calling, titleCalling = "", latest.Calling
+	case len(entries) > 0:
+		out.Calling, titleCalling = "", ""
+	}
+	out.Quorum = quorumForCalling(out.Calling)
+	out.Title = titleFor(titleCalling)
+	return out
+}
+
+// PresidentAt returns the President of the Church at t.
+func (r *SpeakerRegistry) PresidentAt(t time.Time) (Speaker, bool) {
+	members := r.MembersAt(QuorumFirstPresidency, t)
+	if len(members) > 0 && members[0].IsPresident() {
+		return members[0], true
+	}
+	return Speaker{}, false
+}
+
+// CounselorsAt returns the First Presidency's counselors at t, first
+// counselor first.
+func (r *SpeakerRegistry) CounselorsAt(t time.Time) []Speaker {
+	var counselors []Speaker
+	for _, sp := range r.MembersAt(QuorumFirstPresidency, t) {
+		if !sp.IsPresident() {
+			counselors = append(counselors, sp)
+		}
+	}
+	return counselors
+}
+
+// FormerPresidentsAt returns the speakers whose service as President of the
+// Church ended by t, most recent first.
+func (r *SpeakerRegistry) FormerPresidentsAt(t time.Time) []Speaker {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	type former struct {
+		sp    Speaker
+		ended time.Time
+	}
+	var formers []former
+	for _, sp := range r.ordered {
+		for _, e := range r.roster[sp.Slug] {
+			if e.isPresident() && !e.end.IsZero() && !e.end.After(t) {
+				if resolved := r.resolveLocked(sp, t); !resolved.IsPresident() {
+					formers = append(formers, former{resolved, e.end})
+				}
+				break
+			}
+		}
+	}
+	sort.SliceStable(formers, func(i, j int) bool { return formers[i].ended.After(formers[j].ended) })
+	out := make([]Speaker, len(formers))
+	for i, f := range formers {
+		out[i] = f.sp
+	}
+	return out
+}
+
+// PresidentsAt returns the speakers of the presidents section at t: the
+// President, then former Presidents.
+func (r *SpeakerRegistry) PresidentsAt(t time.Time) []Speaker {
+	var out []Speaker
+	if president, ok := r.PresidentAt(t); ok {
+		out = append(out, president)
+	}
+	return append(out, r.FormerPresidentsAt(t)...)
+}
+
+// lineup is who each of the named search agents covers.
+type lineup struct {
+	president       Speaker   // presidents_current
+	formerPresident Speaker   // presidents_former
+	presidents      []Speaker // presidents_general
+	firstCounselor  Speaker   // leaders_first_counselor
+	secondCounselor Speaker   // leaders_second_counselor
+	exclude         []string  // slugs get_leaders_talks leaves to the agents above
+}
+
+// lineupAt builds the lineup from the roster at t. Callings nobody holds
+// leave a zero Speaker.
+func (r *SpeakerRegistry) lineupAt(t time.Time) lineup {
+	var l lineup
+	l.presidents = r.PresidentsAt(t)
+	if president, ok := r.PresidentAt(t); ok {
+		l.president = president
+	}
+	if formers := r.FormerPresidentsAt(t); len(formers) > 0 {
+		l.formerPresident = formers[0]
+	}
+	counselors := r.CounselorsAt(t)
+	for _, sp := range counselors {
+		switch presidencyRank(sp.Calling) {
+		case 1:
+			l.firstCounselor = sp
+		case 2:
+			l.secondCounselor = sp
+		}
+	}
+	l.exclude = slugsOf(append(append([]Speaker{}, l.presidents...), counselors...))
+	return l
+}
+
+// namedPrompt fills a prompt template's {speaker} with the first speaker's
+// display name and {speakers} with all of them.
+func namedPrompt(template string, speakers ...Speaker) string {
+	names := make([]string, len(speakers))
+	for i, sp := range speakers {
+		names[i] = sp.DisplayName()
+	}
+	first := ""
+	if len(names) > 0 {
+		first = names[0]
+	}
+	return strings.NewReplacer("{speakers}", strings.Join(names, " or "), "{speaker}", first).Replace(template)
+}
+
+// Now is the registry's current time, the date sections are built for.
+func (r *SpeakerRegistry) Now() time.Time {
+	return r.now()
+}
+
+var (
+	conferenceMonthPattern = regexp.MustCompile(`(?i)\b(january|february|march|april|may|june|july|august|september|october|november|december)\b`)
+	conferenceYearPattern  = regexp.MustCompile(`\b(19|20)\d{2}\b`)
+)
+
+// ConferenceDate returns the first day of a conference's month, e.g. for
+// "October 2024" or "April 2024 General Conference".
+func ConferenceDate(conference string) (time.Time, bool) {
+	month := conferenceMonthPattern.FindString(conference)
+	year := conferenceYearPattern.FindString(conference)
+	if month == "" || year == "" {
+		return time.Time{}, false
+	}
+	m, err := time.Parse("January", strings.ToUpper(month[:1])+strings.ToLower(month[1:]))
+	if err != nil {
+		return time.Time{}, false
+	}
+	y, _ := strconv.Atoi(year)
+	return time.Date(y, m.Month(), 1, 0, 0, 0, 0, time.UTC), true
+}
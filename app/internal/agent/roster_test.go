@@ -0,0 +1,105 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse(rosterDateLayout, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestRosterFollowsCallingChanges(t *testing.T) {
+	r := loadedRegistry(t)
+
+	before, after := date("2024-04-06"), date("2025-11-01")
+	if p, _ := r.PresidentAt(before); p.Slug != "russell-nelson" {
+		t.Errorf("President in 2024 = %q", p.Slug)
+	}
+	if p, _ := r.PresidentAt(after); p.Slug != "dallin-oaks" {
+		t.Errorf("President after October 2025 = %q", p.Slug)
+	}
+	if got := slugsOf(r.CounselorsAt(after)); len(got) != 2 || got[0] != "henry-eyring" || got[1] != "todd-christofferson" {
+		t.Errorf("counselors after October 2025 = %v", got)
+	}
+	if sp, _ := r.LookupAt("Elder Christofferson", before); sp.Quorum != QuorumTwelve || sp.Title != "Elder" {
+		t.Errorf("Christofferson in 2024 = %+v", sp)
+	}
+
+	l := r.lineupAt(after)
+	if l.president.Slug != "dallin-oaks" || l.formerPresident.Slug != "russell-nelson" || l.secondCounselor.Slug != "todd-christofferson" {
+		t.Errorf("lineup = %+v", l)
+	}
+	if got := slugsOf(l.presidents); len(got) != 2 || got[0] != "dallin-oaks" || got[1] != "russell-nelson" {
+		t.Errorf("presidents section = %v", got)
+	}
+	if got := namedPrompt(presidentsGeneralPrompt, l.presidents...); !containsAll(got, "President Dallin H. Oaks or President Russell M. Nelson", `"speaker":"President Dallin H. Oaks"`) {
+		t.Errorf("prompt = %s", got)
+	}
+}
+
+func TestRosterNoCallingBeforeFirstEntry(t *testing.T) {
+	r := loadedRegistry(t)
+
+	// The roster starts in 2018 for Oaks and Nelson
+	for _, name := range []string{"Dallin H. Oaks", "Russell M. Nelson"} {
+		if sp, ok := r.LookupAt(name, date("2015-10-01")); !ok || sp.Calling != "" || sp.Quorum != QuorumOther || sp.Title != "" {
+			t.Errorf("%s in 2015 = %+v, want no calling", name, sp)
+		}
+	}
+	if _, ok := r.PresidentAt(date("2015-10-01")); ok {
+		t.Error("expected no President before the roster starts")
+	}
+	// Speakers the roster doesn't list keep the speakers table's calling
+	for _, sp := range r.ordered {
+		if _, listed := r.roster[sp.Slug]; !listed {
+			if then, _ := r.LookupAt(sp.Name, date("2015-10-01")); then.Calling != sp.Calling {
+				t.Errorf("%s in 2015 = %q, want %q", sp.Name, then.Calling, sp.Calling)
+			}
+			break
+		}
+	}
+}
+
+func TestRosterFromCallingsTable(t *testing.T) {
+	r := NewSpeakerRegistry()
+	callings := `[
+{"name_slug":"dallin-oaks","calling":"President of The Church of Jesus Christ of Latter-day Saints","starts_on":"2025-10-14","ends_on":"2030-01-01"},
+{"name_slug":"henry-eyring","calling":"President of The Church of Jesus Christ of Latter-day Saints","starts_on":"2030-01-01","ends_on":""}]`
+	err := r.Load(context.Background(), func(context.Context, map[string]any) (any, error) {
+		return speakersJSON, nil
+	}, func(context.Context, map[string]any) (any, error) {
+		return callings, nil
+	})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if p, _ := r.PresidentAt(date("2030-06-01")); p.Slug != "henry-eyring" {
+		t.Errorf("President = %q, want the callings table's", p.Slug)
+	}
+}
+
+func TestConferenceDate(t *testing.T) {
+	got, ok := ConferenceDate("October 2024 General Conference")
+	if !ok || !got.Equal(date("2024-10-01")) {
+		t.Errorf("ConferenceDate = %v, %v", got, ok)
+	}
+	if _, ok := ConferenceDate("Devotional"); ok {
+		t.Error("expected no date")
+	}
+}
+
+func containsAll(s string, subs ...string) bool {
+	for _, sub := range subs {
+		if !strings.Contains(s, sub) {
+			return false
+		}
+	}
+	return true
+}
@@ -20,114 +20,168 @@ const (
 
 // RedirectResponse is returned for controversial/inappropriate content
 type RedirectResponse struct {
-	Message            string   `json:"message"`
-	SuggestedQuestions []string `json:"suggested_questions"`
+	Message            string              `json:"message"`
+	SuggestedQuestions []string            `json:"suggested_questions"`
+	Suggestions        []SuggestedQuestion `json:"suggestions,omitempty"`
+}
+
+// contentPattern tags a safety regex with the topic category it detects.
+// Categories drive which suggested questions are offered in a redirect.
+type contentPattern struct {
+	category string
+	pattern  string
+}
+
+type categorizedRegex struct {
+	category string
+	re       *regexp.Regexp
 }
 
 var (
-	// Controversial topics that should trigger redirect
-	controversialPatterns = []string{
+	// Controversial topics that should trigger redirect. Patterns cover
+	// English, Spanish and Portuguese.
+	controversialPatterns = []contentPattern{
 		// Historical/doctrinal controversies
-		`(?i)polygamy|plural.?marriage|multiple.?wives`,
-		`(?i)mountain.?meadows`,
-		`(?i)book.?of.?abraham.*papyrus|papyri`,
-		`(?i)seer.?stone|hat.*translation`,
-		`(?i)first.?vision.*versions?`,
-		`(?i)blacks?.*priesthood|priesthood.*ban`,
-		`(?i)masonic|freemasonry`,
+		{"church_history", `(?i)polygamy|plural.?marriage|multiple.?wives`},
+		{"church_history", `(?i)poligamia|matrimonio.?plural|casamento.?plural|(varias|v[aá]rias|m[uú]ltiples).?esposas`},
+		{"church_history", `(?i)mountain.?meadows`},
+		{"church_history", `(?i)book.?of.?abraham.*papyrus|papyri`},
+		{"church_history", `(?i)seer.?stone|hat.*translation`},
+		{"church_history", `(?i)piedra.?de.?vidente|pedra.?de.?vidente`},
+		{"church_history", `(?i)first.?vision.*versions?`},
+		{"church_history", `(?i)blacks?.*priesthood|priesthood.*ban`},
+		{"church_history", `(?i)negros.*sacerd[oó]cio|sacerd[oó]cio.*negros`},
+		{"church_history", `(?i)masonic|freemasonry|mas[oó]nic|masoner[ií]a|ma[cç]onaria`},
 
 		// Political topics
-		`(?i)democrat|republican|trump|biden|politic`,
-		`(?i)abortion|pro.?life|pro.?choice`,
-		`(?i)gun.?control|second.?amendment`,
-		`(?i)immigration.?policy|border.?wall`,
-		`(?i)climate.?change.?hoax|global.?warming.?fake`,
+		{"politics", `(?i)democrat|republican|trump|biden|politic|pol[ií]tic|dem[oó]crata`},
+		{"politics", `(?i)abortion|pro.?life|pro.?choice|aborto`},
+		{"politics", `(?i)gun.?control|second.?amendment|control.?de.?armas|controle.?de.?armas`},
+		{"politics", `(?i)immigration.?policy|border.?wall`},
+		{"politics", `(?i)climate.?change.?hoax|global.?warming.?fake`},
 
 		// LGBTQ+ topics (redirect to missionaries, not appropriate for kiosk)
-		`(?i)gay.?marriage|same.?sex|homosexual|lgbtq|transgender`,
+		{"social_issues", `(?i)gay.?marriage|same.?sex|homosexual|lgbtq|transgender`},
+		{"social_issues", `(?i)(matrimonio|casamento).?(gay|homosexual|igualitario)|mismo.?sexo|mesmo.?sexo|homossexual|transg[eé]ner`},
 
 		// Anti-Mormon content
-		`(?i)cult|brainwash|false.?prophet`,
-		`(?i)cesletter|ces.?letter|mormonthink`,
-		`(?i)exmormon|ex.?mormon|left.?the.?church`,
+		// Whole words only: "culto" is a worship service and "difficult" is not a cult
+		{"critics", `(?i)\bcults?\b|cultish|brainwash|false.?prophet`},
+		{"critics", `(?i)\bsecta\b|\bseita\b|lavado.?de.?cerebro|lavagem.?cerebral|fals[oa]s?.?profeta`},
+		{"critics", `(?i)cesletter|ces.?letter|mormonthink`},
+		{"critics", `(?i)exmormon|ex.?mormon|left.?the.?church|ex.?m[oó]rm|dej[eé].?la.?iglesia|sa[ií].?da.?igreja`},
 
 		// Financial
-		`(?i)church.?wealth|100.?billion|tithing.?fraud`,
+		{"finances", `(?i)church.?wealth|100.?billion|tithing.?fraud`},
+		{"finances", `(?i)riqueza.?de.?la.?iglesia|riqueza.?da.?igreja|fraude.?(del|do).?d[ií]zimo`},
 	}
 
-	// Inappropriate content that should be blocked
-	inappropriatePatterns = []string{
-		`(?i)fuck|shit|damn|ass|bitch|bastard`,
-		`(?i)porn|xxx|nude|naked|sex`,
-		`(?i)kill|murder|violence|attack`,
-		`(?i)hack|exploit|jailbreak|bypass`,
-		`(?i)drug|cocaine|heroin|meth`,
+	// Inappropriate content that should be blocked, in English, Spanish and
+	// Portuguese. Short English words are matched whole, so "assim", "sexta"
+	// and "madrugada" aren't caught.
+	inappropriatePatterns = []contentPattern{
+		{"profanity", `(?i)fuck|shit|damn|\b(ass|asses|asshole|jackass|dumbass)\b|bitch|bastard`},
+		{"profanity", `(?i)\b(mierda|puta|putas|joder|carajo|pendejo|cabr[oó]n|merda|porra|caralho|foda)\b`},
+		{"explicit", `(?i)porn|xxx|nude|naked|\bsex(ual|y)?\b`},
+		{"explicit", `(?i)\b(desnud[oa]s?|nuas?)\b`},
+		{"violence", `(?i)kill|murder|violence|attack`},
+		{"violence", `(?i)\b(matar|asesinar|asesinato|assassinar|assassinato|violencia|viol[eê]ncia)\b`},
+		{"manipulation", `(?i)hack|exploit|jailbreak|bypass`},
+		{"substances", `(?i)\bdrugs?\b|cocaine|heroin|\bmeth(amphetamine)?\b`},
+		{"substances", `(?i)\b(drogas?|coca[ií]na|hero[ií]na)\b`},
 		// Violence/harm patterns
-		`(?i)\b(harm|hurt|injure|wound|maim)\b`,
-		`(?i)\b(how\s+to|ways?\s+to)\s+(harm|hurt|kill|attack|injure)`,
-		`(?i)\b(weapon|bomb|gun|knife|poison)\b`,
+		{"violence", `(?i)\b(harm|hurt|injure|wound|maim)\b`},
+		{"violence", `(?i)\b(how\s+to|ways?\s+to)\s+(harm|hurt|kill|attack|injure)`},
+		{"violence", `(?i)\b(weapon|bomb|gun|knife|poison)\b`},
+		{"violence", `(?i)\b(armas?|bombas?|pistola|cuchillo|faca|veneno)\b`},
 		// Self-harm patterns
-		`(?i)\b(suicide|self[- ]?harm|cut\s+myself|end\s+my\s+life)\b`,
+		{"self_harm", `(?i)\b(suicide|self[- ]?harm|cut\s+myself|end\s+my\s+life)\b`},
+		{"self_harm", `(?i)suicid|autolesi|cortarme|me\s+cortar|quitarme\s+la\s+vida|tirar\s+(a\s+)?minha\s+vida`},
 		// Illegal activities
-		`(?i)\b(how\s+to\s+(steal|hack|break\s+into|get\s+drugs))\b`,
+		{"illegal", `(?i)\b(how\s+to\s+(steal|hack|break\s+into|get\s+drugs))\b`},
+		{"illegal", `(?i)\bc[oó]mo\s+(robar|roubar|conseguir\s+drogas)\b`},
 	}
 
-	controversialRegexes []*regexp.Regexp
-	inappropriateRegexes []*regexp.Regexp
+	controversialRegexes []categorizedRegex
+	inappropriateRegexes []categorizedRegex
 )
 
 func init() {
 	for _, p := range controversialPatterns {
-		controversialRegexes = append(controversialRegexes, regexp.MustCompile(p))
+		controversialRegexes = append(controversialRegexes, categorizedRegex{category: p.category, re: regexp.MustCompile(p.pattern)})
 	}
 	for _, p := range inappropriatePatterns {
-		inappropriateRegexes = append(inappropriateRegexes, regexp.MustCompile(p))
+		inappropriateRegexes = append(inappropriateRegexes, categorizedRegex{category: p.category, re: regexp.MustCompile(p.pattern)})
 	}
 }
 
 // ClassifyContent determines if user input is safe, controversial, or inappropriate
 func ClassifyContent(input string) ContentClassification {
+	classification, _ := ClassifyContentCategory(input)
+	return classification
+}
+
+// ClassifyContentCategory classifies user input and also reports the topic
+// category of the first matching pattern (empty for safe content).
+func ClassifyContentCategory(input string) (ContentClassification, string) {
 	// Check inappropriate first (higher priority)
-	for _, re := range inappropriateRegexes {
-		if re.MatchString(input) {
-			return ContentInappropriate
+	for _, cr := range inappropriateRegexes {
+		if cr.re.MatchString(input) {
+			return ContentInappropriate, cr.category
 		}
 	}
 
 	// Check controversial
-	for _, re := range controversialRegexes {
-		if re.MatchString(input) {
-			return ContentControversial
+	for _, cr := range controversialRegexes {
+		if cr.re.MatchString(input) {
+			return ContentControversial, cr.category
 		}
 	}
 
-	return ContentSafe
+	return ContentSafe, ""
 }
 
-// GetRedirectResponse returns the appropriate redirect response
-func GetRedirectResponse(classification ContentClassification) RedirectResponse {
-	baseMessage := "That's an interesting question that deserves a thoughtful conversation. " +
-		"The missionaries here at the conference center would love to explore it with you in greater depth. " +
-		"Please reach out to them to discuss this topic further."
+// redirectMessages are the redirect messages by language, for
+// controversial and for inappropriate questions.
+var redirectMessages = map[Language][2]string{
+	LanguageEnglish: {
+		"That's an interesting question that deserves a thoughtful conversation. " +
+			"The missionaries here at the conference center would love to explore it with you in greater depth. " +
+			"Please reach out to them to discuss this topic further.",
+		"I'd love to help you with questions about the gospel and teachings of Jesus Christ. " +
+			"Let me suggest some meaningful topics we could explore together.",
+	},
+	LanguageSpanish: {
+		"Esa es una pregunta interesante que merece una conversación atenta. " +
+			"A los misioneros aquí en el centro de conferencias les encantaría explorarla contigo con más profundidad. " +
+			"Por favor, acércate a ellos para hablar más sobre este tema.",
+		"Me encantaría ayudarte con preguntas sobre el Evangelio y las enseñanzas de Jesucristo. " +
+			"Permíteme sugerirte algunos temas significativos que podríamos explorar juntos.",
+	},
+	LanguagePortuguese: {
+		"Essa é uma pergunta interessante que merece uma conversa cuidadosa. " +
+			"Os missionários aqui no centro de conferências adorariam explorá-la com você com mais profundidade. " +
+			"Procure-os para conversar mais sobre esse assunto.",
+		"Eu adoraria ajudar você com perguntas sobre o evangelho e os ensinamentos de Jesus Cristo. " +
+			"Deixe-me sugerir alguns temas significativos que podemos explorar juntos.",
+	},
+}
 
+// redirectMessage returns the redirect message for a classification in a
+// locale's language, English for unsupported locales.
+func redirectMessage(classification ContentClassification, locale string) string {
+	lang, _ := ParseLanguage(locale)
+	messages := redirectMessages[lang]
 	if classification == ContentInappropriate {
-		baseMessage = "I'd love to help you with questions about the gospel and teachings of Jesus Christ. " +
-			"Let me suggest some meaningful topics we could explore together."
+		return messages[1]
 	}
+	return messages[0]
+}
 
-	return RedirectResponse{
-		Message: baseMessage,
-		SuggestedQuestions: []string{
-			"Does God really exist?",
-			"What is the purpose of life?",
-			"Where can I find peace and joy?",
-			"Why do bad things happen to good people?",
-			"What happens after I die?",
-			"How can families be together forever?",
-			"Who is Jesus Christ?",
-			"What is faith?",
-		},
-	}
+// GetRedirectResponse returns the appropriate redirect response using the
+// default suggestion catalog in English.
+func GetRedirectResponse(classification ContentClassification) RedirectResponse {
+	return DefaultSuggestionCatalog().Redirect(classification, "", "en")
 }
 
 // SanitizeForDisplay removes any potentially harmful content from display
@@ -0,0 +1,218 @@
+// Package agent provides a shared scheduler for Gemini requests.
+// All sessions share one in-flight budget; when it is exhausted, waiting calls
+// are admitted by priority class so the early sections of every session get
+// through before the tail sections of any other session.
+package agent
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Priority orders Gemini calls when the scheduler is saturated. Lower values
+// are admitted first.
+type Priority int
+
+const (
+	PriorityOrchestrator Priority = iota
+	PriorityPresidents
+	PriorityLeaders
+	PriorityScriptures
+	PrioritySummary
+
+	numPriorities
+)
+
+// DefaultMaxInFlight is the default number of concurrent Gemini requests.
+const DefaultMaxInFlight = 24
+
+var priorityNames = [numPriorities]string{"orchestrator", "presidents", "leaders", "scriptures", "summary"}
+
+// String returns the metric label for the priority.
+func (p Priority) String() string {
+	if p < 0 || p >= numPriorities {
+		return "unknown"
+	}
+	return priorityNames[p]
+}
+
+type priorityKey struct{}
+
+// WithPriority tags ctx so Gemini calls made with it are scheduled at p.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// priorityFrom returns the priority carried by ctx. Untagged calls are
+// treated as background work.
+func priorityFrom(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok && p >= 0 && p < numPriorities {
+		return p
+	}
+	return PrioritySummary
+}
+
+// searchPriority maps a search agent name to its section's priority.
+func searchPriority(name string) Priority {
+	switch {
+	case strings.HasPrefix(name, "presidents_"):
+		return PriorityPresidents
+	case strings.HasPrefix(name, "leaders_"):
+		return PriorityLeaders
+	default:
+		return PriorityScriptures
+	}
+}
+
+// PriorityStats reports queueing for one priority class.
+type PriorityStats struct {
+	Priority  string  `json:"priority"`
+	Requests  uint64  `json:"requests"`
+	Queued    uint64  `json:"queued"`
+	Waiting   int     `json:"waiting"`
+	InFlight  int     `json:"in_flight"`
+	AvgWaitMS float64 `json:"avg_wait_ms"`
+	MaxWaitMS float64 `json:"max_wait_ms"`
+}
+
+// SchedulerStats is a snapshot of the scheduler's state and wait metrics.
+type SchedulerStats struct {
+	MaxInFlight int             `json:"max_in_flight"`
+	InFlight    int             `json:"in_flight"`
+	Priorities  []PriorityStats `json:"priorities"`
+}
+
+type schedulerWaiter struct {
+	ready    chan struct{}
+	enqueued time.Time
+}
+
+type priorityCounters struct {
+	requests  uint64
+	queued    uint64
+	admitted  uint64
+	inFlight  int
+	totalWait time.Duration
+	maxWait   time.Duration
+}
+
+// llmScheduler bounds in-flight Gemini requests and admits waiters in
+// priority order, FIFO within a priority.
+type llmScheduler struct {
+	mu          sync.Mutex
+	maxInFlight int
+	inFlight    int
+	queues      [numPriorities][]*schedulerWaiter
+	counters    [numPriorities]priorityCounters
+}
+
+func newLLMScheduler(maxInFlight int) *llmScheduler {
+	if maxInFlight < 1 {
+		maxInFlight = DefaultMaxInFlight
+	}
+	return &llmScheduler{maxInFlight: maxInFlight}
+}
+
+// acquire waits for an in-flight slot at priority p. The returned release
+// must be called once the request (including any streamed body) is done.
+func (s *llmScheduler) acquire(ctx context.Context, p Priority) (func(), error) {
+	s.mu.Lock()
+	s.counters[p].requests++
+	if s.inFlight < s.maxInFlight && !s.waitingAtOrAboveLocked(p) {
+		s.inFlight++
+		s.counters[p].inFlight++
+		s.mu.Unlock()
+		return s.releaser(p), nil
+	}
+	w := &schedulerWaiter{ready: make(chan struct{}), enqueued: time.Now()}
+	s.queues[p] = append(s.queues[p], w)
+	s.counters[p].queued++
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return s.releaser(p), nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for i, queued := range s.queues[p] {
+			if queued == w {
+				s.queues[p] = append(s.queues[p][:i], s.queues[p][i+1:]...)
+				return nil, ctx.Err()
+			}
+		}
+		// Admitted concurrently with cancellation; give the slot back.
+		s.releaseLocked(p)
+		return nil, ctx.Err()
+	}
+}
+
+// waitingAtOrAboveLocked reports whether anyone at priority p or higher is
+// queued, so a newcomer can't jump ahead of them.
+func (s *llmScheduler) waitingAtOrAboveLocked(p Priority) bool {
+	for q := Priority(0); q <= p; q++ {
+		if len(s.queues[q]) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *llmScheduler) releaser(p Priority) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.releaseLocked(p)
+		})
+	}
+}
+
+// releaseLocked frees a slot held at priority p and admits the next waiter.
+func (s *llmScheduler) releaseLocked(p Priority) {
+	s.inFlight--
+	s.counters[p].inFlight--
+	for q := Priority(0); q < numPriorities; q++ {
+		if len(s.queues[q]) == 0 {
+			continue
+		}
+		w := s.queues[q][0]
+		s.queues[q] = s.queues[q][1:]
+		wait := time.Since(w.enqueued)
+		s.counters[q].admitted++
+		s.counters[q].totalWait += wait
+		if wait > s.counters[q].maxWait {
+			s.counters[q].maxWait = wait
+		}
+		s.inFlight++
+		s.counters[q].inFlight++
+		close(w.ready)
+		return
+	}
+}
+
+// Stats returns a snapshot of in-flight counts and per-priority queue waits.
+func (s *llmScheduler) Stats() SchedulerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := SchedulerStats{MaxInFlight: s.maxInFlight, InFlight: s.inFlight}
+	for p := Priority(0); p < numPriorities; p++ {
+		c := s.counters[p]
+		ps := PriorityStats{
+			Priority:  p.String(),
+			Requests:  c.requests,
+			Queued:    c.queued,
+			Waiting:   len(s.queues[p]),
+			InFlight:  c.inFlight,
+			MaxWaitMS: float64(c.maxWait) / float64(time.Millisecond),
+		}
+		if c.admitted > 0 {
+			ps.AvgWaitMS = float64(c.totalWait) / float64(c.admitted) / float64(time.Millisecond)
+		}
+		stats.Priorities = append(stats.Priorities, ps)
+	}
+	return stats
+}
@@ -0,0 +1,240 @@
+// Package agent tests the priority scheduler for Gemini requests.
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// waitQueued blocks until n calls are queued at priority p.
+func waitQueued(t *testing.T, s *llmScheduler, p Priority, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		queued := len(s.queues[p])
+		s.mu.Unlock()
+		if queued == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Expected %d calls queued at %s", n, p)
+}
+
+// admission is a queued call that got its slot.
+type admission struct {
+	label   string
+	release func()
+}
+
+// acquireAsync queues a call at p that reports its admission on admitted.
+func acquireAsync(t *testing.T, s *llmScheduler, p Priority, label string, admitted chan<- admission) {
+	go func() {
+		release, err := s.acquire(context.Background(), p)
+		if err != nil {
+			t.Errorf("acquire(%s): %v", label, err)
+			return
+		}
+		admitted <- admission{label, release}
+	}()
+}
+
+// admissionOrder releases held and records the order in which the queued
+// calls are admitted, releasing each in turn.
+func admissionOrder(t *testing.T, held func(), admitted <-chan admission, n int) []string {
+	t.Helper()
+	held()
+	var order []string
+	for i := 0; i < n; i++ {
+		select {
+		case a := <-admitted:
+			order = append(order, a.label)
+			a.release()
+		case <-time.After(time.Second):
+			t.Fatalf("Only %d of %d queued calls were admitted: %v", i, n, order)
+		}
+	}
+	return order
+}
+
+// TestSchedulerAdmitsByPriority verifies queued calls are admitted in
+// priority order, regardless of when they queued
+func TestSchedulerAdmitsByPriority(t *testing.T) {
+	s := newLLMScheduler(1)
+	held, err := s.acquire(context.Background(), PriorityOrchestrator)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	admitted := make(chan admission, 4)
+	for _, p := range []Priority{PrioritySummary, PriorityScriptures, PriorityLeaders, PriorityPresidents} {
+		acquireAsync(t, s, p, p.String(), admitted)
+		waitQueued(t, s, p, 1)
+	}
+
+	order := admissionOrder(t, held, admitted, 4)
+	want := []string{"presidents", "leaders", "scriptures", "summary"}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("Admission order = %v, want %v", order, want)
+		}
+	}
+}
+
+// TestSchedulerFIFOWithinPriority verifies calls of the same priority are
+// admitted in the order they queued
+func TestSchedulerFIFOWithinPriority(t *testing.T) {
+	s := newLLMScheduler(1)
+	held, err := s.acquire(context.Background(), PriorityOrchestrator)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	admitted := make(chan admission, 3)
+	for i, label := range []string{"first", "second", "third"} {
+		acquireAsync(t, s, PriorityLeaders, label, admitted)
+		waitQueued(t, s, PriorityLeaders, i+1)
+	}
+
+	order := admissionOrder(t, held, admitted, 3)
+	want := []string{"first", "second", "third"}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("Admission order = %v, want %v", order, want)
+		}
+	}
+}
+
+// TestSchedulerNewcomerWaitsBehindQueue verifies a new call doesn't take a
+// free slot ahead of calls already queued at its priority or higher
+func TestSchedulerNewcomerWaitsBehindQueue(t *testing.T) {
+	s := newLLMScheduler(1)
+	held, _ := s.acquire(context.Background(), PriorityOrchestrator)
+	admitted := make(chan admission, 2)
+	acquireAsync(t, s, PriorityPresidents, "queued", admitted)
+	waitQueued(t, s, PriorityPresidents, 1)
+	acquireAsync(t, s, PrioritySummary, "newcomer", admitted)
+	waitQueued(t, s, PrioritySummary, 1)
+
+	order := admissionOrder(t, held, admitted, 2)
+	if order[0] != "queued" || order[1] != "newcomer" {
+		t.Errorf("Admission order = %v, want [queued newcomer]", order)
+	}
+}
+
+// TestSchedulerCancelWhileQueued verifies a cancelled call leaves the queue
+// and every slot is still available afterwards
+func TestSchedulerCancelWhileQueued(t *testing.T) {
+	s := newLLMScheduler(2)
+	held1, _ := s.acquire(context.Background(), PriorityOrchestrator)
+	held2, _ := s.acquire(context.Background(), PriorityOrchestrator)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		_, err := s.acquire(ctx, PriorityScriptures)
+		errs <- err
+	}()
+	waitQueued(t, s, PriorityScriptures, 1)
+	cancel()
+	if err := <-errs; err != context.Canceled {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	waitQueued(t, s, PriorityScriptures, 0)
+
+	held1()
+	held2()
+	held2() // releasing twice must not free a second slot
+	if stats := s.Stats(); stats.InFlight != 0 {
+		t.Fatalf("Expected no calls in flight, got %d", stats.InFlight)
+	}
+
+	// Both slots can be taken again without waiting
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		if _, err := s.acquire(ctx, PriorityScriptures); err != nil {
+			t.Errorf("Slot %d leaked: %v", i+1, err)
+		}
+		cancel()
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := s.acquire(ctx, PriorityScriptures); err == nil {
+		t.Error("Expected a third call to wait with only two slots")
+	}
+}
+
+// TestSchedulerCancelAfterAdmission verifies a call admitted just as it was
+// cancelled doesn't keep its slot, whichever of the two acquire sees first
+func TestSchedulerCancelAfterAdmission(t *testing.T) {
+	s := newLLMScheduler(1)
+	for i := 0; i < 50; i++ {
+		if _, err := s.acquire(context.Background(), PriorityOrchestrator); err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		type result struct {
+			release func()
+			err     error
+		}
+		results := make(chan result, 1)
+		go func() {
+			release, err := s.acquire(ctx, PrioritySummary)
+			results <- result{release, err}
+		}()
+		waitQueued(t, s, PrioritySummary, 1)
+
+		// Cancel and hand over the slot at the same moment
+		s.mu.Lock()
+		cancel()
+		s.releaseLocked(PriorityOrchestrator)
+		s.mu.Unlock()
+
+		r := <-results
+		if r.err == nil {
+			r.release()
+		}
+		if stats := s.Stats(); stats.InFlight != 0 {
+			t.Fatalf("Round %d: slot leaked, %d in flight (err=%v)", i, stats.InFlight, r.err)
+		}
+	}
+}
+
+// TestSchedulerStats verifies request, queue and wait accounting per priority
+func TestSchedulerStats(t *testing.T) {
+	s := newLLMScheduler(1)
+	held, _ := s.acquire(context.Background(), PriorityOrchestrator)
+
+	admitted := make(chan admission, 1)
+	acquireAsync(t, s, PriorityLeaders, "leaders", admitted)
+	waitQueued(t, s, PriorityLeaders, 1)
+
+	stats := s.Stats()
+	if stats.MaxInFlight != 1 || stats.InFlight != 1 || len(stats.Priorities) != int(numPriorities) {
+		t.Fatalf("Stats = %+v", stats)
+	}
+	if leaders := stats.Priorities[PriorityLeaders]; leaders.Waiting != 1 || leaders.Queued != 1 || leaders.Requests != 1 {
+		t.Errorf("Leaders while queued = %+v", leaders)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	admissionOrder(t, held, admitted, 1)
+
+	stats = s.Stats()
+	orchestrator := stats.Priorities[PriorityOrchestrator]
+	if orchestrator.Requests != 1 || orchestrator.Queued != 0 || orchestrator.AvgWaitMS != 0 {
+		t.Errorf("Orchestrator = %+v, want one unqueued request", orchestrator)
+	}
+	leaders := stats.Priorities[PriorityLeaders]
+	if leaders.Waiting != 0 || leaders.InFlight != 0 || leaders.Priority != "leaders" {
+		t.Errorf("Leaders after release = %+v", leaders)
+	}
+	if leaders.AvgWaitMS < 20 || leaders.MaxWaitMS < leaders.AvgWaitMS {
+		t.Errorf("Expected a wait of at least 20ms, got avg=%.1f max=%.1f", leaders.AvgWaitMS, leaders.MaxWaitMS)
+	}
+	if stats.InFlight != 0 {
+		t.Errorf("InFlight = %d, want 0", stats.InFlight)
+	}
+}
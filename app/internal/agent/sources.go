@@ -0,0 +1,267 @@
+// Package agent links each card back to its source. Formatted cards are
+// matched to the tool rows they were made from, and the talk ID, paragraph
+// anchor and canonical churchofjesuschrist.org URL are copied from the row,
+// so links never come from the model. Links open the site in the visitor's
+// language.
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const churchSiteURL = "https://www.churchofjesuschrist.org"
+
+// sourceRow holds the fields of a talk or verse row that identify its source.
+type sourceRow struct {
+	TalkID    any    `json:"talk_id"`
+	Title     string `json:"title"`
+	Content   string `json:"content"`
+	SourceURL string `json:"source_url"`
+
+	VerseID     any    `json:"verse_id"`
+	Book        string `json:"book_name"`
+	Chapter     any    `json:"chapter_number"`
+	Verse       any    `json:"verse_number"`
+	VerseText   string `json:"verse_text"`
+	VolumeAbbr  string `json:"volume_abbr"`
+	BookAbbr    string `json:"book_abbr"`
+	contentNorm string
+}
+
+// sourceIndex matches cards to the rows of one search.
+type sourceIndex struct {
+	rows []sourceRow
+	lang Language // of the site pages linked to
+}
+
+func newSourceIndex(result any, lang Language) *sourceIndex {
+	idx := &sourceIndex{lang: lang}
+	if result == nil || decodeRows(result, &idx.rows) != nil {
+		return idx
+	}
+	for i := range idx.rows {
+		idx.rows[i].contentNorm = normalizeForMatch(idx.rows[i].Content)
+	}
+	return idx
+}
+
+// attach fills source fields on every card of a formatter response. Content
+// it can't parse is returned unchanged.
+func (idx *sourceIndex) attach(content string) string {
+	if len(idx.rows) == 0 {
+		return content
+	}
+	var resp struct {
+		Quotes     []StructuredQuote     `json:"quotes"`
+		Scriptures []StructuredScripture `json:"scriptures"`
+	}
+	// Decode the first JSON value; the model sometimes trails text after it
+	if err := json.NewDecoder(strings.NewReader(content)).Decode(&resp); err != nil {
+		return content
+	}
+	var data []byte
+	var err error
+	switch {
+	case resp.Quotes != nil:
+		for i := range resp.Quotes {
+			idx.attachQuote(&resp.Quotes[i])
+		}
+		data, err = json.Marshal(PresidentsResponse{Quotes: resp.Quotes})
+	case resp.Scriptures != nil:
+		for i := range resp.Scriptures {
+			idx.attachScripture(&resp.Scriptures[i])
+		}
+		data, err = json.Marshal(ScripturesResponse{Scriptures: resp.Scriptures})
+	default:
+		return content
+	}
+	if err != nil {
+		return content
+	}
+	return string(data)
+}
+
+// attachQuote links a quote to the talk containing it, or failing that to
+// the talk with its title.
+func (idx *sourceIndex) attachQuote(q *StructuredQuote) {
+	probe := normalizeForMatch(q.Quote)
+	if len(probe) > 80 {
+		probe = probe[:80]
+	}
+	var match *sourceRow
+	for i := range idx.rows {
+		row := &idx.rows[i]
+		if probe != "" && strings.Contains(row.contentNorm, probe) {
+			match = row
+			break
+		}
+		if match == nil && row.Title != "" && strings.EqualFold(strings.TrimSpace(row.Title), strings.TrimSpace(q.Title)) {
+			match = row
+		}
+	}
+	if match == nil || match.TalkID == nil {
+		return
+	}
+	q.TalkID = fmt.Sprint(match.TalkID)
+	q.Paragraph = paragraphAnchor(match.Content, q.Quote)
+	q.SourceURL = talkURL(match.SourceURL, q.Paragraph, idx.lang)
+}
+
+// attachScripture links a scripture card to the verse it starts with, and
+// its related talk to a search for it.
+func (idx *sourceIndex) attachScripture(s *StructuredScripture) {
+	if s.RelatedTalk != nil {
+		s.RelatedTalk.SourceURL = talkSearchURL(s.RelatedTalk.Title, s.RelatedTalk.Speaker, idx.lang)
+	}
+	text := normalizeForMatch(s.Text)
+	for i := range idx.rows {
+		row := &idx.rows[i]
+		if row.VerseID == nil {
+			continue
+		}
+		ref := fmt.Sprintf("%s %v:%v", row.Book, row.Chapter, row.Verse)
+		sameRef := strings.HasPrefix(s.Reference, ref) && !startsWithDigit(s.Reference[len(ref):])
+		if !sameRef && (text == "" || normalizeForMatch(row.VerseText) != text) {
+			continue
+		}
+		s.VerseID = fmt.Sprint(row.VerseID)
+		s.SourceURL = scriptureURL(row.VolumeAbbr, row.BookAbbr, row.Chapter, row.Verse, idx.lang)
+		return
+	}
+}
+
+// talk returns the row of the talk with the given ID, or nil.
+func (idx *sourceIndex) talk(id string) *sourceRow {
+	for i := range idx.rows {
+		if row := &idx.rows[i]; row.TalkID != nil && fmt.Sprint(row.TalkID) == id {
+			return row
+		}
+	}
+	return nil
+}
+
+// verse returns the row of the verse with the given ID, or nil.
+func (idx *sourceIndex) verse(id string) *sourceRow {
+	for i := range idx.rows {
+		if row := &idx.rows[i]; row.VerseID != nil && fmt.Sprint(row.VerseID) == id {
+			return row
+		}
+	}
+	return nil
+}
+
+func startsWithDigit(s string) bool {
+	return s != "" && s[0] >= '0' && s[0] <= '9'
+}
+
+// paragraphAnchor returns the site's anchor ("p3") for the paragraph of
+// content where quote starts, or "" if it isn't found.
+func paragraphAnchor(content, quote string) string {
+	first, _ := paragraphSpan(content, quote)
+	if first < 0 {
+		return ""
+	}
+	return fmt.Sprintf("p%d", first+1)
+}
+
+// paragraphSpan returns the indexes of the paragraphs of content where
+// quote starts and ends, or -1 and -1 if it isn't found. Only the quote's
+// first and last words are matched, so a quote the model trimmed in the
+// middle still spans the right paragraphs. Talk content joins paragraphs
+// with blank lines.
+func paragraphSpan(content, quote string) (first, last int) {
+	const probeLen = 40
+	norm := normalizeForMatch(quote)
+	head, tail := norm, norm
+	if len(norm) > probeLen {
+		head, tail = norm[:probeLen], norm[len(norm)-probeLen:]
+	}
+	if head == "" {
+		return -1, -1
+	}
+	var text strings.Builder
+	var starts []int
+	for i, para := range strings.Split(content, "\n\n") {
+		if i > 0 {
+			text.WriteByte(' ')
+		}
+		starts = append(starts, text.Len())
+		text.WriteString(normalizeForMatch(para))
+	}
+	at := strings.Index(text.String(), head)
+	if at < 0 {
+		return -1, -1
+	}
+	end := at + len(head)
+	if i := strings.Index(text.String()[at:], tail); i >= 0 {
+		end = at + i + len(tail)
+	}
+	paragraphAt := func(offset int) int {
+		return sort.Search(len(starts), func(i int) bool { return starts[i] > offset }) - 1
+	}
+	return paragraphAt(at), paragraphAt(end - 1)
+}
+
+// talkURL returns the canonical talk page for a stored source URL, which is
+// either a study page or a content API URL carrying the page in its uri
+// parameter. Other URLs give "".
+func talkURL(source, anchor string, lang Language) string {
+	u, err := url.Parse(source)
+	if err != nil || !strings.HasSuffix(u.Hostname(), "churchofjesuschrist.org") {
+		return ""
+	}
+	path := u.Path
+	if uri := u.Query().Get("uri"); uri != "" {
+		path = "/study" + uri
+	}
+	if !strings.HasPrefix(path, "/study/general-conference/") {
+		return ""
+	}
+	return studyURL(path, anchor, lang)
+}
+
+// talkSearchURL returns a search of the Church's site for a talk by its
+// title and speaker, or "" without a title.
+func talkSearchURL(title, speaker string, lang Language) string {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return ""
+	}
+	query := strings.TrimSpace(title + " " + speaker)
+	return churchSiteURL + "/search?lang=" + lang.siteCode() + "&query=" + url.QueryEscape(query)
+}
+
+var scripturePathPart = regexp.MustCompile(`^[a-z0-9-]+$`)
+
+// scriptureURL returns the chapter page, anchored at the verse.
+func scriptureURL(volumeAbbr, bookAbbr string, chapter, verse any, lang Language) string {
+	volume, book := strings.ToLower(volumeAbbr), strings.ToLower(bookAbbr)
+	if !scripturePathPart.MatchString(volume) || !scripturePathPart.MatchString(book) {
+		return ""
+	}
+	anchor := ""
+	if verse != nil {
+		anchor = fmt.Sprintf("p%v", verse)
+	}
+	return studyURL(fmt.Sprintf("/study/scriptures/%s/%s/%v", volume, book, chapter), anchor, lang)
+}
+
+func studyURL(path, anchor string, lang Language) string {
+	query := "?lang=" + lang.siteCode()
+	if anchor == "" {
+		return churchSiteURL + path + query
+	}
+	return churchSiteURL + path + query + "&id=" + anchor + "#" + anchor
+}
+
+// normalizeForMatch folds case, quotes and whitespace so a quote the model
+// copied still matches the row it came from.
+func normalizeForMatch(s string) string {
+	s = strings.NewReplacer("‘", "'", "’", "'", "“", `"`, "”", `"`, "—", "-", "–", "-").Replace(s)
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
@@ -0,0 +1,80 @@
+package agent
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSourceIndexAttachesTalk(t *testing.T) {
+	rows := `[{"talk_id":"13oaks","title":"Covenants","content":"Opening words here.\n\nThe covenant path leads home. Keep walking it.\n\nClosing.",
+"source_url":"https://www.churchofjesuschrist.org/study/api/v3/language-pages/type/content?lang=eng&uri=/general-conference/2024/10/13oaks"}]`
+	idx := newSourceIndex(rows, LanguageEnglish)
+
+	// The model's quote differs in quotes and spacing from the row
+	out := idx.attach(`{"quotes":[{"speaker":"President Dallin H. Oaks","title":"Covenants","conference":"October 2024","quote":"The covenant  path leads home.  Keep walking it."}]}` + "\n")
+	var resp PresidentsResponse
+	if err := json.Unmarshal([]byte(out), &resp); err != nil {
+		t.Fatalf("attach output: %v", err)
+	}
+	q := resp.Quotes[0]
+	if q.TalkID != "13oaks" || q.Paragraph != "p2" {
+		t.Errorf("talk_id %q paragraph %q", q.TalkID, q.Paragraph)
+	}
+	want := "https://www.churchofjesuschrist.org/study/general-conference/2024/10/13oaks?lang=eng&id=p2#p2"
+	if q.SourceURL != want {
+		t.Errorf("source_url = %q, want %q", q.SourceURL, want)
+	}
+}
+
+func TestSourceIndexAttachesVerse(t *testing.T) {
+	rows := []map[string]any{
+		{"verse_id": "bofm-moro-10-4", "volume_abbr": "bofm", "book_name": "Moroni", "book_abbr": "moro", "chapter_number": 10.0, "verse_number": 4.0, "verse_text": "And when ye shall receive these things..."},
+		{"verse_id": "bofm-moro-10-5", "volume_abbr": "bofm", "book_name": "Moroni", "book_abbr": "moro", "chapter_number": 10.0, "verse_number": 5.0, "verse_text": "And by the power of the Holy Ghost..."},
+	}
+	out := newSourceIndex(rows, LanguageEnglish).attach(`{"scriptures":[{"volume":"Book of Mormon","reference":"Moroni 10:4-5","text":"And when ye shall receive these things...",
+"related_talk":{"speaker":"Russell M. Nelson","title":"Revelation for the Church","quote":"q","source_url":"https://example.com/made-up"}}]}`)
+	var resp ScripturesResponse
+	if err := json.Unmarshal([]byte(out), &resp); err != nil {
+		t.Fatalf("attach output: %v", err)
+	}
+	s := resp.Scriptures[0]
+	if s.VerseID != "bofm-moro-10-4" || s.SourceURL != "https://www.churchofjesuschrist.org/study/scriptures/bofm/moro/10?lang=eng&id=p4#p4" {
+		t.Errorf("scripture = %+v", s)
+	}
+	// The model's link is replaced with a search for the talk
+	if want := "https://www.churchofjesuschrist.org/search?lang=eng&query=Revelation+for+the+Church+Russell+M.+Nelson"; s.RelatedTalk == nil || s.RelatedTalk.SourceURL != want {
+		t.Errorf("related_talk = %+v, want source_url %q", s.RelatedTalk, want)
+	}
+}
+
+func TestSourceIndexLeavesUnknownContent(t *testing.T) {
+	idx := newSourceIndex(`[{"talk_id":"x","content":"text","source_url":"https://example.com/talk"}]`, LanguageEnglish)
+	in := `not json`
+	if got := idx.attach(in); got != in {
+		t.Errorf("attach(%q) = %q", in, got)
+	}
+	if got := talkURL("https://example.com/study/general-conference/2024/10/x", "", LanguageEnglish); got != "" {
+		t.Errorf("talkURL accepted another host: %q", got)
+	}
+}
+
+func TestSourceLinksInVisitorLanguage(t *testing.T) {
+	got := talkURL("https://www.churchofjesuschrist.org/study/general-conference/2024/10/13oaks?lang=eng", "p2", LanguageSpanish)
+	if want := "https://www.churchofjesuschrist.org/study/general-conference/2024/10/13oaks?lang=spa&id=p2#p2"; got != want {
+		t.Errorf("talkURL = %q, want %q", got, want)
+	}
+	got = scriptureURL("bofm", "moro", 10.0, 4.0, LanguagePortuguese)
+	if want := "https://www.churchofjesuschrist.org/study/scriptures/bofm/moro/10?lang=por&id=p4#p4"; got != want {
+		t.Errorf("scriptureURL = %q, want %q", got, want)
+	}
+}
+
+func TestParagraphSpan(t *testing.T) {
+	content := "Opening words here.\n\nThe covenant path leads home.\n\nKeep walking it every day of your life.\n\nClosing."
+	if first, last := paragraphSpan(content, "The covenant path leads home. Keep walking it every day of your life."); first != 1 || last != 2 {
+		t.Errorf("span = %d, %d, want 1, 2", first, last)
+	}
+	if first, last := paragraphSpan(content, "Not in this talk at all, anywhere."); first != -1 || last != -1 {
+		t.Errorf("span of a missing quote = %d, %d", first, last)
+	}
+}
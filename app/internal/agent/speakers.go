@@ -0,0 +1,457 @@
+// Package agent provides the speaker registry, loaded from the speakers table
+// through Toolbox and refreshed periodically. It is the one place that knows
+// speakers' slugs, name variants, callings, quorums and headshots: the server
+// uses it to normalize names and find headshots, and the agent uses it to
+// decide section membership and to list leaders in prompts. Callings come
+// from the leadership roster (roster.go) where it covers a speaker, so they
+// follow changes in leadership without a deploy.
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Quorum is a speaker's leadership group, derived from their calling.
+type Quorum int
+
+const (
+	QuorumOther Quorum = iota
+	QuorumFirstPresidency
+	QuorumTwelve
+	QuorumSeventy
+	QuorumGeneralOfficer // auxiliary presidencies and the Presiding Bishopric
+)
+
+var quorumNames = map[Quorum]string{
+	QuorumOther:           "Other",
+	QuorumFirstPresidency: "First Presidency",
+	QuorumTwelve:          "Quorum of the Twelve Apostles",
+	QuorumSeventy:         "Seventy",
+	QuorumGeneralOfficer:  "General Officers",
+}
+
+func (q Quorum) String() string { return quorumNames[q] }
+
+// Speaker is one row of the speakers table plus derived fields.
+type Speaker struct {
+	Slug             string   `json:"name_slug"`
+	Name             string   `json:"name"` // without title, e.g. "Dallin H. Oaks"
+	Title            string   `json:"title,omitempty"`
+	Aliases          []string `json:"aliases,omitempty"`
+	Calling          string   `json:"calling"`
+	Quorum           Quorum   `json:"quorum"`
+	HeadshotSquare   string   `json:"headshot_square"`
+	HeadshotPortrait string   `json:"headshot_portrait"`
+}
+
+// DisplayName is the name with its title, e.g. "President Dallin H. Oaks".
+func (s Speaker) DisplayName() string {
+	if s.Title == "" {
+		return s.Name
+	}
+	return s.Title + " " + s.Name
+}
+
+// IsPresident reports whether the speaker is President of the Church, as of
+// the date their calling was resolved for.
+func (s Speaker) IsPresident() bool {
+	return s.Quorum == QuorumFirstPresidency && presidencyRank(s.Calling) == 0
+}
+
+// HeadshotFile is the square headshot's file name, so callers can serve it
+// from their own assets base URL.
+func (s Speaker) HeadshotFile() string {
+	if s.HeadshotSquare == "" {
+		return ""
+	}
+	return path.Base(s.HeadshotSquare)
+}
+
+// seedSpeakers cover the First Presidency until the speakers table loads,
+// so the presidents searches work even if that first load fails. Their
+// callings come from the roster.
+var seedSpeakers = []Speaker{
+	{Slug: "dallin-oaks", Name: "Dallin H. Oaks",
+		HeadshotSquare: "https://storage.googleapis.com/temple-square-assets/headshots/dallin-oaks-square.webp"},
+	{Slug: "russell-nelson", Name: "Russell M. Nelson",
+		HeadshotSquare: "https://storage.googleapis.com/temple-square-assets/headshots/russell-nelson-square.webp"},
+	{Slug: "henry-eyring", Name: "Henry B. Eyring",
+		HeadshotSquare: "https://storage.googleapis.com/temple-square-assets/headshots/henry-eyring-square.webp"},
+	{Slug: "todd-christofferson", Name: "D. Todd Christofferson",
+		HeadshotSquare: "https://storage.googleapis.com/temple-square-assets/headshots/todd-christofferson-square.webp"},
+}
+
+// DefaultSpeakersRefresh is how often the registry reloads the table.
+const DefaultSpeakersRefresh = time.Hour
+
+func speakersRefreshFromEnv() time.Duration {
+	if v := os.Getenv("SPEAKERS_REFRESH"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+		log.Printf("Invalid SPEAKERS_REFRESH=%q, using %s", v, DefaultSpeakersRefresh)
+	}
+	return DefaultSpeakersRefresh
+}
+
+// SpeakerRegistry indexes speakers by slug and by every name variant. It
+// stores each speaker's calling from the speakers table and resolves the
+// calling on a given date through the roster.
+type SpeakerRegistry struct {
+	mu      sync.RWMutex
+	bySlug  map[string]*Speaker
+	byAlias map[string]*Speaker
+	ordered []*Speaker // by name
+	roster  map[string][]RosterEntry
+	now     func() time.Time
+}
+
+// NewSpeakerRegistry returns a registry holding the seed speakers and the
+// embedded roster.
+func NewSpeakerRegistry() *SpeakerRegistry {
+	r := &SpeakerRegistry{now: time.Now}
+	r.setRoster(defaultRoster())
+	r.replace(seedSpeakers)
+	return r
+}
+
+// replace swaps in a new speaker list, deriving titles, quorums and aliases.
+func (r *SpeakerRegistry) replace(rows []Speaker) {
+	now := r.now()
+	bySlug := make(map[string]*Speaker, len(rows))
+	var ordered []*Speaker
+	for _, row := range rows {
+		sp := row
+		sp.Name = stripTitle(strings.TrimSpace(sp.Name))
+		if sp.Slug == "" || sp.Name == "" {
+			continue
+		}
+		bySlug[sp.Slug] = &sp
+		ordered = append(ordered, &sp)
+	}
+	r.mu.RLock()
+	for _, sp := range ordered {
+		current := r.resolveLocked(sp, now)
+		sp.Aliases = aliasesFor(current)
+	}
+	r.mu.RUnlock()
+
+	// A short alias ("Elder Cook") claimed by two speakers resolves to neither
+	byAlias := map[string]*Speaker{}
+	ambiguous := map[string]bool{}
+	for _, sp := range ordered {
+		for _, alias := range sp.Aliases {
+			key := nameKey(alias)
+			if other, ok := byAlias[key]; ok && other != sp {
+				ambiguous[key] = true
+				continue
+			}
+			byAlias[key] = sp
+		}
+	}
+	for key := range ambiguous {
+		delete(byAlias, key)
+	}
+	for _, sp := range ordered {
+		byAlias[nameKey(sp.Name)] = sp // full names always resolve
+	}
+
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Name < ordered[j].Name })
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bySlug, r.byAlias, r.ordered = bySlug, byAlias, ordered
+}
+
+// Load replaces the registry from the speakers table via the list_speakers
+// tool, and the roster from the callings table via list_callings when that
+// is set and returns rows. On failure the previous speakers stay in place;
+// without callings the current roster stays in place.
+func (r *SpeakerRegistry) Load(ctx context.Context, listSpeakers, listCallings func(context.Context, map[string]any) (any, error)) error {
+	if listCallings != nil {
+		if err := r.loadRoster(ctx, listCallings); err != nil {
+			log.Printf("Roster load failed, keeping the current roster: %v", err)
+		}
+	}
+	result, err := listSpeakers(ctx, map[string]any{})
+	if err != nil {
+		return fmt.Errorf("list_speakers failed: %w", err)
+	}
+	var rows []Speaker
+	if err := decodeRows(result, &rows); err != nil {
+		return fmt.Errorf("failed to decode speakers: %w", err)
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("speakers table is empty")
+	}
+	r.replace(rows)
+	log.Printf("Speaker registry loaded %d speakers", len(rows))
+	return nil
+}
+
+// StartRefresh reloads the registry every interval until ctx ends.
+func (r *SpeakerRegistry) StartRefresh(ctx context.Context, interval time.Duration, listSpeakers, listCallings func(context.Context, map[string]any) (any, error)) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				loadCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+				if err := r.Load(loadCtx, listSpeakers, listCallings); err != nil {
+					log.Printf("Speaker registry refresh failed, keeping %d speakers: %v", r.Len(), err)
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+// Len returns the number of registered speakers.
+func (r *SpeakerRegistry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.bySlug)
+}
+
+// Lookup resolves any name variant ("Elder Bednar", "President Dallin H.
+// Oaks", a slug) to its speaker, with today's calling.
+func (r *SpeakerRegistry) Lookup(name string) (Speaker, bool) {
+	return r.LookupAt(name, r.now())
+}
+
+// LookupAt is Lookup with the calling the speaker held at t.
+func (r *SpeakerRegistry) LookupAt(name string, t time.Time) (Speaker, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if sp := r.findLocked(name); sp != nil {
+		return r.resolveLocked(sp, t), true
+	}
+	return Speaker{}, false
+}
+
+func (r *SpeakerRegistry) findLocked(name string) *Speaker {
+	key := nameKey(name)
+	if key == "" {
+		return nil
+	}
+	if sp, ok := r.bySlug[strings.TrimSpace(strings.ToLower(name))]; ok {
+		return sp
+	}
+	if sp, ok := r.byAlias[key]; ok {
+		return sp
+	}
+	// A known full name inside a longer string, e.g. "By Elder David A. Bednar"
+	for _, sp := range r.ordered {
+		if full := nameKey(sp.Name); len(full) > 8 && strings.Contains(key, full) {
+			return sp
+		}
+	}
+	return nil
+}
+
+// Normalize returns the display name for a known speaker, or name unchanged.
+func (r *SpeakerRegistry) Normalize(name string) string {
+	if sp, ok := r.Lookup(name); ok {
+		return sp.DisplayName()
+	}
+	return strings.TrimSpace(name)
+}
+
+// Slug returns the slug for a speaker name, or "" if unknown.
+func (r *SpeakerRegistry) Slug(name string) string {
+	if sp, ok := r.Lookup(name); ok {
+		return sp.Slug
+	}
+	return ""
+}
+
+// Members returns today's members of quorum in seniority order.
+func (r *SpeakerRegistry) Members(q Quorum) []Speaker {
+	return r.MembersAt(q, r.now())
+}
+
+// MembersAt returns the members of quorum at t in seniority order: the
+// First Presidency by calling, everyone else by name.
+func (r *SpeakerRegistry) MembersAt(q Quorum, t time.Time) []Speaker {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []Speaker
+	for _, sp := range r.ordered {
+		if resolved := r.resolveLocked(sp, t); resolved.Quorum == q {
+			out = append(out, resolved)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return presidencyRank(out[i].Calling) < presidencyRank(out[j].Calling)
+	})
+	return out
+}
+
+// Slugs returns the slugs of quorum's members today in seniority order.
+func (r *SpeakerRegistry) Slugs(q Quorum) []string {
+	return slugsOf(r.Members(q))
+}
+
+func slugsOf(speakers []Speaker) []string {
+	slugs := make([]string, 0, len(speakers))
+	for _, sp := range speakers {
+		slugs = append(slugs, sp.Slug)
+	}
+	return slugs
+}
+
+// InQuorum reports whether the named speaker belongs to q.
+func (r *SpeakerRegistry) InQuorum(name string, q Quorum) bool {
+	sp, ok := r.Lookup(name)
+	return ok && sp.Quorum == q
+}
+
+// Roster lists the members of each quorum by display name, for prompts.
+func (r *SpeakerRegistry) Roster(quorums ...Quorum) string {
+	var lines []string
+	for _, q := range quorums {
+		members := r.Members(q)
+		if len(members) == 0 {
+			continue
+		}
+		names := make([]string, len(members))
+		for i, sp := range members {
+			names[i] = sp.DisplayName()
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", q, strings.Join(names, ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// withRoster appends the current members of quorums to a formatter prompt,
+// so the model knows who belongs where and how to write their names.
+func (a *ProphetAgent) withRoster(prompt string, quorums ...Quorum) string {
+	roster := a.speakers.Roster(quorums...)
+	if roster == "" {
+		return prompt
+	}
+	return prompt + "\n\nCURRENT LEADERS (write speaker names exactly as listed):\n" + roster
+}
+
+// Speakers returns the agent's speaker registry.
+func (a *ProphetAgent) Speakers() *SpeakerRegistry {
+	return a.speakers
+}
+
+// quorumForCalling classifies a calling from the speakers table.
+func quorumForCalling(calling string) Quorum {
+	c := strings.ToLower(calling)
+	switch {
+	case strings.Contains(c, "first presidency"),
+		strings.Contains(c, "president of the church"):
+		return QuorumFirstPresidency
+	case strings.Contains(c, "twelve"):
+		return QuorumTwelve
+	case strings.Contains(c, "seventy"):
+		return QuorumSeventy
+	case strings.Contains(c, "general president"),
+		strings.Contains(c, "general presidency"),
+		strings.Contains(c, "presiding bishop"),
+		strings.Contains(c, "bishopric"):
+		return QuorumGeneralOfficer
+	}
+	return QuorumOther
+}
+
+// presidencyRank orders the First Presidency: the President, then the first
+// and second counselors.
+func presidencyRank(calling string) int {
+	c := strings.ToLower(calling)
+	switch {
+	case strings.HasPrefix(c, "president of the church"):
+		return 0
+	case strings.Contains(c, "first counselor"):
+		return 1
+	case strings.Contains(c, "second counselor"):
+		return 2
+	}
+	return 3
+}
+
+// titleFor picks the title conference talks use for a speaker with calling.
+func titleFor(calling string) string {
+	c := strings.ToLower(calling)
+	switch q := quorumForCalling(calling); {
+	case q == QuorumFirstPresidency:
+		return "President"
+	case q == QuorumTwelve, q == QuorumSeventy:
+		return "Elder"
+	case strings.Contains(c, "bishop"):
+		return "Bishop"
+	case strings.Contains(c, "relief society"), strings.Contains(c, "young women"), strings.Contains(c, "primary"):
+		return "Sister"
+	}
+	return ""
+}
+
+// aliasesFor lists the name variants that refer to the speaker.
+func aliasesFor(sp Speaker) []string {
+	parts := strings.Fields(sp.Name)
+	last := parts[len(parts)-1]
+	var plain []string // without middle initials: "Dallin Oaks"
+	for _, p := range parts {
+		if !(len(p) == 2 && strings.HasSuffix(p, ".")) {
+			plain = append(plain, p)
+		}
+	}
+	aliases := []string{sp.Name, strings.Join(plain, " "), sp.Slug}
+	for _, title := range []string{sp.Title, "President", "Elder", "Sister", "Bishop"} {
+		if title == "" {
+			continue
+		}
+		aliases = append(aliases, title+" "+sp.Name)
+	}
+	// Last names alone are only used for the First Presidency, the Twelve and
+	// former Presidents
+	if sp.Title == "President" || (sp.Title != "" && sp.Quorum == QuorumTwelve) {
+		aliases = append(aliases, sp.Title+" "+last)
+	}
+	return aliases
+}
+
+var speakerTitles = []string{"president", "elder", "sister", "bishop", "brother", "prophet"}
+
+// stripTitle removes a leading title from a name.
+func stripTitle(name string) string {
+	for {
+		first, rest, ok := strings.Cut(name, " ")
+		if !ok || !containsString(speakerTitles, strings.ToLower(first)) {
+			return name
+		}
+		name = strings.TrimSpace(rest)
+	}
+}
+
+// nameKey folds case, punctuation and titles so name variants compare equal.
+func nameKey(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r) {
+			return unicode.ToLower(r)
+		}
+		if r == '-' {
+			return ' '
+		}
+		return -1
+	}, name)
+	return strings.Join(strings.Fields(stripTitle(name)), " ")
+}
@@ -0,0 +1,90 @@
+package agent
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const speakersJSON = `[
+{"name_slug":"russell-nelson","name":"Russell M. Nelson","calling":"President of The Church of Jesus Christ of Latter-day Saints","headshot_square":""},
+{"name_slug":"henry-eyring","name":"Henry B. Eyring","calling":"First Counselor in the First Presidency","headshot_square":"https://example.com/headshots/henry-eyring-square.webp"},
+{"name_slug":"dallin-oaks","name":"Dallin H. Oaks","calling":"President of The Church of Jesus Christ of Latter-day Saints","headshot_square":"https://example.com/headshots/dallin-oaks-square.webp"},
+{"name_slug":"todd-christofferson","name":"D. Todd Christofferson","calling":"Second Counselor in the First Presidency","headshot_square":""},
+{"name_slug":"david-bednar","name":"Elder David A. Bednar","calling":"Quorum of the Twelve Apostles","headshot_square":""},
+{"name_slug":"quentin-cook","name":"Quentin L. Cook","calling":"Quorum of the Twelve Apostles","headshot_square":""},
+{"name_slug":"l-cook","name":"Larry Cook","calling":"General Authority Seventy","headshot_square":""},
+{"name_slug":"camille-johnson","name":"Camille N. Johnson","calling":"Relief Society General President","headshot_square":""}
+]`
+
+func loadedRegistry(t *testing.T) *SpeakerRegistry {
+	t.Helper()
+	r := NewSpeakerRegistry()
+	r.now = func() time.Time { return time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC) }
+	err := r.Load(context.Background(), func(context.Context, map[string]any) (any, error) {
+		return speakersJSON, nil
+	}, nil)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return r
+}
+
+func TestSpeakerRegistryLookup(t *testing.T) {
+	r := loadedRegistry(t)
+
+	cases := map[string]string{
+		"Dallin H. Oaks":                   "dallin-oaks",
+		"President Dallin H. Oaks":         "dallin-oaks",
+		"president oaks":                   "dallin-oaks",
+		"Elder Bednar":                     "david-bednar",
+		"David Bednar":                     "david-bednar",
+		"todd-christofferson":              "todd-christofferson",
+		"President D. Todd Christofferson": "todd-christofferson",
+		"Sister Camille N. Johnson":        "camille-johnson",
+		"By Elder Quentin L. Cook":         "quentin-cook",
+		"Elder Cook":                       "quentin-cook", // last-name aliases are for the Twelve and up only
+		"Someone Else":                     "",
+	}
+	for name, want := range cases {
+		if got := r.Slug(name); got != want {
+			t.Errorf("Slug(%q) = %q, want %q", name, got, want)
+		}
+	}
+
+	if got := r.Normalize("elder david a. bednar"); got != "Elder David A. Bednar" {
+		t.Errorf("Normalize = %q", got)
+	}
+	if sp, _ := r.Lookup("President Oaks"); !sp.IsPresident() || sp.HeadshotFile() != "dallin-oaks-square.webp" {
+		t.Errorf("Oaks = %+v", sp)
+	}
+}
+
+func TestSpeakerRegistryQuorums(t *testing.T) {
+	r := loadedRegistry(t)
+
+	want := []string{"dallin-oaks", "henry-eyring", "todd-christofferson"}
+	if got := r.Slugs(QuorumFirstPresidency); !reflect.DeepEqual(got, want) {
+		t.Errorf("First Presidency = %v, want %v", got, want)
+	}
+	if !r.InQuorum("Elder Bednar", QuorumTwelve) || r.InQuorum("Elder Bednar", QuorumSeventy) {
+		t.Error("Bednar should be in the Twelve only")
+	}
+	if r.Len() != 8 {
+		t.Errorf("Len = %d, want the table's 8 speakers", r.Len())
+	}
+}
+
+func TestSpeakerRegistryLoadFailureKeepsSpeakers(t *testing.T) {
+	r := NewSpeakerRegistry()
+	err := r.Load(context.Background(), func(context.Context, map[string]any) (any, error) {
+		return "[]", nil
+	}, nil)
+	if err == nil {
+		t.Fatal("expected an error for an empty table")
+	}
+	if r.Slug("President Nelson") != "russell-nelson" {
+		t.Error("seed speakers should survive a failed load")
+	}
+}
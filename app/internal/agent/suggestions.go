@@ -0,0 +1,300 @@
+// Package agent provides the curated suggested-question catalog used by redirects.
+package agent
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultSuggestionCount is how many suggested questions a redirect shows.
+const defaultSuggestionCount = 8
+
+//go:embed suggestions.json
+var defaultSuggestionsJSON []byte
+
+// SuggestedQuestion is a localized catalog question offered to the visitor.
+type SuggestedQuestion struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+}
+
+// CatalogQuestion is a curated question as stored in the catalog file.
+// Classifications limits the entry to certain redirect types (empty = all);
+// Categories are the safety categories it is especially relevant to.
+type CatalogQuestion struct {
+	ID              string            `json:"id"`
+	Themes          []string          `json:"themes"`
+	Classifications []string          `json:"classifications,omitempty"`
+	Categories      []string          `json:"categories,omitempty"`
+	Weight          int               `json:"weight,omitempty"`
+	Text            map[string]string `json:"text"`
+}
+
+// SuggestionCatalog holds the curated questions and rotates between equally
+// relevant alternatives so repeat visitors see variety.
+type SuggestionCatalog struct {
+	Version   int               `json:"version"`
+	Questions []CatalogQuestion `json:"questions"`
+
+	byID     map[string]*CatalogQuestion
+	rotation uint64
+}
+
+var (
+	defaultCatalogOnce sync.Once
+	defaultCatalog     *SuggestionCatalog
+)
+
+// DefaultSuggestionCatalog returns the catalog embedded in the binary.
+func DefaultSuggestionCatalog() *SuggestionCatalog {
+	defaultCatalogOnce.Do(func() {
+		catalog, err := ParseSuggestionCatalog(defaultSuggestionsJSON)
+		if err != nil {
+			panic(fmt.Sprintf("invalid embedded suggestion catalog: %v", err))
+		}
+		defaultCatalog = catalog
+	})
+	return defaultCatalog
+}
+
+// LoadSuggestionCatalog reads a catalog file. An empty path returns the
+// embedded default catalog.
+func LoadSuggestionCatalog(path string) (*SuggestionCatalog, error) {
+	if path == "" {
+		return DefaultSuggestionCatalog(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read suggestion catalog: %w", err)
+	}
+	return ParseSuggestionCatalog(data)
+}
+
+// ParseSuggestionCatalog parses and validates catalog JSON.
+func ParseSuggestionCatalog(data []byte) (*SuggestionCatalog, error) {
+	var catalog SuggestionCatalog
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return nil, fmt.Errorf("failed to parse suggestion catalog: %w", err)
+	}
+	catalog.byID = make(map[string]*CatalogQuestion, len(catalog.Questions))
+	for i := range catalog.Questions {
+		q := &catalog.Questions[i]
+		if q.ID == "" {
+			return nil, fmt.Errorf("catalog question %d has no id", i)
+		}
+		if _, dup := catalog.byID[q.ID]; dup {
+			return nil, fmt.Errorf("duplicate catalog question id %q", q.ID)
+		}
+		if q.Text["en"] == "" {
+			return nil, fmt.Errorf("catalog question %q has no English text", q.ID)
+		}
+		catalog.byID[q.ID] = q
+	}
+	if len(catalog.Questions) == 0 {
+		return nil, fmt.Errorf("suggestion catalog is empty")
+	}
+	return &catalog, nil
+}
+
+// Lookup returns the catalog question with the given ID.
+func (c *SuggestionCatalog) Lookup(id string) (*CatalogQuestion, bool) {
+	q, ok := c.byID[id]
+	return q, ok
+}
+
+// Localized returns the question text for a locale, falling back from a
+// regional locale (es-MX) to its language (es) and finally to English.
+func (q *CatalogQuestion) Localized(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if text, ok := q.Text[locale]; ok && text != "" {
+		return text
+	}
+	if lang, _, found := strings.Cut(locale, "-"); found {
+		if text, ok := q.Text[lang]; ok && text != "" {
+			return text
+		}
+	}
+	return q.Text["en"]
+}
+
+// Select returns up to n questions for a blocked question. Entries tagged with
+// the blocked category rank first; ties are rotated on every call.
+func (c *SuggestionCatalog) Select(classification ContentClassification, category, locale string, n int) []SuggestedQuestion {
+	type scored struct {
+		q     *CatalogQuestion
+		score int
+		order int
+	}
+
+	offset := int(atomic.AddUint64(&c.rotation, 1))
+	var candidates []scored
+	for i := range c.Questions {
+		q := &c.Questions[i]
+		if len(q.Classifications) > 0 && !containsString(q.Classifications, string(classification)) {
+			continue
+		}
+		score := q.Weight
+		if score <= 0 {
+			score = 1
+		}
+		if category != "" && containsString(q.Categories, category) {
+			score += 4
+		}
+		candidates = append(candidates, scored{q: q, score: score, order: (i + offset) % len(c.Questions)})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].order < candidates[j].order
+	})
+
+	if n > len(candidates) {
+		n = len(candidates)
+	}
+	out := make([]SuggestedQuestion, n)
+	for i := 0; i < n; i++ {
+		out[i] = SuggestedQuestion{ID: candidates[i].q.ID, Text: candidates[i].q.Localized(locale)}
+	}
+	return out
+}
+
+// Redirect builds the redirect response for a blocked question, with the
+// message and suggestions in the locale's language.
+func (c *SuggestionCatalog) Redirect(classification ContentClassification, category, locale string) RedirectResponse {
+	suggestions := c.Select(classification, category, locale, defaultSuggestionCount)
+	texts := make([]string, len(suggestions))
+	for i, s := range suggestions {
+		texts[i] = s.Text
+	}
+	return RedirectResponse{
+		Message:            redirectMessage(classification, locale),
+		SuggestedQuestions: texts,
+		Suggestions:        suggestions,
+	}
+}
+
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// SuggestionCount aggregates how often a suggestion was shown and clicked.
+type SuggestionCount struct {
+	ID          string  `json:"id"`
+	Impressions int64   `json:"impressions"`
+	Clicks      int64   `json:"clicks"`
+	ClickRate   float64 `json:"click_rate"`
+}
+
+// suggestionEvent is one line of the analytics log.
+type suggestionEvent struct {
+	Time           time.Time `json:"time"`
+	Event          string    `json:"event"`
+	ID             string    `json:"id"`
+	Classification string    `json:"classification,omitempty"`
+	Category       string    `json:"category,omitempty"`
+	Locale         string    `json:"locale,omitempty"`
+}
+
+// SuggestionStats tracks suggestion impressions and click-throughs in memory
+// and optionally appends every event to a JSON-lines log for offline curation.
+type SuggestionStats struct {
+	mu      sync.Mutex
+	counts  map[string]*SuggestionCount
+	logPath string
+}
+
+// NewSuggestionStats creates a stats tracker. logPath may be empty.
+func NewSuggestionStats(logPath string) *SuggestionStats {
+	return &SuggestionStats{
+		counts:  make(map[string]*SuggestionCount),
+		logPath: logPath,
+	}
+}
+
+// RecordImpressions counts each suggestion shown in a redirect.
+func (s *SuggestionStats) RecordImpressions(suggestions []SuggestedQuestion, classification ContentClassification, category, locale string) {
+	s.mu.Lock()
+	for _, sq := range suggestions {
+		s.countLocked(sq.ID).Impressions++
+	}
+	s.mu.Unlock()
+	for _, sq := range suggestions {
+		s.appendEvent(suggestionEvent{Event: "impression", ID: sq.ID, Classification: string(classification), Category: category, Locale: locale})
+	}
+}
+
+// RecordClick counts a visitor asking a suggested question.
+func (s *SuggestionStats) RecordClick(id, locale string) {
+	s.mu.Lock()
+	s.countLocked(id).Clicks++
+	s.mu.Unlock()
+	s.appendEvent(suggestionEvent{Event: "click", ID: id, Locale: locale})
+}
+
+// Snapshot returns the current counts sorted by click count.
+func (s *SuggestionStats) Snapshot() []SuggestionCount {
+	s.mu.Lock()
+	out := make([]SuggestionCount, 0, len(s.counts))
+	for _, c := range s.counts {
+		count := *c
+		if count.Impressions > 0 {
+			count.ClickRate = float64(count.Clicks) / float64(count.Impressions)
+		}
+		out = append(out, count)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Clicks != out[j].Clicks {
+			return out[i].Clicks > out[j].Clicks
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+func (s *SuggestionStats) countLocked(id string) *SuggestionCount {
+	c, ok := s.counts[id]
+	if !ok {
+		c = &SuggestionCount{ID: id}
+		s.counts[id] = c
+	}
+	return c
+}
+
+func (s *SuggestionStats) appendEvent(ev suggestionEvent) {
+	if s.logPath == "" {
+		return
+	}
+	ev.Time = time.Now().UTC()
+	line, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		log.Printf("[suggestions] Failed to open analytics log %s: %v", s.logPath, err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		log.Printf("[suggestions] Failed to write analytics log: %v", err)
+	}
+}
@@ -0,0 +1,179 @@
+// Package agent tests the suggested-question catalog and its stats.
+package agent
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testCatalogJSON = `{
+  "version": 1,
+  "questions": [
+    {"id": "a", "themes": ["faith"], "text": {"en": "Question A", "es": "Pregunta A"}},
+    {"id": "b", "themes": ["faith"], "text": {"en": "Question B"}},
+    {"id": "c", "themes": ["faith"], "text": {"en": "Question C"}},
+    {"id": "violence", "themes": ["peace"], "categories": ["violence"], "text": {"en": "Where can I find peace?"}},
+    {"id": "inappropriate-only", "themes": ["prayer"], "classifications": ["inappropriate"], "text": {"en": "How do I pray?"}}
+  ]
+}`
+
+func testCatalog(t *testing.T) *SuggestionCatalog {
+	t.Helper()
+	c, err := ParseSuggestionCatalog([]byte(testCatalogJSON))
+	if err != nil {
+		t.Fatalf("ParseSuggestionCatalog: %v", err)
+	}
+	return c
+}
+
+// TestParseSuggestionCatalogValidation verifies malformed catalogs are rejected
+func TestParseSuggestionCatalogValidation(t *testing.T) {
+	cases := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"invalid json", `{`, "failed to parse"},
+		{"empty", `{"version": 1, "questions": []}`, "empty"},
+		{"missing id", `{"questions": [{"text": {"en": "Q"}}]}`, "has no id"},
+		{"duplicate id", `{"questions": [{"id": "a", "text": {"en": "Q"}}, {"id": "a", "text": {"en": "R"}}]}`, "duplicate"},
+		{"no english", `{"questions": [{"id": "a", "text": {"es": "P"}}]}`, "no English text"},
+	}
+	for _, tc := range cases {
+		_, err := ParseSuggestionCatalog([]byte(tc.json))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: error = %v, want one containing %q", tc.name, err, tc.want)
+		}
+	}
+}
+
+// TestLoadSuggestionCatalog verifies the embedded default and file loading
+func TestLoadSuggestionCatalog(t *testing.T) {
+	def, err := LoadSuggestionCatalog("")
+	if err != nil || def != DefaultSuggestionCatalog() || len(def.Questions) == 0 {
+		t.Fatalf("LoadSuggestionCatalog(\"\") = %v, %v; want the embedded catalog", def, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "suggestions.json")
+	if err := os.WriteFile(path, []byte(testCatalogJSON), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadSuggestionCatalog(path)
+	if err != nil {
+		t.Fatalf("LoadSuggestionCatalog: %v", err)
+	}
+	if q, ok := c.Lookup("violence"); !ok || q.Text["en"] != "Where can I find peace?" {
+		t.Errorf("Lookup(violence) = %+v, %v", q, ok)
+	}
+	if _, ok := c.Lookup("missing"); ok {
+		t.Error("Expected Lookup of an unknown ID to fail")
+	}
+
+	if _, err := LoadSuggestionCatalog(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected an error for a missing catalog file")
+	}
+}
+
+// TestCatalogQuestionLocalized verifies regional locales fall back to their
+// language and then to English
+func TestCatalogQuestionLocalized(t *testing.T) {
+	q := &CatalogQuestion{Text: map[string]string{"en": "Hello", "es": "Hola", "pt-br": "Olá", "pt": ""}}
+	cases := map[string]string{
+		"es":    "Hola",
+		"ES":    "Hola",
+		"es-MX": "Hola",
+		"pt-BR": "Olá",
+		"pt":    "Hello", // empty translations don't count
+		"fr":    "Hello",
+		"":      "Hello",
+	}
+	for locale, want := range cases {
+		if got := q.Localized(locale); got != want {
+			t.Errorf("Localized(%q) = %q, want %q", locale, got, want)
+		}
+	}
+}
+
+// TestSelectRanksCategoryAndRotates verifies category matches come first,
+// classification-restricted entries are filtered, and ties rotate
+func TestSelectRanksCategoryAndRotates(t *testing.T) {
+	c := testCatalog(t)
+
+	for i := 0; i < 3; i++ {
+		got := c.Select(ContentControversial, "violence", "en", 10)
+		if len(got) != 4 || got[0].ID != "violence" {
+			t.Fatalf("Select(violence) = %+v, want the category match first and 4 entries", got)
+		}
+		for _, s := range got {
+			if s.ID == "inappropriate-only" {
+				t.Errorf("Select(controversial) offered %q", s.ID)
+			}
+		}
+	}
+
+	seen := map[string]bool{}
+	for i := 0; i < 4; i++ {
+		got := c.Select(ContentControversial, "", "en", 1)
+		if len(got) != 1 {
+			t.Fatalf("Select(n=1) = %+v", got)
+		}
+		seen[got[0].ID] = true
+	}
+	if len(seen) < 3 {
+		t.Errorf("Expected equally weighted questions to rotate, saw %v", seen)
+	}
+
+	got := c.Select(ContentInappropriate, "", "es-MX", 10)
+	if len(got) != 5 {
+		t.Errorf("Select(inappropriate) = %d entries, want 5", len(got))
+	}
+	for _, s := range got {
+		if s.ID == "a" && s.Text != "Pregunta A" {
+			t.Errorf("Expected localized text for %q, got %q", s.ID, s.Text)
+		}
+	}
+}
+
+// TestSuggestionStatsCounts verifies impressions, clicks, click rates and
+// the analytics log
+func TestSuggestionStatsCounts(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "suggestions.jsonl")
+	s := NewSuggestionStats(logPath)
+
+	shown := []SuggestedQuestion{{ID: "a"}, {ID: "b"}}
+	s.RecordImpressions(shown, ContentControversial, "politics", "en")
+	s.RecordImpressions(shown, ContentControversial, "politics", "en")
+	s.RecordClick("b", "en")
+	s.RecordClick("b", "es")
+	s.RecordClick("a", "en")
+
+	got := s.Snapshot()
+	want := []SuggestionCount{
+		{ID: "b", Impressions: 2, Clicks: 2, ClickRate: 1},
+		{ID: "a", Impressions: 2, Clicks: 1, ClickRate: 0.5},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Snapshot = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Snapshot[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	f, err := os.Open(logPath)
+	if err != nil {
+		t.Fatalf("Expected an analytics log: %v", err)
+	}
+	defer f.Close()
+	lines := 0
+	for sc := bufio.NewScanner(f); sc.Scan(); {
+		lines++
+	}
+	if lines != 7 {
+		t.Errorf("Expected 7 logged events, got %d", lines)
+	}
+}
@@ -0,0 +1,145 @@
+// Package agent shows cards in the visitor's language where the corpus has
+// a translation. The searches and the formatter work on the English corpus;
+// afterwards each card's text is swapped for the translated paragraphs or
+// verses it was taken from, found by talk ID and paragraph or by verse ID
+// through the translations toolset. Cards without a translation stay in
+// English.
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// translationStore invokes the translations tools.
+type translationStore struct {
+	talk, verses toolFunc
+}
+
+// talkTranslationRow is a row of get_talk_translation.
+type talkTranslationRow struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
+
+// verseTranslationRow is a row of get_verse_translations.
+type verseTranslationRow struct {
+	VerseNumber any    `json:"verse_number"`
+	BookName    string `json:"book_name"`
+	VerseText   string `json:"verse_text"`
+}
+
+// localize translates the cards of a formatter response whose sources are
+// in idx. Content it can't parse is returned unchanged.
+func (s *translationStore) localize(ctx context.Context, content string, idx *sourceIndex, lang Language) string {
+	var resp struct {
+		Quotes     []StructuredQuote     `json:"quotes"`
+		Scriptures []StructuredScripture `json:"scriptures"`
+	}
+	if err := json.NewDecoder(strings.NewReader(content)).Decode(&resp); err != nil {
+		return content
+	}
+	var data []byte
+	var err error
+	switch {
+	case resp.Quotes != nil:
+		for i := range resp.Quotes {
+			s.localizeQuote(ctx, &resp.Quotes[i], idx, lang)
+		}
+		data, err = json.Marshal(PresidentsResponse{Quotes: resp.Quotes})
+	case resp.Scriptures != nil:
+		for i := range resp.Scriptures {
+			s.localizeScripture(ctx, &resp.Scriptures[i], idx, lang)
+		}
+		data, err = json.Marshal(ScripturesResponse{Scriptures: resp.Scriptures})
+	default:
+		return content
+	}
+	if err != nil {
+		return content
+	}
+	return string(data)
+}
+
+// localizeQuote replaces a quote with the translated paragraphs it spans.
+// The translation has the same paragraphs as the English talk, so the
+// quote's paragraphs are found in the English row and taken whole from the
+// translation.
+func (s *translationStore) localizeQuote(ctx context.Context, q *StructuredQuote, idx *sourceIndex, lang Language) {
+	row := idx.talk(q.TalkID)
+	if row == nil {
+		return
+	}
+	first, last := paragraphSpan(row.Content, q.Quote)
+	if first < 0 {
+		return
+	}
+	result, err := s.talk(ctx, map[string]any{"talk_id": q.TalkID, "language": string(lang)})
+	if err != nil {
+		log.Printf("[translations] get_talk_translation %s failed: %v", q.TalkID, err)
+		return
+	}
+	var rows []talkTranslationRow
+	if err := decodeRows(result, &rows); err != nil || len(rows) == 0 {
+		return
+	}
+	paragraphs := strings.Split(rows[0].Content, "\n\n")
+	if last >= len(paragraphs) {
+		return
+	}
+	q.Quote = strings.Join(paragraphs[first:last+1], " ")
+	if rows[0].Title != "" {
+		q.Title = rows[0].Title
+	}
+}
+
+// verseRange matches the verses of a reference after its book name, e.g.
+// " 10:4-5".
+var verseRange = regexp.MustCompile(`^\s*\d+:(\d+)(?:\s*[-–]\s*(\d+))?`)
+
+// localizeScripture replaces a scripture's text with the translated verses
+// of its reference, and its book name with the translated one. Only a
+// complete translation of the verses is used.
+func (s *translationStore) localizeScripture(ctx context.Context, sc *StructuredScripture, idx *sourceIndex, lang Language) {
+	row := idx.verse(sc.VerseID)
+	if row == nil || !strings.HasPrefix(sc.Reference, row.Book) {
+		return
+	}
+	m := verseRange.FindStringSubmatch(sc.Reference[len(row.Book):])
+	if m == nil {
+		return
+	}
+	firstVerse, _ := strconv.Atoi(m[1])
+	lastVerse := firstVerse
+	if m[2] != "" {
+		lastVerse, _ = strconv.Atoi(m[2])
+	}
+	if lastVerse < firstVerse {
+		return
+	}
+	result, err := s.verses(ctx, map[string]any{"verse_id": sc.VerseID, "last_verse": lastVerse, "language": string(lang)})
+	if err != nil {
+		log.Printf("[translations] get_verse_translations %s failed: %v", sc.VerseID, err)
+		return
+	}
+	var rows []verseTranslationRow
+	if err := decodeRows(result, &rows); err != nil || len(rows) != lastVerse-firstVerse+1 {
+		return
+	}
+	texts := make([]string, len(rows))
+	for i, r := range rows {
+		if fmt.Sprint(r.VerseNumber) != strconv.Itoa(firstVerse+i) || r.VerseText == "" {
+			return
+		}
+		texts[i] = r.VerseText
+	}
+	sc.Text = strings.Join(texts, " ")
+	if rows[0].BookName != "" {
+		sc.Reference = rows[0].BookName + sc.Reference[len(row.Book):]
+	}
+}
@@ -0,0 +1,56 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestLocalizeCards(t *testing.T) {
+	store := &translationStore{
+		talk: func(_ context.Context, args map[string]any) (any, error) {
+			if args["talk_id"] != "13oaks" || args["language"] != "es" {
+				return "[]", nil
+			}
+			return `[{"title":"Convenios","content":"Palabras iniciales.\n\nLa senda de los convenios lleva a casa.\n\nSigan andando en ella.\n\nConclusión."}]`, nil
+		},
+		verses: func(_ context.Context, args map[string]any) (any, error) {
+			if args["verse_id"] != "bofm-moro-10-4" || args["last_verse"] != 5 {
+				return "[]", nil
+			}
+			return []map[string]any{
+				{"verse_number": 4.0, "book_name": "Moroni", "verse_text": "Y cuando recibáis estas cosas..."},
+				{"verse_number": 5.0, "book_name": "Moroni", "verse_text": "Y por el poder del Espíritu Santo..."},
+			}, nil
+		},
+	}
+	ctx := context.Background()
+
+	talks := newSourceIndex(`[{"talk_id":"13oaks","title":"Covenants","content":"Opening words.\n\nThe covenant path leads home.\n\nKeep walking it, every single day.\n\nClosing."}]`, LanguageSpanish)
+	out := store.localize(ctx, `{"quotes":[{"speaker":"President Dallin H. Oaks","title":"Covenants","quote":"The covenant path leads home. Keep walking it, every single day.","talk_id":"13oaks"}]}`, talks, LanguageSpanish)
+	var quotes PresidentsResponse
+	if err := json.Unmarshal([]byte(out), &quotes); err != nil {
+		t.Fatal(err)
+	}
+	if q := quotes.Quotes[0]; q.Title != "Convenios" || q.Quote != "La senda de los convenios lleva a casa. Sigan andando en ella." {
+		t.Errorf("quote = %+v", q)
+	}
+
+	verses := newSourceIndex([]map[string]any{
+		{"verse_id": "bofm-moro-10-4", "book_name": "Moroni", "chapter_number": 10.0, "verse_number": 4.0, "verse_text": "And when ye shall receive these things..."},
+	}, LanguageSpanish)
+	out = store.localize(ctx, `{"scriptures":[{"volume":"Book of Mormon","reference":"Moroni 10:4-5","text":"And when ye shall receive these things...","verse_id":"bofm-moro-10-4"}]}`, verses, LanguageSpanish)
+	var scriptures ScripturesResponse
+	if err := json.Unmarshal([]byte(out), &scriptures); err != nil {
+		t.Fatal(err)
+	}
+	if s := scriptures.Scriptures[0]; s.Text != "Y cuando recibáis estas cosas... Y por el poder del Espíritu Santo..." || s.Reference != "Moroni 10:4-5" {
+		t.Errorf("scripture = %+v", s)
+	}
+
+	// Without a translation the card stays in English
+	out = store.localize(ctx, `{"scriptures":[{"reference":"Moroni 10:4","text":"English","verse_id":"bofm-moro-10-4"}]}`, verses, LanguageSpanish)
+	if err := json.Unmarshal([]byte(out), &scriptures); err != nil || scriptures.Scriptures[0].Text != "English" {
+		t.Errorf("untranslated scripture = %s", out)
+	}
+}
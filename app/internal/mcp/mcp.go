@@ -0,0 +1,284 @@
+// Package mcp serves tools over the Model Context Protocol, so other
+// assistants can call them. It implements the tools subset of the protocol
+// over stdio (newline-delimited JSON-RPC) and stateless streamable HTTP
+// (one JSON response per POST).
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"sync"
+)
+
+// LatestProtocolVersion is offered to clients that ask for a version this
+// server doesn't know.
+const LatestProtocolVersion = "2025-06-18"
+
+// supportedVersions are the protocol versions the server speaks, newest
+// first. The tools subset is the same in all of them.
+var supportedVersions = []string{LatestProtocolVersion, "2025-03-26", "2024-11-05"}
+
+// maxMessageBytes bounds a single JSON-RPC message.
+const maxMessageBytes = 1 << 20
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Tool is a tool the server exposes.
+type Tool struct {
+	Name        string
+	Title       string
+	Description string
+	// InputSchema and OutputSchema are JSON Schemas of type object.
+	// OutputSchema may be nil.
+	InputSchema  any
+	OutputSchema any
+	// Call runs the tool with its raw arguments. An error is reported to
+	// the model as a failed tool result, not as a protocol error.
+	Call func(ctx context.Context, arguments json.RawMessage) (*Result, error)
+}
+
+// Result is a tool's result.
+type Result struct {
+	Content           []Content `json:"content"`
+	StructuredContent any       `json:"structuredContent,omitempty"`
+	IsError           bool      `json:"isError,omitempty"`
+}
+
+// Content is a block of a tool result.
+type Content struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+}
+
+// TextResult is a result of plain text.
+func TextResult(text string) *Result {
+	return &Result{Content: []Content{{Type: "text", Text: text}}}
+}
+
+// StructuredResult is a result whose structured content is v. The JSON is
+// repeated as text for clients that don't read structured content.
+func StructuredResult(v any) (*Result, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Content: []Content{{Type: "text", Text: string(b)}}, StructuredContent: v}, nil
+}
+
+// Server dispatches MCP requests to its tools.
+type Server struct {
+	name, version string
+	instructions  string
+	tools         []Tool
+}
+
+// NewServer returns a server that introduces itself as name and version.
+// instructions, if set, tell the client's model how to use the tools.
+func NewServer(name, version, instructions string) *Server {
+	return &Server{name: name, version: version, instructions: instructions}
+}
+
+// AddTool adds a tool. Tools are listed in the order they are added.
+func (s *Server) AddTool(tool Tool) {
+	s.tools = append(s.tools, tool)
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	// Result and Error are set on responses from the client
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  json.RawMessage `json:"error,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+type toolInfo struct {
+	Name         string `json:"name"`
+	Title        string `json:"title,omitempty"`
+	Description  string `json:"description,omitempty"`
+	InputSchema  any    `json:"inputSchema"`
+	OutputSchema any    `json:"outputSchema,omitempty"`
+}
+
+// Handle processes one JSON-RPC message and returns the response to send,
+// or nil for a notification.
+func (s *Server) Handle(ctx context.Context, msg []byte) []byte {
+	var req request
+	if err := json.Unmarshal(msg, &req); err != nil {
+		return encode(response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{codeParseError, "parse error"}})
+	}
+	if req.Method == "" && (len(req.Result) > 0 || len(req.Error) > 0) {
+		// A response from the client; nothing is ever asked of it
+		return nil
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		if len(req.ID) == 0 {
+			return nil
+		}
+		return encode(response{JSONRPC: "2.0", ID: req.ID, Error: &rpcError{codeInvalidRequest, "invalid request"}})
+	}
+	result, err := s.dispatch(ctx, req)
+	if len(req.ID) == 0 {
+		return nil
+	}
+	resp := response{JSONRPC: "2.0", ID: req.ID, Result: result}
+	if err != nil {
+		var rpcErr *rpcError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &rpcError{codeInvalidRequest, err.Error()}
+		}
+		resp.Result, resp.Error = nil, rpcErr
+	}
+	return encode(resp)
+}
+
+func (s *Server) dispatch(ctx context.Context, req request) (any, error) {
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		json.Unmarshal(req.Params, &params)
+		version := LatestProtocolVersion
+		if slices.Contains(supportedVersions, params.ProtocolVersion) {
+			version = params.ProtocolVersion
+		}
+		return map[string]any{
+			"protocolVersion": version,
+			"capabilities":    map[string]any{"tools": map[string]any{"listChanged": false}},
+			"serverInfo":      map[string]string{"name": s.name, "version": s.version},
+			"instructions":    s.instructions,
+		}, nil
+	case "ping":
+		return map[string]any{}, nil
+	case "tools/list":
+		tools := make([]toolInfo, len(s.tools))
+		for i, t := range s.tools {
+			tools[i] = toolInfo{Name: t.Name, Title: t.Title, Description: t.Description, InputSchema: t.InputSchema, OutputSchema: t.OutputSchema}
+		}
+		return map[string]any{"tools": tools}, nil
+	case "tools/call":
+		return s.call(ctx, req.Params)
+	}
+	if len(req.ID) == 0 {
+		// notifications/initialized, notifications/cancelled, ...
+		return nil, nil
+	}
+	return nil, &rpcError{codeMethodNotFound, "method not found: " + req.Method}
+}
+
+func (s *Server) call(ctx context.Context, raw json.RawMessage) (*Result, error) {
+	var params struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, &rpcError{codeInvalidParams, "invalid tools/call params"}
+	}
+	i := slices.IndexFunc(s.tools, func(t Tool) bool { return t.Name == params.Name })
+	if i < 0 {
+		return nil, &rpcError{codeInvalidParams, "unknown tool: " + params.Name}
+	}
+	if len(params.Arguments) == 0 {
+		params.Arguments = json.RawMessage("{}")
+	}
+	result, err := s.tools[i].Call(ctx, params.Arguments)
+	if err != nil {
+		log.Printf("MCP: Tool %s failed: %v", params.Name, err)
+		result = TextResult(err.Error())
+		result.IsError = true
+	}
+	return result, nil
+}
+
+func encode(resp response) []byte {
+	b, err := json.Marshal(resp)
+	if err != nil {
+		b, _ = json.Marshal(response{JSONRPC: "2.0", ID: resp.ID, Error: &rpcError{codeInvalidRequest, fmt.Sprintf("failed to encode result: %v", err)}})
+	}
+	return b
+}
+
+// ServeStdio reads newline-delimited messages from r and writes responses
+// to w until r ends or ctx is cancelled. Requests run concurrently, since a
+// tool call can take a while; responses are written whole, one per line.
+func (s *Server) ServeStdio(ctx context.Context, r io.Reader, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), maxMessageBytes)
+	for scanner.Scan() {
+		line := append([]byte(nil), scanner.Bytes()...)
+		if len(line) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			out := s.Handle(ctx, line)
+			if out == nil {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			w.Write(append(out, '\n'))
+		}()
+	}
+	return scanner.Err()
+}
+
+// ServeHTTP serves the streamable HTTP transport statelessly: every POST
+// carries one message and gets its response as JSON. There is no session
+// and the server never sends requests of its own, so GET and DELETE are
+// not allowed.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	msg, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxMessageBytes))
+	if err != nil {
+		http.Error(w, "request too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	out := s.Handle(r.Context(), msg)
+	if out == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(out)
+}
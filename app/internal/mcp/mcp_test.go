@@ -0,0 +1,187 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func testServer() *Server {
+	s := NewServer("test", "0.1", "Use echo.")
+	s.AddTool(Tool{
+		Name:        "echo",
+		Description: "Echoes text",
+		InputSchema: map[string]any{"type": "object"},
+		Call: func(ctx context.Context, args json.RawMessage) (*Result, error) {
+			var in struct {
+				Text string `json:"text"`
+			}
+			json.Unmarshal(args, &in)
+			if in.Text == "" {
+				return nil, errors.New("text is required")
+			}
+			return StructuredResult(map[string]string{"text": in.Text})
+		},
+	})
+	return s
+}
+
+type testResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+func call(t *testing.T, s *Server, msg string) testResponse {
+	t.Helper()
+	out := s.Handle(context.Background(), []byte(msg))
+	if out == nil {
+		t.Fatalf("no response to %s", msg)
+	}
+	var resp testResponse
+	if err := json.Unmarshal(out, &resp); err != nil {
+		t.Fatalf("bad response %s: %v", out, err)
+	}
+	return resp
+}
+
+func TestInitialize(t *testing.T) {
+	s := testServer()
+	for requested, want := range map[string]string{
+		"2025-03-26": "2025-03-26",
+		"1999-01-01": LatestProtocolVersion,
+	} {
+		resp := call(t, s, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"`+requested+`","capabilities":{},"clientInfo":{"name":"c","version":"1"}}}`)
+		var result struct {
+			ProtocolVersion string `json:"protocolVersion"`
+			Capabilities    struct {
+				Tools map[string]any `json:"tools"`
+			} `json:"capabilities"`
+			ServerInfo struct {
+				Name string `json:"name"`
+			} `json:"serverInfo"`
+			Instructions string `json:"instructions"`
+		}
+		if err := json.Unmarshal(resp.Result, &result); err != nil {
+			t.Fatal(err)
+		}
+		if result.ProtocolVersion != want || result.Capabilities.Tools == nil || result.ServerInfo.Name != "test" || result.Instructions == "" {
+			t.Errorf("initialize(%s) = %+v", requested, result)
+		}
+	}
+	if string(call(t, s, `{"jsonrpc":"2.0","id":"a","method":"ping"}`).ID) != `"a"` {
+		t.Error("ping should echo a string id")
+	}
+}
+
+func TestNotificationsGetNoResponse(t *testing.T) {
+	s := testServer()
+	for _, msg := range []string{
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","method":"notifications/unknown"}`,
+		`{"jsonrpc":"2.0","id":3,"result":{}}`,
+	} {
+		if out := s.Handle(context.Background(), []byte(msg)); out != nil {
+			t.Errorf("%s got response %s", msg, out)
+		}
+	}
+}
+
+func TestToolsListAndCall(t *testing.T) {
+	s := testServer()
+	var list struct {
+		Tools []toolInfo `json:"tools"`
+	}
+	json.Unmarshal(call(t, s, `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`).Result, &list)
+	if len(list.Tools) != 1 || list.Tools[0].Name != "echo" || list.Tools[0].InputSchema == nil {
+		t.Fatalf("tools = %+v", list.Tools)
+	}
+
+	var result Result
+	json.Unmarshal(call(t, s, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{"text":"hi"}}}`).Result, &result)
+	if result.IsError || len(result.Content) != 1 || result.Content[0].Text != `{"text":"hi"}` {
+		t.Errorf("call = %+v", result)
+	}
+	if got, _ := json.Marshal(result.StructuredContent); string(got) != `{"text":"hi"}` {
+		t.Errorf("structured content = %s", got)
+	}
+
+	// Tool failures are results the model can read, not protocol errors
+	resp := call(t, s, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"echo"}}`)
+	result = Result{}
+	json.Unmarshal(resp.Result, &result)
+	if resp.Error != nil || !result.IsError || result.Content[0].Text != "text is required" {
+		t.Errorf("failed call = %+v, %+v", resp.Error, result)
+	}
+}
+
+func TestProtocolErrors(t *testing.T) {
+	s := testServer()
+	tests := []struct {
+		msg  string
+		code int
+	}{
+		{`{not json`, codeParseError},
+		{`{"jsonrpc":"1.0","id":1,"method":"ping"}`, codeInvalidRequest},
+		{`{"jsonrpc":"2.0","id":1,"method":"resources/list"}`, codeMethodNotFound},
+		{`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"nope"}}`, codeInvalidParams},
+	}
+	for _, tt := range tests {
+		if resp := call(t, s, tt.msg); resp.Error == nil || resp.Error.Code != tt.code {
+			t.Errorf("%s: error = %+v, want code %d", tt.msg, resp.Error, tt.code)
+		}
+	}
+}
+
+func TestServeStdio(t *testing.T) {
+	in := strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18"}}
+{"jsonrpc":"2.0","method":"notifications/initialized"}
+
+{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{"text":"hi"}}}
+`)
+	var out bytes.Buffer
+	if err := testServer().ServeStdio(context.Background(), in, &out); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("responses = %q", lines)
+	}
+	ids := map[string]bool{}
+	for _, line := range lines {
+		var resp testResponse
+		if err := json.Unmarshal([]byte(line), &resp); err != nil || resp.Error != nil {
+			t.Errorf("bad line %s", line)
+		}
+		ids[string(resp.ID)] = true
+	}
+	if !ids["1"] || !ids["2"] {
+		t.Errorf("ids = %v", ids)
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	s := testServer()
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body)))
+		return w
+	}
+	w := post(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" || !strings.Contains(w.Body.String(), `"echo"`) {
+		t.Errorf("tools/list = %d %q %s", w.Code, w.Header().Get("Content-Type"), w.Body)
+	}
+	if w := post(`{"jsonrpc":"2.0","method":"notifications/initialized"}`); w.Code != http.StatusAccepted || w.Body.Len() != 0 {
+		t.Errorf("notification = %d %s", w.Code, w.Body)
+	}
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/mcp", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET = %d", w.Code)
+	}
+}
@@ -0,0 +1,143 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testQuote struct {
+	Speaker string `json:"speaker" doc:"Who said it"`
+	Source  string `json:"source_url,omitempty"`
+}
+
+type testAnswer struct {
+	Question string          `json:"question"`
+	Status   string          `json:"status" enum:"ok,failed"`
+	Quotes   []testQuote     `json:"quotes"`
+	Best     *testQuote      `json:"best,omitempty"`
+	Tags     map[string]int  `json:"tags,omitempty"`
+	At       time.Time       `json:"at"`
+	Raw      json.RawMessage `json:"raw,omitempty"`
+	Internal string          `json:"-"`
+	hidden   string
+	Nested   struct{ N bool }  `json:"nested"`
+	Children []*testAnswer     `json:"children,omitempty"`
+	Extra    map[string]string `json:"extra,omitempty"`
+}
+
+func TestSchemaFromStruct(t *testing.T) {
+	doc := New(Info{Title: "test", Version: "1"})
+	ref := doc.Schema(testAnswer{})
+	if ref.Ref != "#/components/schemas/testAnswer" {
+		t.Fatalf("ref = %q", ref.Ref)
+	}
+	s := doc.Components.Schemas["testAnswer"]
+	if s == nil || s.Type != "object" {
+		t.Fatalf("testAnswer schema = %+v", s)
+	}
+
+	wantRequired := []string{"question", "status", "quotes", "at", "nested"}
+	if !reflect.DeepEqual(s.Required, wantRequired) {
+		t.Errorf("required = %v, want %v", s.Required, wantRequired)
+	}
+	for _, name := range []string{"Internal", "hidden", "-"} {
+		if _, ok := s.Properties[name]; ok {
+			t.Errorf("property %q should be left out", name)
+		}
+	}
+	if got := s.Properties["status"].Enum; !reflect.DeepEqual(got, []string{"ok", "failed"}) {
+		t.Errorf("status enum = %v", got)
+	}
+	if quotes := s.Properties["quotes"]; quotes.Type != "array" || quotes.Items.Ref != "#/components/schemas/testQuote" {
+		t.Errorf("quotes = %+v", quotes)
+	}
+	if best := s.Properties["best"]; best.Ref != "#/components/schemas/testQuote" {
+		t.Errorf("best = %+v", best)
+	}
+	if tags := s.Properties["tags"]; tags.Type != "object" || tags.AdditionalProperties.Type != "integer" {
+		t.Errorf("tags = %+v", tags)
+	}
+	if at := s.Properties["at"]; at.Type != "string" || at.Format != "date-time" {
+		t.Errorf("at = %+v", at)
+	}
+	if nested := s.Properties["nested"]; nested.Type != "object" || nested.Properties["N"].Type != "boolean" {
+		t.Errorf("nested = %+v", nested)
+	}
+	if children := s.Properties["children"]; children.Items.Ref != "#/components/schemas/testAnswer" {
+		t.Errorf("recursive children = %+v", children)
+	}
+
+	quote := doc.Components.Schemas["testQuote"]
+	if quote.Properties["speaker"].Description != "Who said it" {
+		t.Errorf("speaker description = %q", quote.Properties["speaker"].Description)
+	}
+	if !reflect.DeepEqual(quote.Required, []string{"speaker"}) {
+		t.Errorf("testQuote required = %v", quote.Required)
+	}
+}
+
+func TestDocumentedRefLeavesComponentUndecorated(t *testing.T) {
+	type wrapper struct {
+		Quote testQuote `json:"quote" doc:"The chosen quote"`
+	}
+	doc := New(Info{Title: "test", Version: "1"})
+	doc.Schema(wrapper{})
+	if got := doc.Components.Schemas["wrapper"].Properties["quote"]; got.Ref == "" || got.Description != "The chosen quote" {
+		t.Errorf("quote property = %+v", got)
+	}
+	if got := doc.Components.Schemas["testQuote"].Description; got != "" {
+		t.Errorf("component description = %q, want none", got)
+	}
+}
+
+func TestEmbeddedFieldsArePromoted(t *testing.T) {
+	type base struct {
+		ID string `json:"id"`
+	}
+	type item struct {
+		base
+		Name string `json:"name"`
+	}
+	doc := New(Info{Title: "test", Version: "1"})
+	doc.Schema(item{})
+	s := doc.Components.Schemas["item"]
+	if _, ok := s.Properties["id"]; !ok {
+		t.Errorf("embedded id not promoted: %+v", s.Properties)
+	}
+}
+
+func TestDocumentMarshals(t *testing.T) {
+	doc := New(Info{Title: "test", Version: "1"})
+	doc.Path("/things").Post = &Operation{
+		OperationID: "createThing",
+		RequestBody: &RequestBody{Content: map[string]MediaType{"application/json": {Schema: doc.Schema(testQuote{})}}},
+		Responses:   map[string]*Response{"200": {Description: "ok"}},
+	}
+	b, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded["openapi"] != Version {
+		t.Errorf("openapi = %v", decoded["openapi"])
+	}
+	if _, ok := decoded["paths"].(map[string]any)["/things"].(map[string]any)["post"]; !ok {
+		t.Errorf("missing POST /things: %s", b)
+	}
+}
+
+func TestInlineSchema(t *testing.T) {
+	type wrapper struct {
+		Quotes []testQuote `json:"quotes"`
+	}
+	s := InlineSchema(wrapper{})
+	items := s.Properties["quotes"].Items
+	if s.Type != "object" || items.Ref != "" || items.Properties["speaker"] == nil {
+		t.Errorf("schema = %+v, items = %+v", s, items)
+	}
+}
@@ -0,0 +1,212 @@
+package qr
+
+import (
+	"bytes"
+	"image/png"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReedSolomon(t *testing.T) {
+	// "HELLO WORLD" at 1-M, from the worked example in the standard
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := reedSolomonRemainder(data, reedSolomonDivisor(10)); !bytes.Equal(got, want) {
+		t.Errorf("ECC = %v, want %v", got, want)
+	}
+}
+
+func TestFormatAndVersionBits(t *testing.T) {
+	cases := []struct {
+		level Level
+		mask  int
+		want  int
+	}{
+		{Medium, 0, 0b101010000010010},
+		{Low, 4, 0b110011000101111},
+		{High, 7, 0b000100000111011},
+	}
+	for _, c := range cases {
+		if got := formatBits(c.level, c.mask); got != c.want {
+			t.Errorf("formatBits(%d, %d) = %015b, want %015b", c.level, c.mask, got, c.want)
+		}
+	}
+	if got := versionBits(7); got != 0b000111110010010100 {
+		t.Errorf("versionBits(7) = %018b", got)
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	cases := []struct {
+		version int
+		level   Level
+		want    int
+	}{
+		{1, Medium, 16},
+		{1, High, 9},
+		{7, Low, 156},
+		{10, Medium, 216},
+		{14, Medium, 365},
+	}
+	for _, c := range cases {
+		if got := numDataCodewords(c.version, c.level); got != c.want {
+			t.Errorf("numDataCodewords(%d, %d) = %d, want %d", c.version, c.level, got, c.want)
+		}
+	}
+	if got := alignmentPositions(14); !reflect.DeepEqual(got, []int{6, 26, 46, 66}) {
+		t.Errorf("alignmentPositions(14) = %v", got)
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"https://app.templesquare.dev/a/3f9c2b1e8d7a6c5b",
+		strings.Repeat("https://www.churchofjesuschrist.org/study/general-conference/", 3),
+	} {
+		for level := Low; level <= High; level++ {
+			c, err := Encode(text, level)
+			if err != nil {
+				t.Fatalf("Encode(%d bytes, %d): %v", len(text), level, err)
+			}
+			if got := decode(t, c); got != text {
+				t.Errorf("decoded %q, want %q", got, text)
+			}
+		}
+	}
+}
+
+func TestEncodeTooLong(t *testing.T) {
+	if _, err := Encode(strings.Repeat("x", 400), Medium); err != ErrTooLong {
+		t.Errorf("err = %v, want ErrTooLong", err)
+	}
+}
+
+func TestFinderPatterns(t *testing.T) {
+	c, err := Encode("hello", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, corner := range [][2]int{{0, 0}, {c.Size - 7, 0}, {0, c.Size - 7}} {
+		for i := 0; i < 7; i++ {
+			x, y := corner[0]+i, corner[1]
+			if !c.Black(x, y) || !c.Black(x, y+6) || !c.Black(corner[0], y+i) || !c.Black(corner[0]+6, y+i) {
+				t.Fatalf("finder at %v has a light edge module", corner)
+			}
+		}
+		if c.Black(corner[0]+1, corner[1]+1) || !c.Black(corner[0]+3, corner[1]+3) {
+			t.Errorf("finder at %v has the wrong ring", corner)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	c, err := Encode("hello", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := c.PNG(4, DefaultBorder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if side := (c.Size + 2*DefaultBorder) * 4; img.Bounds().Dx() != side {
+		t.Errorf("PNG is %dpx wide, want %d", img.Bounds().Dx(), side)
+	}
+	svg := string(c.SVG(DefaultBorder))
+	if !strings.HasPrefix(svg, "<svg") || !strings.Contains(svg, `viewBox="0 0 29 29"`) {
+		t.Errorf("unexpected SVG: %.80s", svg)
+	}
+}
+
+// decode reads a code back: it unmasks the data modules, collects the
+// codewords in placement order, de-interleaves the data blocks and parses
+// the byte-mode segment.
+func decode(t *testing.T, c *Code) string {
+	t.Helper()
+	ref := newCode(c.Version, c.Level)
+	var bits bitBuffer
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < c.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = c.Size - 1 - vert
+				}
+				if ref.function[y*c.Size+x] {
+					continue
+				}
+				ref.modules[y*c.Size+x] = c.Black(x, y)
+			}
+		}
+	}
+	ref.applyMask(c.Mask)
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < c.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = c.Size - 1 - vert
+				}
+				if !ref.function[y*c.Size+x] {
+					bits = append(bits, ref.modules[y*c.Size+x])
+				}
+			}
+		}
+	}
+	codewords := bits.bytes()
+
+	numBlocks := numErrorCorrectionBlocks[c.Level][c.Version]
+	blockECCLen := eccCodewordsPerBlock[c.Level][c.Version]
+	rawCodewords := numRawDataModules(c.Version) / 8
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortDataLen := rawCodewords/numBlocks - blockECCLen
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for i := 0; i <= shortDataLen; i++ {
+		for j := range blocks {
+			if i == shortDataLen && j < numShortBlocks {
+				continue
+			}
+			blocks[j] = append(blocks[j], codewords[k])
+			k++
+		}
+	}
+	var data bitBuffer
+	for _, block := range blocks {
+		for _, b := range block {
+			data.append(int(b), 8)
+		}
+	}
+
+	read := func(n int) int {
+		v := 0
+		for i := 0; i < n; i++ {
+			v <<= 1
+			if data[0] {
+				v |= 1
+			}
+			data = data[1:]
+		}
+		return v
+	}
+	if mode := read(4); mode != 0x4 {
+		t.Fatalf("mode = %04b, want byte mode", mode)
+	}
+	n := read(charCountBits(c.Version))
+	out := make([]byte, n)
+	for i := range out {
+		out[i] = byte(read(8))
+	}
+	return string(out)
+}
@@ -0,0 +1,53 @@
+package qr
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+)
+
+// DefaultBorder is the quiet zone the standard asks for, in modules.
+const DefaultBorder = 4
+
+// PNG renders the code with scale pixels per module and border modules of
+// quiet zone on each side.
+func (c *Code) PNG(scale, border int) ([]byte, error) {
+	if scale < 1 || border < 0 {
+		return nil, fmt.Errorf("qr: invalid scale %d or border %d", scale, border)
+	}
+	side := (c.Size + 2*border) * scale
+	img := image.NewPaletted(image.Rect(0, 0, side, side), color.Palette{color.White, color.Black})
+	for py := 0; py < side; py++ {
+		for px := 0; px < side; px++ {
+			if c.Black(px/scale-border, py/scale-border) {
+				img.SetColorIndex(px, py, 1)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG renders the code as a scalable image, one unit per module, with border
+// modules of quiet zone on each side.
+func (c *Code) SVG(border int) []byte {
+	side := c.Size + 2*border
+	var path strings.Builder
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.Black(x, y) {
+				fmt.Fprintf(&path, "M%d,%dh1v1h-1z", x+border, y+border)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, side, side)
+	fmt.Fprintf(&buf, `<rect width="100%%" height="100%%" fill="#fff"/><path d="%s" fill="#000"/></svg>`, path.String())
+	return buf.Bytes()
+}
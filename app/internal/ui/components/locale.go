@@ -0,0 +1,214 @@
+package components
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type localeKey struct{}
+
+// WithLocale makes components rendered with ctx show their text in locale
+// ("es", "pt-BR"). Locales without a catalog render in English.
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, normalizeLocale(locale))
+}
+
+// normalizeLocale reduces a locale to its language code, "en" if empty.
+func normalizeLocale(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(locale, "-_"); i >= 0 {
+		locale = locale[:i]
+	}
+	if locale == "" {
+		return "en"
+	}
+	return locale
+}
+
+func localeFrom(ctx context.Context) string {
+	if locale, ok := ctx.Value(localeKey{}).(string); ok {
+		return locale
+	}
+	return "en"
+}
+
+// catalog holds the UI text in each language, keyed by its English text.
+var catalog = map[string]map[string]string{
+	"es": {
+		// Sections
+		"Recent Remarks From Church Presidents":         "Palabras recientes de los Presidentes de la Iglesia",
+		"Recent Remarks From Other Church Leaders":      "Palabras recientes de otros líderes de la Iglesia",
+		"Related Scriptures":                            "Pasajes de las Escrituras relacionados",
+		"Summary":                                       "Resumen",
+		"Searching teachings from Church Presidents...": "Buscando enseñanzas de los Presidentes de la Iglesia...",
+		"Searching teachings from Church leaders...":    "Buscando enseñanzas de los líderes de la Iglesia...",
+		"Finding relevant scriptures...":                "Buscando pasajes de las Escrituras...",
+		"Read the full talk":                            "Leer el discurso completo",
+		"Read the chapter":                              "Leer el capítulo",
+		"Find the talk":                                 "Buscar el discurso",
+		"QR code linking to this page":                  "Código QR de esta página",
+		"Bible":                                         "Biblia",
+		"Book of Mormon":                                "Libro de Mormón",
+		"Other Scriptures":                              "Otras Escrituras",
+		"Old Testament":                                 "Antiguo Testamento",
+		"New Testament":                                 "Nuevo Testamento",
+		"Doctrine and Covenants":                        "Doctrina y Convenios",
+		"Pearl of Great Price":                          "Perla de Gran Precio",
+		"Back to top":                                   "Volver arriba",
+
+		// Terminal section cards
+		"We couldn't find remarks from Church Presidents on this topic in time. Try rephrasing your question.": "No encontramos a tiempo palabras de los Presidentes de la Iglesia sobre este tema. Intenta expresar tu pregunta de otra manera.",
+		"We couldn't load remarks from Church Presidents right now. The rest of your answer is below.":         "No pudimos cargar las palabras de los Presidentes de la Iglesia en este momento. El resto de tu respuesta está abajo.",
+		"We couldn't find remarks from other Church leaders on this topic in time.":                            "No encontramos a tiempo palabras de otros líderes de la Iglesia sobre este tema.",
+		"We couldn't load remarks from other Church leaders right now.":                                        "No pudimos cargar las palabras de otros líderes de la Iglesia en este momento.",
+		"We couldn't find related scriptures in time.":                                                         "No encontramos a tiempo pasajes de las Escrituras relacionados.",
+		"We couldn't load related scriptures right now.":                                                       "No pudimos cargar los pasajes de las Escrituras en este momento.",
+		"A summary isn't available for this question right now.":                                               "No hay un resumen disponible para esta pregunta en este momento.",
+		"We couldn't put together a summary right now. The quotes above still answer your question.":           "No pudimos preparar un resumen en este momento. Las citas de arriba igual responden tu pregunta.",
+
+		// Follow-ups, queue and errors
+		"Want to know more?":                 "¿Quieres saber más?",
+		"Ask a follow-up question":           "Haz otra pregunta sobre este tema",
+		"ASK":                                "PREGUNTAR",
+		"Many visitors are asking right now": "Muchos visitantes están preguntando ahora mismo",
+		"Your question is next in line and will start momentarily.":                       "Tu pregunta es la siguiente y comenzará en un momento.",
+		"Your question is number %d in line and will start shortly.":                      "Tu pregunta es la número %d en la fila y comenzará en breve.",
+		"We're answering a lot of questions at the moment. Please try again in a minute.": "Estamos respondiendo muchas preguntas en este momento. Vuelve a intentarlo en un minuto.",
+		"Error rendering response":                                                        "Error al mostrar la respuesta",
+
+		// Redirects and related questions
+		"Here are some additional soul searching questions that might spark your curiosity:": "Estas son otras preguntas profundas que podrían despertar tu curiosidad:",
+		"Ask Another Question": "Hacer otra pregunta",
+		"You might also ask":   "También podrías preguntar",
+
+		// Take-home and saved answers
+		"QR code linking to this answer": "Código QR con el enlace a esta respuesta",
+		"Take this home":                 "Llévatelo a casa",
+		"Scan the code with your phone's camera to keep your question and everything on this page, with links to the full talks and scriptures.": "Escanea el código con la cámara de tu teléfono para guardar tu pregunta y todo lo que hay en esta página, con enlaces a los discursos y pasajes completos.",
+		"You asked":             "Preguntaste",
+		"Answered %s":           "Respondida el %s",
+		"Ask Your Own Question": "Haz tu propia pregunta",
+
+		// Callings
+		"President of The Church of Jesus Christ of Latter-day Saints": "Presidente de La Iglesia de Jesucristo de los Santos de los Últimos Días",
+		"First Counselor in the First Presidency":                      "Primer Consejero de la Primera Presidencia",
+		"Second Counselor in the First Presidency":                     "Segundo Consejero de la Primera Presidencia",
+		"President of the Quorum of the Twelve Apostles":               "Presidente del Cuórum de los Doce Apóstoles",
+		"Quorum of the Twelve Apostles":                                "Cuórum de los Doce Apóstoles",
+	},
+	"pt": {
+		// Sections
+		"Recent Remarks From Church Presidents":         "Palavras recentes dos Presidentes da Igreja",
+		"Recent Remarks From Other Church Leaders":      "Palavras recentes de outros líderes da Igreja",
+		"Related Scriptures":                            "Escrituras relacionadas",
+		"Summary":                                       "Resumo",
+		"Searching teachings from Church Presidents...": "Buscando ensinamentos dos Presidentes da Igreja...",
+		"Searching teachings from Church leaders...":    "Buscando ensinamentos dos líderes da Igreja...",
+		"Finding relevant scriptures...":                "Buscando escrituras relacionadas...",
+		"Read the full talk":                            "Ler o discurso completo",
+		"Read the chapter":                              "Ler o capítulo",
+		"Find the talk":                                 "Buscar o discurso",
+		"QR code linking to this page":                  "Código QR desta página",
+		"Bible":                                         "Bíblia",
+		"Book of Mormon":                                "Livro de Mórmon",
+		"Other Scriptures":                              "Outras escrituras",
+		"Old Testament":                                 "Velho Testamento",
+		"New Testament":                                 "Novo Testamento",
+		"Doctrine and Covenants":                        "Doutrina e Convênios",
+		"Pearl of Great Price":                          "Pérola de Grande Valor",
+		"Back to top":                                   "Voltar ao topo",
+
+		// Terminal section cards
+		"We couldn't find remarks from Church Presidents on this topic in time. Try rephrasing your question.": "Não encontramos a tempo palavras dos Presidentes da Igreja sobre este assunto. Tente fazer sua pergunta de outra forma.",
+		"We couldn't load remarks from Church Presidents right now. The rest of your answer is below.":         "Não conseguimos carregar as palavras dos Presidentes da Igreja agora. O restante da sua resposta está abaixo.",
+		"We couldn't find remarks from other Church leaders on this topic in time.":                            "Não encontramos a tempo palavras de outros líderes da Igreja sobre este assunto.",
+		"We couldn't load remarks from other Church leaders right now.":                                        "Não conseguimos carregar as palavras de outros líderes da Igreja agora.",
+		"We couldn't find related scriptures in time.":                                                         "Não encontramos a tempo escrituras relacionadas.",
+		"We couldn't load related scriptures right now.":                                                       "Não conseguimos carregar as escrituras relacionadas agora.",
+		"A summary isn't available for this question right now.":                                               "Não há um resumo disponível para esta pergunta agora.",
+		"We couldn't put together a summary right now. The quotes above still answer your question.":           "Não conseguimos preparar um resumo agora. As citações acima ainda respondem à sua pergunta.",
+
+		// Follow-ups, queue and errors
+		"Want to know more?":                 "Quer saber mais?",
+		"Ask a follow-up question":           "Faça outra pergunta sobre o assunto",
+		"ASK":                                "PERGUNTAR",
+		"Many visitors are asking right now": "Muitos visitantes estão perguntando agora",
+		"Your question is next in line and will start momentarily.":                       "Sua pergunta é a próxima da fila e começará em instantes.",
+		"Your question is number %d in line and will start shortly.":                      "Sua pergunta é a número %d da fila e começará em breve.",
+		"We're answering a lot of questions at the moment. Please try again in a minute.": "Estamos respondendo a muitas perguntas no momento. Tente novamente em um minuto.",
+		"Error rendering response":                                                        "Erro ao exibir a resposta",
+
+		// Redirects and related questions
+		"Here are some additional soul searching questions that might spark your curiosity:": "Aqui estão outras perguntas profundas que podem despertar sua curiosidade:",
+		"Ask Another Question": "Fazer outra pergunta",
+		"You might also ask":   "Você também pode perguntar",
+
+		// Take-home and saved answers
+		"QR code linking to this answer": "Código QR com o link para esta resposta",
+		"Take this home":                 "Leve para casa",
+		"Scan the code with your phone's camera to keep your question and everything on this page, with links to the full talks and scriptures.": "Escaneie o código com a câmera do seu celular para guardar sua pergunta e tudo o que está nesta página, com links para os discursos e as escrituras completos.",
+		"You asked":             "Você perguntou",
+		"Answered %s":           "Respondida em %s",
+		"Ask Your Own Question": "Faça sua própria pergunta",
+
+		// Callings
+		"President of The Church of Jesus Christ of Latter-day Saints": "Presidente de A Igreja de Jesus Cristo dos Santos dos Últimos Dias",
+		"First Counselor in the First Presidency":                      "Primeiro Conselheiro na Primeira Presidência",
+		"Second Counselor in the First Presidency":                     "Segundo Conselheiro na Primeira Presidência",
+		"President of the Quorum of the Twelve Apostles":               "Presidente do Quórum dos Doze Apóstolos",
+		"Quorum of the Twelve Apostles":                                "Quórum dos Doze Apóstolos",
+	},
+}
+
+// Translate returns text in locale, or text itself when it has no
+// translation.
+func Translate(locale, text string) string {
+	if translated, ok := catalog[normalizeLocale(locale)][text]; ok {
+		return translated
+	}
+	return text
+}
+
+// t translates text into the locale of ctx.
+func t(ctx context.Context, text string) string {
+	return Translate(localeFrom(ctx), text)
+}
+
+// tf translates format into the locale of ctx and formats it with args.
+func tf(ctx context.Context, format string, args ...any) string {
+	return fmt.Sprintf(t(ctx, format), args...)
+}
+
+// months holds the month names in each language, January first.
+var months = map[string][12]string{
+	"es": {"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+	"pt": {"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+}
+
+// monthYear matches a conference name such as "April 2024".
+var monthYear = regexp.MustCompile(`^(January|February|March|April|May|June|July|August|September|October|November|December) (\d{4})$`)
+
+// FormatDate formats a date as "January 2, 2006", or "2 de enero de 2006"
+// in Spanish and Portuguese.
+func FormatDate(locale string, date time.Time) string {
+	names, ok := months[normalizeLocale(locale)]
+	if !ok {
+		return date.Format("January 2, 2006")
+	}
+	return fmt.Sprintf("%d de %s de %d", date.Day(), names[date.Month()-1], date.Year())
+}
+
+// conference translates a conference name such as "April 2024" into the
+// locale of ctx. Other names are returned as they are.
+func conference(ctx context.Context, name string) string {
+	names, ok := months[localeFrom(ctx)]
+	m := monthYear.FindStringSubmatch(name)
+	if !ok || m == nil {
+		return name
+	}
+	month, _ := time.Parse("January", m[1])
+	return names[month.Month()-1] + " de " + m[2]
+}
@@ -63,22 +63,49 @@ function unauthorizedResponse() {
   });
 }
 
+// Take-home links are opened on visitors' phones, which don't have the
+// password. Saved answers have unguessable IDs; the page also needs its CSS.
+const PUBLIC_PATH_PREFIXES = ["/a/", "/static/"];
+
+function isPublicRequest(request, url) {
+  return (
+    request.method === "GET" &&
+    PUBLIC_PATH_PREFIXES.some((prefix) => url.pathname.startsWith(prefix))
+  );
+}
+
+// The versioned JSON API authenticates clients with its own API keys, sent
+// as a Bearer token, so it skips basic auth and keeps its Authorization header.
+const API_PATH_PREFIX = "/api/v1/";
+
+function isAPIRequest(url) {
+  return url.pathname.startsWith(API_PATH_PREFIX);
+}
+
 export default {
   async fetch(request, env, ctx) {
-    if (!isAuthorized(request, env)) {
+    const url = new URL(request.url);
+
+    const api = isAPIRequest(url);
+    if (!api && !isPublicRequest(request, url) && !isAuthorized(request, env)) {
       return unauthorizedResponse();
     }
 
-    const url = new URL(request.url);
-
     // Build the backend URL
     const backendUrl = new URL(url.pathname + url.search, env.BACKEND_URL);
 
     const forwardHeaders = new Headers(request.headers);
-    if (env.BASIC_AUTH_PASSWORD) {
+    if (env.BASIC_AUTH_PASSWORD && !api) {
       forwardHeaders.delete("authorization");
     }
 
+    // The backend only trusts CF-Connecting-IP from requests carrying the
+    // proxy secret; never pass on one the client sent
+    forwardHeaders.delete("x-proxy-secret");
+    if (env.PROXY_SECRET) {
+      forwardHeaders.set("x-proxy-secret", env.PROXY_SECRET);
+    }
+
     // Clone the request with the new URL
     const backendRequest = new Request(backendUrl, {
       method: request.method,
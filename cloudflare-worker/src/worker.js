@@ -79,6 +79,13 @@ export default {
       forwardHeaders.delete("authorization");
     }
 
+    // The backend only trusts CF-Connecting-IP from requests carrying the
+    // proxy secret; never pass on one the client sent
+    forwardHeaders.delete("x-proxy-secret");
+    if (env.PROXY_SECRET) {
+      forwardHeaders.set("x-proxy-secret", env.PROXY_SECRET);
+    }
+
     // Clone the request with the new URL
     const backendRequest = new Request(backendUrl, {
       method: request.method,
@@ -15,6 +15,7 @@ DB_USER = "postgres"
 DB_PASS = open("/tmp/temple-square-db-pass.txt").read().strip()
 
 DATA_DIR = Path("/Users/justinjones/Developer/temple-square/tmp/data")
+ROSTER_PATH = Path(__file__).resolve().parent.parent / "app/internal/agent/roster.json"
 HEADSHOTS_BASE_URL = "https://storage.googleapis.com/temple-square-assets/headshots"
 
 # Headshot mapping (name_slug -> files exist)
@@ -116,6 +117,20 @@ def load_scriptures(conn):
     return cursor.rowcount
 
 
+def talk_content(data: dict) -> str:
+    """Return a talk's text with paragraphs separated by blank lines."""
+    content = data.get("content", "") or data.get("full_text", "")
+    if not content and "paragraphs" in data:
+        paragraphs = data["paragraphs"]
+        if paragraphs and isinstance(paragraphs[0], str):
+            # Older format: list of strings
+            content = "\n\n".join(paragraphs)
+        elif paragraphs and isinstance(paragraphs[0], dict):
+            # Newer format: list of objects with 'text' key
+            content = "\n\n".join(p.get("text", "") for p in paragraphs)
+    return content
+
+
 def load_talks(conn):
     """Load all talk JSON files into the database."""
     talks_dir = DATA_DIR / "talks"
@@ -154,15 +169,7 @@ def load_talks(conn):
                 }
 
             # Get content from either format
-            content = data.get("content", "") or data.get("full_text", "")
-            if not content and "paragraphs" in data:
-                paragraphs = data["paragraphs"]
-                if paragraphs and isinstance(paragraphs[0], str):
-                    # Older format: list of strings
-                    content = "\n\n".join(paragraphs)
-                elif paragraphs and isinstance(paragraphs[0], dict):
-                    # Newer format: list of objects with 'text' key
-                    content = "\n\n".join(p.get("text", "") for p in paragraphs)
+            content = talk_content(data)
 
             # Parse conference from folder name (e.g., "2024-A" -> "April 2024")
             conf_match = re.match(r'(\d{4})-([AO])', conf_name)
@@ -245,6 +252,167 @@ def load_talks(conn):
     return len(talks_data)
 
 
+def load_callings(conn):
+    """Load the leadership roster into the callings table.
+
+    Later changes in leadership can be made directly in the table; the agent
+    reloads it periodically.
+    """
+    cursor = conn.cursor()
+    cursor.execute(
+        """
+        CREATE TABLE IF NOT EXISTS callings (
+            id SERIAL PRIMARY KEY,
+            speaker_id INTEGER NOT NULL REFERENCES speakers(id),
+            calling TEXT NOT NULL,
+            starts_on DATE NOT NULL,
+            ends_on DATE,
+            UNIQUE (speaker_id, calling, starts_on)
+        )
+        """
+    )
+
+    with open(ROSTER_PATH) as f:
+        roster = json.load(f)
+
+    inserted = 0
+    for entry in roster["callings"]:
+        cursor.execute("SELECT id FROM speakers WHERE name_slug = %s", (entry["name_slug"],))
+        row = cursor.fetchone()
+        if row is None:
+            print(f"  Skipping {entry['name_slug']}: not in speakers")
+            continue
+        cursor.execute(
+            """
+            INSERT INTO callings (speaker_id, calling, starts_on, ends_on)
+            VALUES (%s, %s, %s, %s)
+            ON CONFLICT (speaker_id, calling, starts_on) DO UPDATE SET
+                ends_on = EXCLUDED.ends_on
+            """,
+            (row[0], entry["calling"], entry["starts_on"], entry.get("ends_on") or None)
+        )
+        inserted += 1
+
+    conn.commit()
+    print(f"  Callings loaded: {inserted} processed")
+    return inserted
+
+
+def create_answers_table(conn):
+    """Create the table behind shareable /a/{id} answer links.
+
+    The app saves answers through the answers toolset and purges expired ones
+    itself; nothing is loaded here.
+    """
+    cursor = conn.cursor()
+    cursor.execute(
+        """
+        CREATE TABLE IF NOT EXISTS answers (
+            id TEXT PRIMARY KEY,
+            question TEXT NOT NULL,
+            payload JSONB NOT NULL,
+            created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+            expires_at TIMESTAMPTZ
+        )
+        """
+    )
+    cursor.execute(
+        "CREATE INDEX IF NOT EXISTS answers_expires_at_idx ON answers (expires_at)"
+    )
+    conn.commit()
+    print("  Answers table ready")
+
+
+def load_translations(conn):
+    """Load Spanish and Portuguese talks and scriptures, where available.
+
+    Translations live under DATA_DIR/translations/<language>/ in the same
+    talks/ and scriptures/ layout as the English data. Talks are matched by
+    talk_id and verses by verse_id; the agent only uses them to show cards
+    in the visitor's language, so missing translations are fine.
+    """
+    cursor = conn.cursor()
+    cursor.execute(
+        """
+        CREATE TABLE IF NOT EXISTS talk_translations (
+            talk_id TEXT NOT NULL REFERENCES talks(talk_id),
+            language TEXT NOT NULL,
+            title TEXT NOT NULL,
+            content TEXT NOT NULL,
+            PRIMARY KEY (talk_id, language)
+        )
+        """
+    )
+    cursor.execute(
+        """
+        CREATE TABLE IF NOT EXISTS scripture_translations (
+            verse_id TEXT NOT NULL REFERENCES scriptures(verse_id),
+            language TEXT NOT NULL,
+            book_name TEXT NOT NULL,
+            verse_text TEXT NOT NULL,
+            PRIMARY KEY (verse_id, language)
+        )
+        """
+    )
+
+    for lang in ("es", "pt"):
+        lang_dir = DATA_DIR / "translations" / lang
+        if not lang_dir.is_dir():
+            print(f"  No {lang} translations found")
+            continue
+
+        talks = []
+        for json_file in sorted((lang_dir / "talks").glob("*/*.json")):
+            with open(json_file) as f:
+                data = json.load(f)
+            talk_id = str(data.get("talk_id", data.get("hex_id", data.get("id", json_file.stem))))
+            content = talk_content(data)
+            if content:
+                talks.append((talk_id, lang, str(data.get("title", "")), content))
+
+        verses = []
+        for json_file in sorted((lang_dir / "scriptures").glob("*/*.json")):
+            with open(json_file) as f:
+                data = json.load(f)
+            book_name = data.get("metadata", {}).get("book_name", "")
+            for verse in data.get("chapter_content", {}).get("verses", []):
+                if verse.get("verse_id") and verse.get("verse_text"):
+                    verses.append((verse["verse_id"], lang, book_name, verse["verse_text"]))
+
+        # Translations of talks or verses missing in English are skipped
+        execute_values(
+            cursor,
+            """
+            INSERT INTO talk_translations (talk_id, language, title, content)
+            SELECT v.talk_id, v.language, v.title, v.content
+            FROM (VALUES %s) AS v (talk_id, language, title, content)
+            JOIN talks ON talks.talk_id = v.talk_id
+            ON CONFLICT (talk_id, language) DO UPDATE SET
+                title = EXCLUDED.title,
+                content = EXCLUDED.content
+            """,
+            talks,
+            page_size=100
+        )
+        execute_values(
+            cursor,
+            """
+            INSERT INTO scripture_translations (verse_id, language, book_name, verse_text)
+            SELECT v.verse_id, v.language, v.book_name, v.verse_text
+            FROM (VALUES %s) AS v (verse_id, language, book_name, verse_text)
+            JOIN scriptures ON scriptures.verse_id = v.verse_id
+            ON CONFLICT (verse_id, language) DO UPDATE SET
+                book_name = EXCLUDED.book_name,
+                verse_text = EXCLUDED.verse_text
+            """,
+            verses,
+            page_size=1000
+        )
+        print(f"  {lang}: {len(talks)} talks, {len(verses)} verses processed")
+
+    conn.commit()
+
+
 def main():
     print("Connecting to Cloud SQL...")
     conn = psycopg2.connect(
@@ -261,6 +429,15 @@ def main():
         print("\n2. Loading talks...")
         talk_count = load_talks(conn)
 
+        print("\n3. Loading callings...")
+        load_callings(conn)
+
+        print("\n4. Creating answers table...")
+        create_answers_table(conn)
+
+        print("\n5. Loading translations...")
+        load_translations(conn)
+
         # Print summary
         cursor = conn.cursor()
         cursor.execute("SELECT COUNT(*) FROM scriptures")